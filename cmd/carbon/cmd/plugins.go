@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/lugondev/go-carbon/internal/decoder/spl_token"
+	"github.com/lugondev/go-carbon/pkg/plugin"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pluginsInit bool
+	pluginsJSON bool
+)
+
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "Plugin management commands",
+	Long:  `Commands for inspecting the plugins built into the Carbon CLI.`,
+}
+
+var pluginsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List built-in plugins",
+	Long: `List the built-in plugins with their version, lifecycle state, capabilities
+and dependencies. With --init, the plugins are initialized in dependency order
+and shut down again, reporting the state of any plugin that fails.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry := plugin.NewRegistry()
+		if err := registerBuiltinPlugins(registry); err != nil {
+			return err
+		}
+
+		if pluginsInit {
+			ctx := context.Background()
+			if err := registry.Initialize(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "Initialization failed: %v\n", err)
+			} else if err := registry.Shutdown(ctx); err != nil {
+				fmt.Fprintf(os.Stderr, "Shutdown failed: %v\n", err)
+			}
+		}
+
+		infos := registry.ListPlugins()
+		if pluginsJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(infos)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tVERSION\tSTATE\tCAPABILITIES\tDEPENDENCIES\tDESCRIPTION")
+		for _, info := range infos {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+				info.Name,
+				info.Version,
+				info.State,
+				listOrDash(info.Capabilities),
+				listOrDash(info.Dependencies),
+				info.Description,
+			)
+		}
+		return w.Flush()
+	},
+}
+
+// registerBuiltinPlugins registers the plugins shipped with the CLI.
+func registerBuiltinPlugins(registry *plugin.Registry) error {
+	builtins := []plugin.Plugin{
+		spl_token.NewSPLTokenPlugin(),
+	}
+	for _, p := range builtins {
+		if err := registry.Register(p); err != nil {
+			return fmt.Errorf("failed to register plugin %s: %w", p.Name(), err)
+		}
+	}
+	return nil
+}
+
+func listOrDash(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ",")
+}
+
+func init() {
+	rootCmd.AddCommand(pluginsCmd)
+	pluginsCmd.AddCommand(pluginsListCmd)
+	pluginsListCmd.Flags().BoolVar(&pluginsInit, "init", false, "Initialize and shut down the plugins to report their lifecycle states")
+	pluginsListCmd.Flags().BoolVar(&pluginsJSON, "json", false, "Print the plugins as JSON")
+}
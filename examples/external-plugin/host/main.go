@@ -0,0 +1,77 @@
+// Package main demonstrates running an external plugin with go-carbon.
+//
+// This example shows how to:
+// - Launch a plugin executable and register it like a compiled-in plugin
+// - Decode events with the decoders the plugin provides
+// - Forward decoded events to the plugin for processing
+//
+// Build the sample plugin first, then run the host:
+//
+//	go build -o transfer-plugin ./examples/external-plugin/plugin
+//	go run ./examples/external-plugin/host -plugin ./transfer-plugin
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/pkg/plugin"
+	"github.com/lugondev/go-carbon/pkg/plugin/external"
+)
+
+func main() {
+	pluginPath := flag.String("plugin", "./transfer-plugin", "Path to the plugin executable")
+	flag.Parse()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+	ctx := context.Background()
+
+	p := external.NewPlugin(*pluginPath).
+		WithLogger(logger).
+		WithHealthCheck(5*time.Second, time.Second).
+		WithRestartPolicy(3, time.Second)
+	if err := p.Start(ctx); err != nil {
+		logger.Error("failed to start plugin", "error", err)
+		os.Exit(1)
+	}
+
+	registry := plugin.NewRegistry()
+	registry.MustRegister(p)
+	if err := registry.Initialize(ctx); err != nil {
+		logger.Error("failed to initialize plugins", "error", err)
+		os.Exit(1)
+	}
+	defer registry.Shutdown(ctx)
+
+	for _, info := range registry.ListPlugins() {
+		fmt.Printf("Loaded plugin %s %s (%s)\n", info.Name, info.Version, info.State)
+	}
+
+	event, err := registry.GetDecoderRegistry().Decode(sampleTransfer(), nil)
+	if err != nil || event == nil {
+		logger.Error("failed to decode event", "error", err)
+		return
+	}
+	fmt.Printf("Decoded %s: %v\n", event.Name, event.Data)
+
+	if err := registry.ProcessEvent(ctx, event); err != nil {
+		logger.Error("failed to process event", "error", err)
+	}
+}
+
+// sampleTransfer returns the "Program data:" payload of a TransferEvent.
+func sampleTransfer() []byte {
+	hash := sha256.Sum256([]byte("event:TransferEvent"))
+	data := append([]byte{}, hash[:8]...)
+	data = binary.LittleEndian.AppendUint64(data, 1_000_000)
+	data = append(data, solana.NewWallet().PublicKey().Bytes()...)
+	data = append(data, solana.NewWallet().PublicKey().Bytes()...)
+	return data
+}
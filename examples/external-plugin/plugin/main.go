@@ -0,0 +1,85 @@
+// Package main is a sample external go-carbon plugin.
+//
+// It decodes Anchor "TransferEvent" events (an u64 amount followed by the
+// sender and recipient public keys) and logs the transfers it processes. The
+// host launches it as a separate process; see ../host.
+//
+// Build it with:
+//
+//	go build -o transfer-plugin ./examples/external-plugin/plugin
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/pkg/plugin/external"
+)
+
+// TransferEvent is the event decoded by the plugin.
+type TransferEvent struct {
+	Amount uint64 `json:"amount"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// transferDiscriminator is the Anchor discriminator of TransferEvent.
+var transferDiscriminator = eventDiscriminator("TransferEvent")
+
+func eventDiscriminator(name string) []byte {
+	hash := sha256.Sum256([]byte("event:" + name))
+	return hash[:8]
+}
+
+func decodeTransfer(data []byte) (*TransferEvent, error) {
+	if len(data) < 8+8+32+32 {
+		return nil, errors.New("transfer event too short")
+	}
+	data = data[8:]
+	return &TransferEvent{
+		Amount: binary.LittleEndian.Uint64(data),
+		From:   solana.PublicKeyFromBytes(data[8:40]).String(),
+		To:     solana.PublicKeyFromBytes(data[40:72]).String(),
+	}, nil
+}
+
+func main() {
+	err := external.Serve(&external.Server{
+		Manifest: external.Manifest{
+			Name:        "transfer-plugin",
+			Version:     "1.0.0",
+			Description: "Sample external plugin decoding TransferEvent",
+			Decoders: []external.DecoderSpec{{
+				Name:          "TransferEvent",
+				Discriminator: transferDiscriminator,
+			}},
+			EventTypes: []string{"TransferEvent"},
+		},
+		Decode: func(_ string, data []byte) (*external.DecodedEvent, error) {
+			transfer, err := decodeTransfer(data)
+			if err != nil {
+				return nil, err
+			}
+			return external.NewDecodedEvent("TransferEvent", transfer)
+		},
+		ProcessEvent: func(_ context.Context, event *external.EventMessage) (bool, error) {
+			var transfer TransferEvent
+			if err := json.Unmarshal(event.Data, &transfer); err != nil {
+				return false, err
+			}
+			// Plugins log to stderr; stdout carries the handshake.
+			fmt.Fprintf(os.Stderr, "transfer of %d from %s to %s\n", transfer.Amount, transfer.From, transfer.To)
+			return true, nil
+		},
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -17,12 +17,15 @@ package account
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	"github.com/lugondev/go-carbon/internal/datasource"
 	"github.com/lugondev/go-carbon/internal/filter"
 	"github.com/lugondev/go-carbon/internal/metrics"
 	"github.com/lugondev/go-carbon/internal/processor"
+	"github.com/lugondev/go-carbon/internal/tracing"
 	"github.com/lugondev/go-carbon/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // AccountMetadata holds metadata for an account update, including the slot and public key.
@@ -191,7 +194,12 @@ func (p *AccountPipe[T]) Run(
 	)
 
 	// Attempt to decode the account
+	_, decodeSpan := tracing.Start(ctx, tracing.SpanDecodeAccount, func() []attribute.KeyValue {
+		return []attribute.KeyValue{tracing.Pubkey(metadata.Pubkey), tracing.Slot(metadata.Slot)}
+	})
 	decodedAccount := p.Decoder.DecodeAccount(account)
+	decodeSpan.End()
+	metrics.RecordPipeDecode(ctx, metricsCollection, decodedAccount != nil)
 	if decodedAccount == nil {
 		// Account doesn't match this decoder, skip processing
 		return nil
@@ -204,7 +212,12 @@ func (p *AccountPipe[T]) Run(
 		RawAccount:     account,
 	}
 
-	return p.Processor.Process(ctx, input, metricsCollection)
+	processCtx, span := tracing.Start(ctx, tracing.SpanProcess, nil)
+	start := time.Now()
+	err := p.Processor.Process(processCtx, input, metricsCollection)
+	metrics.RecordPipeProcess(ctx, metricsCollection, time.Since(start), err)
+	tracing.End(span, err)
+	return err
 }
 
 // AccountPipeRunner is an interface for running account pipes.
@@ -0,0 +1,20 @@
+package account
+
+import (
+	"fmt"
+
+	"github.com/lugondev/go-carbon/pkg/decoder"
+)
+
+// NewIDLAccountDecoder returns an AccountDecoder decoding the accounts owned by
+// the program described by an IDL at runtime, without generated code. The
+// program ID is the IDL address.
+func NewIDLAccountDecoder(idl *decoder.IDLDecoder) *ProgramAccountDecoder[*decoder.IDLValue] {
+	return NewProgramAccountDecoder(idl.ProgramID(), func(data []byte) (*decoder.IDLValue, error) {
+		value, err := idl.DecodeAccountData(data)
+		if err == nil && value == nil {
+			err = fmt.Errorf("unknown account discriminator")
+		}
+		return value, err
+	})
+}
@@ -0,0 +1,248 @@
+// Package admin provides an HTTP server exposing health, readiness, status and
+// profiling endpoints for a running pipeline.
+//
+// The server exposes:
+//
+//   - /healthz: liveness, OK while the pipeline is running.
+//   - /readyz: readiness, OK when all datasources are running and healthy, the
+//     slot lag and block time staleness are under their thresholds and every
+//     registered check, such as a storage Ping, succeeds.
+//   - /status: a JSON snapshot of datasources, pipes, queue depth and slots.
+//   - /metrics: the metrics handler, e.g. of a Prometheus backend, if set.
+//   - /debug/pprof/: the Go profiler, if enabled.
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lugondev/go-carbon/internal/pipeline"
+)
+
+// DefaultAddr is the default address of the admin server.
+const DefaultAddr = ":8081"
+
+// DefaultCheckTimeout is the default timeout of the readiness checks.
+const DefaultCheckTimeout = 5 * time.Second
+
+// Config holds the configuration for the admin server.
+type Config struct {
+	// Addr is the listen address of the admin server.
+	Addr string
+
+	// MaxSlotLag is the maximum slot lag for the pipeline to be ready.
+	// Zero disables the check.
+	MaxSlotLag uint64
+
+	// MaxStaleness is the maximum age of the latest block time for the
+	// pipeline to be ready. Zero disables the check.
+	MaxStaleness time.Duration
+
+	// CheckTimeout bounds the time readiness checks may take.
+	CheckTimeout time.Duration
+
+	// MetricsHandler serves /metrics, e.g. PrometheusMetrics.Handler().
+	// The endpoint is not registered if nil.
+	MetricsHandler http.Handler
+
+	// EnablePprof registers the /debug/pprof/ endpoints.
+	EnablePprof bool
+}
+
+// DefaultConfig returns a default configuration.
+func DefaultConfig() *Config {
+	return &Config{
+		Addr:         DefaultAddr,
+		CheckTimeout: DefaultCheckTimeout,
+	}
+}
+
+// Pinger is implemented by dependencies that can be pinged, such as
+// storage.Repository.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Check is a named readiness check.
+type Check func(ctx context.Context) error
+
+// Server is the admin HTTP server of a pipeline.
+type Server struct {
+	config   *Config
+	pipeline *pipeline.Pipeline
+	checks   map[string]Check
+	server   *http.Server
+	logger   *slog.Logger
+	mu       sync.RWMutex
+}
+
+// NewServer creates a new admin Server for p.
+func NewServer(p *pipeline.Pipeline, config *Config) *Server {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if config.CheckTimeout <= 0 {
+		config.CheckTimeout = DefaultCheckTimeout
+	}
+
+	return &Server{
+		config:   config,
+		pipeline: p,
+		checks:   make(map[string]Check),
+		logger:   slog.Default(),
+	}
+}
+
+// WithLogger sets a custom logger.
+func (s *Server) WithLogger(logger *slog.Logger) *Server {
+	s.logger = logger
+	return s
+}
+
+// AddCheck registers a readiness check.
+func (s *Server) AddCheck(name string, check Check) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checks[name] = check
+	return s
+}
+
+// AddPinger registers a readiness check that pings a dependency, such as storage.
+func (s *Server) AddPinger(name string, pinger Pinger) *Server {
+	return s.AddCheck(name, pinger.Ping)
+}
+
+// Handler returns the HTTP handler serving the admin endpoints.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.handleHealthz)
+	mux.HandleFunc("/readyz", s.handleReadyz)
+	mux.HandleFunc("/status", s.handleStatus)
+
+	if s.config.MetricsHandler != nil {
+		mux.Handle("/metrics", s.config.MetricsHandler)
+	}
+
+	if s.config.EnablePprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	return mux
+}
+
+// Start starts the admin server in the background.
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.config.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.config.Addr, err)
+	}
+
+	s.server = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.logger.Error("admin server failed", "error", err)
+		}
+	}()
+
+	s.logger.Info("admin server started", "addr", listener.Addr().String())
+	return nil
+}
+
+// Shutdown gracefully stops the admin server.
+func (s *Server) Shutdown(ctx context.Context) error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Shutdown(ctx)
+}
+
+// CheckResult is the outcome of a readiness check.
+type CheckResult struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// Ready runs the readiness checks and reports whether all of them passed.
+func (s *Server) Ready(ctx context.Context) (bool, []CheckResult) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.CheckTimeout)
+	defer cancel()
+
+	checks := map[string]Check{
+		"datasources": s.pipeline.CheckDatasources,
+		"freshness": func(context.Context) error {
+			return s.pipeline.Slots.Status().CheckFreshness(s.config.MaxSlotLag, s.config.MaxStaleness)
+		},
+	}
+
+	s.mu.RLock()
+	for name, check := range s.checks {
+		checks[name] = check
+	}
+	s.mu.RUnlock()
+
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	ready := true
+	results := make([]CheckResult, 0, len(names))
+	for _, name := range names {
+		result := CheckResult{Name: name, OK: true}
+		if err := checks[name](ctx); err != nil {
+			result.OK = false
+			result.Error = err.Error()
+			ready = false
+		}
+		results = append(results, result)
+	}
+	return ready, results
+}
+
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	if !s.pipeline.Status().Running {
+		http.Error(w, "pipeline not running", http.StatusServiceUnavailable)
+		return
+	}
+	_, _ = w.Write([]byte("ok"))
+}
+
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ready, results := s.Ready(r.Context())
+
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, map[string]any{"ready": ready, "checks": results})
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.pipeline.Status())
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
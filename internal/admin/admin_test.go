@@ -0,0 +1,90 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/internal/metrics"
+	"github.com/lugondev/go-carbon/internal/pipeline"
+)
+
+// blockingDatasource consumes until its context is done.
+type blockingDatasource struct{}
+
+func (blockingDatasource) Consume(ctx context.Context, id datasource.DatasourceID, updates chan<- datasource.UpdateWithSource, m *metrics.Collection) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (blockingDatasource) UpdateTypes() []datasource.UpdateType { return nil }
+
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) Ping(ctx context.Context) error { return f(ctx) }
+
+func get(t *testing.T, handler http.Handler, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestServerEndpoints(t *testing.T) {
+	p := pipeline.Builder().
+		Datasource(datasource.NewNamedDatasourceID("rpc"), blockingDatasource{}).
+		Build()
+
+	var storageErr error
+	server := NewServer(p, &Config{EnablePprof: true}).
+		AddPinger("storage", pingerFunc(func(context.Context) error { return storageErr }))
+	handler := server.Handler()
+
+	if rec := get(t, handler, "/healthz"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("healthz before run = %d, want 503", rec.Code)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = p.Run(ctx) }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for ready, _ := server.Ready(ctx); !ready; ready, _ = server.Ready(ctx) {
+		if time.Now().After(deadline) {
+			t.Fatal("pipeline did not become ready")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if rec := get(t, handler, "/healthz"); rec.Code != http.StatusOK {
+		t.Errorf("healthz = %d, want 200", rec.Code)
+	}
+	if rec := get(t, handler, "/readyz"); rec.Code != http.StatusOK {
+		t.Errorf("readyz = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	storageErr = errors.New("connection refused")
+	if rec := get(t, handler, "/readyz"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("readyz with failing storage = %d, want 503", rec.Code)
+	}
+
+	var status pipeline.Status
+	if err := json.NewDecoder(get(t, handler, "/status").Body).Decode(&status); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	if len(status.Datasources) != 1 || status.Datasources[0].State != pipeline.DatasourceStateRunning {
+		t.Errorf("unexpected datasources %+v", status.Datasources)
+	}
+
+	if rec := get(t, handler, "/debug/pprof/"); rec.Code != http.StatusOK {
+		t.Errorf("pprof = %d, want 200", rec.Code)
+	}
+	if rec := get(t, handler, "/metrics"); rec.Code != http.StatusNotFound {
+		t.Errorf("metrics without handler = %d, want 404", rec.Code)
+	}
+}
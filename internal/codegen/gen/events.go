@@ -7,6 +7,10 @@ import (
 	"github.com/lugondev/go-carbon/internal/codegen"
 )
 
+// eventIxTag is the tag Anchor's emit_cpi! prefixes to event instruction data,
+// sha256("anchor:event")[..8].
+var eventIxTag = []byte{0xe4, 0x45, 0xa5, 0x2e, 0x51, 0xcb, 0x9a, 0x1d}
+
 // EventsGenerator generates event type definitions and decoders.
 type EventsGenerator struct {
 	*Generator
@@ -23,6 +27,9 @@ func (g *EventsGenerator) Generate() error {
 		return nil
 	}
 
+	// Generate emit_cpi! support shared by the event decoders
+	g.generateEventIxTag()
+
 	// Generate each event definition
 	for _, event := range g.IDL.Events {
 		if err := g.generateEvent(event); err != nil {
@@ -66,6 +73,32 @@ func (g *EventsGenerator) generateEvent(event codegen.IDLEvent) error {
 	return nil
 }
 
+// generateEventIxTag generates the emit_cpi! event instruction tag and the
+// helper that strips it, so event decoders accept payloads of both emit! logs
+// and emit_cpi! self-CPI instruction data.
+func (g *EventsGenerator) generateEventIxTag() {
+	g.File.Comment("EventIxTag prefixes the instruction data of events emitted with emit_cpi!.")
+	g.File.Var().Id("EventIxTag").Op("=").Add(DiscriminatorToBytes(eventIxTag))
+	g.File.Line()
+
+	g.File.Comment("stripEventIxTag returns the event payload of emit_cpi! instruction data,")
+	g.File.Comment("or data unchanged if it is a \"Program data:\" log payload.")
+	g.File.Func().Id("stripEventIxTag").Params(
+		jen.Id("data").Index().Byte(),
+	).Index().Byte().Block(
+		jen.If(
+			jen.Len(jen.Id("data")).Op(">=").Lit(16).Op("&&").Qual("bytes", "Equal").Call(
+				jen.Id("data").Index(jen.Op(":").Lit(8)),
+				jen.Id("EventIxTag"),
+			),
+		).Block(
+			jen.Return(jen.Id("data").Index(jen.Lit(8).Op(":"))),
+		),
+		jen.Return(jen.Id("data")),
+	)
+	g.File.Line()
+}
+
 // generateEventStruct generates the event struct type.
 func (g *EventsGenerator) generateEventStruct(eventName string, event codegen.IDLEvent) error {
 	if len(event.Fields) == 0 {
@@ -101,12 +134,16 @@ func (g *EventsGenerator) generateEventDecode(eventName string, event codegen.ID
 	discConst := eventName + "EventDiscriminator"
 
 	g.File.Comment(fmt.Sprintf("Decode%sEvent decodes event data into %s.", eventName, eventTypeName))
+	g.File.Comment("It accepts payloads of both emit! logs and emit_cpi! instructions.")
 	g.File.Func().Id("Decode"+eventName+"Event").Params(
 		jen.Id("data").Index().Byte(),
 	).Params(
 		jen.Op("*").Id(eventTypeName),
 		jen.Error(),
 	).Block(
+		jen.Id("data").Op("=").Id("stripEventIxTag").Call(jen.Id("data")),
+		jen.Line(),
+
 		// Check minimum length (8 bytes discriminator)
 		jen.If(jen.Len(jen.Id("data")).Op("<").Lit(8)).Block(
 			jen.Return(jen.Nil(), jen.Qual("fmt", "Errorf").Call(jen.Lit("event data too short"))),
@@ -153,13 +190,17 @@ func (g *EventsGenerator) generateEventDecode(eventName string, event codegen.ID
 // generateEventParser generates a unified event parser.
 func (g *EventsGenerator) generateEventParser() error {
 	g.File.Comment("ParseEvent parses an event from raw data.")
-	g.File.Comment("It uses the discriminator (first 8 bytes) to identify the event type.")
+	g.File.Comment("It uses the discriminator (first 8 bytes) to identify the event type,")
+	g.File.Comment("after the EventIxTag of emit_cpi! instruction data if present.")
 	g.File.Func().Id("ParseEvent").Params(
 		jen.Id("data").Index().Byte(),
 	).Params(
 		jen.Interface(),
 		jen.Error(),
 	).Block(
+		jen.Id("data").Op("=").Id("stripEventIxTag").Call(jen.Id("data")),
+		jen.Line(),
+
 		// Check minimum length
 		jen.If(jen.Len(jen.Id("data")).Op("<").Lit(8)).Block(
 			jen.Return(jen.Nil(), jen.Qual("fmt", "Errorf").Call(jen.Lit("event data too short"))),
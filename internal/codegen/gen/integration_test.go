@@ -287,3 +287,25 @@ func TestDebugTypesGeneration(t *testing.T) {
 		t.Errorf("Expected 'type UserData struct' in generated code")
 	}
 }
+
+func TestGenerateProgramFile_RegistersErrors(t *testing.T) {
+	idl := &codegen.IDL{
+		Address:  "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA",
+		Metadata: codegen.IDLMetadata{Name: "test_program"},
+		Errors:   []codegen.IDLError{{Code: 6000, Name: "SlippageExceeded", Msg: "Slippage exceeded"}},
+	}
+
+	tmpDir := t.TempDir()
+	if err := gen.GenerateProgramFile(idl, "test", tmpDir); err != nil {
+		t.Fatalf("GenerateProgramFile failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "program.go"))
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+
+	if !strings.Contains(string(content), "types.RegisterProgramErrors(ProgramID, ErrorNames, ErrorMessages)") {
+		t.Errorf("Expected generated errors to be registered in init:\n%s", content)
+	}
+}
@@ -3,6 +3,7 @@ package gen
 import (
 	"fmt"
 
+	"github.com/dave/jennifer/jen"
 	"github.com/lugondev/go-carbon/internal/codegen"
 )
 
@@ -26,6 +27,9 @@ func (g *ProgramGenerator) Generate() error {
 	// Generate program metadata
 	g.generateProgramMetadata()
 
+	// Generate program error maps
+	g.generateProgramErrors()
+
 	return nil
 }
 
@@ -79,6 +83,38 @@ func (g *ProgramGenerator) generateProgramMetadata() {
 	}
 }
 
+// generateProgramErrors generates the ErrorNames and ErrorMessages maps and
+// an init function registering them, so failed transactions of the program
+// resolve their error names.
+func (g *ProgramGenerator) generateProgramErrors() {
+	if len(g.IDL.Errors) == 0 {
+		return
+	}
+
+	names := make(jen.Dict)
+	messages := make(jen.Dict)
+	for _, e := range g.IDL.Errors {
+		names[jen.Lit(uint32(e.Code))] = jen.Lit(e.Name)
+		if e.Msg != "" {
+			messages[jen.Lit(uint32(e.Code))] = jen.Lit(e.Msg)
+		}
+	}
+
+	g.File.Comment("ErrorNames maps custom program error codes to their IDL names.")
+	g.File.Var().Id("ErrorNames").Op("=").Map(jen.Uint32()).String().Values(names)
+	g.File.Line()
+
+	g.File.Comment("ErrorMessages maps custom program error codes to their IDL messages.")
+	g.File.Var().Id("ErrorMessages").Op("=").Map(jen.Uint32()).String().Values(messages)
+	g.File.Line()
+
+	g.File.Func().Id("init").Params().Block(
+		jen.Qual("github.com/lugondev/go-carbon/pkg/types", "RegisterProgramErrors").
+			Call(jen.Id("ProgramID"), jen.Id("ErrorNames"), jen.Id("ErrorMessages")),
+	)
+	g.File.Line()
+}
+
 // GenerateProgramFile generates the program.go file.
 func GenerateProgramFile(idl *codegen.IDL, packageName, outputDir string) error {
 	gen := NewGenerator(idl, packageName)
@@ -1,25 +1,13 @@
 package codegen
 
-import (
-	"encoding/json"
-	"fmt"
-	"os"
-)
+import "github.com/lugondev/go-carbon/pkg/idl"
 
+// ParseIDLFile reads and parses the IDL JSON file at filePath.
 func ParseIDLFile(filePath string) (*IDL, error) {
-	data, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to read IDL file: %w", err)
-	}
-
-	return ParseIDL(data)
+	return idl.ParseFile(filePath)
 }
 
+// ParseIDL parses IDL JSON.
 func ParseIDL(data []byte) (*IDL, error) {
-	var idl IDL
-	if err := json.Unmarshal(data, &idl); err != nil {
-		return nil, fmt.Errorf("failed to parse IDL JSON: %w", err)
-	}
-
-	return &idl, nil
+	return idl.Parse(data)
 }
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/lugondev/go-carbon/internal/filter"
+	"github.com/lugondev/go-carbon/internal/filter/expr"
 	"github.com/spf13/viper"
 )
 
@@ -12,6 +14,7 @@ type Config struct {
 	Solana   SolanaConfig   `mapstructure:"solana"`
 	Log      LogConfig      `mapstructure:"log"`
 	Database DatabaseConfig `mapstructure:"database"`
+	Filters  []FilterConfig `mapstructure:"filters"`
 }
 
 // SolanaConfig holds Solana-specific configuration
@@ -70,6 +73,12 @@ type MySQLConfig struct {
 	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime"`
 }
 
+// FilterConfig holds a named filter expression
+type FilterConfig struct {
+	Name string `mapstructure:"name"`
+	Expr string `mapstructure:"expr"` // see internal/filter/expr for the syntax
+}
+
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
@@ -150,6 +159,26 @@ func Load(configPath string) (*Config, error) {
 	return cfg, nil
 }
 
+// CompileFilters compiles the configured filter expressions, keyed by name
+func (c *Config) CompileFilters() (map[string]filter.Filter, error) {
+	filters := make(map[string]filter.Filter, len(c.Filters))
+	for i, fc := range c.Filters {
+		if fc.Name == "" {
+			return nil, fmt.Errorf("filter %d: name is required", i)
+		}
+		if _, exists := filters[fc.Name]; exists {
+			return nil, fmt.Errorf("filter %q: duplicate name", fc.Name)
+		}
+
+		compiled, err := expr.Compile(fc.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("filter %q: %w", fc.Name, err)
+		}
+		filters[fc.Name] = compiled
+	}
+	return filters, nil
+}
+
 // GetRPCEndpoint returns the RPC endpoint for the configured network
 func (c *SolanaConfig) GetRPCEndpoint() string {
 	if c.RPC != "" {
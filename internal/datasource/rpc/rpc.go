@@ -57,6 +57,11 @@ func DefaultConfig(rpcURL string) *Config {
 	}
 }
 
+// sourceLabels returns the metric labels identifying a datasource.
+func sourceLabels(id datasource.DatasourceID) metrics.Labels {
+	return metrics.Labels{metrics.LabelDatasourceID: id.String()}
+}
+
 // AccountMonitorDatasource monitors specific accounts for changes.
 type AccountMonitorDatasource struct {
 	config   *Config
@@ -122,7 +127,7 @@ func (d *AccountMonitorDatasource) Consume(
 		case <-ticker.C:
 			if err := d.fetchAccounts(ctx, id, updates, m); err != nil {
 				d.logger.Error("failed to fetch accounts", "error", err)
-				_ = m.IncrementCounter(ctx, "rpc_fetch_errors", 1)
+				_ = m.IncrementCounterWith(ctx, "rpc_fetch_errors", sourceLabels(id), 1)
 			}
 		}
 	}
@@ -193,7 +198,7 @@ func (d *AccountMonitorDatasource) fetchAccounts(
 				"pubkey", pubkey.String(),
 				"slot", currentSlot,
 			)
-			_ = m.IncrementCounter(ctx, "rpc_account_updates", 1)
+			_ = m.IncrementCounterWith(ctx, "rpc_account_updates", sourceLabels(id), 1)
 		case <-ctx.Done():
 			return ctx.Err()
 		}
@@ -233,6 +238,11 @@ func (d *AccountMonitorDatasource) getAccountInfoWithRetry(
 	return nil, fmt.Errorf("failed after %d retries: %w", d.config.MaxRetries, lastErr)
 }
 
+// CheckHealth reports whether the RPC node is healthy.
+func (d *AccountMonitorDatasource) CheckHealth(ctx context.Context) error {
+	return checkHealth(ctx, d.client)
+}
+
 // UpdateTypes returns the types of updates this datasource can provide.
 func (d *AccountMonitorDatasource) UpdateTypes() []datasource.UpdateType {
 	return []datasource.UpdateType{datasource.UpdateTypeAccount}
@@ -327,7 +337,7 @@ func (d *TransactionFetcherDatasource) Consume(
 			d.logger.Debug("sent transaction update",
 				"signature", sig.String(),
 			)
-			_ = m.IncrementCounter(ctx, "rpc_transaction_updates", 1)
+			_ = m.IncrementCounterWith(ctx, "rpc_transaction_updates", sourceLabels(id), 1)
 		case <-ctx.Done():
 			return ctx.Err()
 		}
@@ -402,6 +412,35 @@ func (d *TransactionFetcherDatasource) convertTransaction(
 	return &update, nil
 }
 
+// RefetchTransaction fetches a transaction by signature. It implements
+// pipeline.TransactionRefetcher, so a fetcher for an RPC node with a higher
+// log size limit can recover transactions whose logs were truncated.
+func (d *TransactionFetcherDatasource) RefetchTransaction(
+	ctx context.Context,
+	signature types.Signature,
+) (*datasource.TransactionUpdate, error) {
+	sig := solana.Signature(signature)
+
+	result, err := d.getTransactionWithRetry(ctx, sig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to refetch transaction %s: %w", sig, err)
+	}
+	if result == nil {
+		return nil, fmt.Errorf("transaction %s not found", sig)
+	}
+
+	update, err := d.convertTransaction(result, sig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert transaction %s: %w", sig, err)
+	}
+	return update.Transaction, nil
+}
+
+// CheckHealth reports whether the RPC node is healthy.
+func (d *TransactionFetcherDatasource) CheckHealth(ctx context.Context) error {
+	return checkHealth(ctx, d.client)
+}
+
 // UpdateTypes returns the types of updates this datasource can provide.
 func (d *TransactionFetcherDatasource) UpdateTypes() []datasource.UpdateType {
 	return []datasource.UpdateType{datasource.UpdateTypeTransaction}
@@ -503,7 +542,7 @@ func (d *SlotMonitorDatasource) checkSlot(
 	select {
 	case updates <- update:
 		d.logger.Debug("sent block details update", "slot", slot)
-		_ = m.IncrementCounter(ctx, "rpc_block_updates", 1)
+		_ = m.IncrementCounterWith(ctx, "rpc_block_updates", sourceLabels(id), 1)
 	case <-ctx.Done():
 		return ctx.Err()
 	}
@@ -511,13 +550,66 @@ func (d *SlotMonitorDatasource) checkSlot(
 	return nil
 }
 
+// CheckHealth reports whether the RPC node is healthy.
+func (d *SlotMonitorDatasource) CheckHealth(ctx context.Context) error {
+	return checkHealth(ctx, d.client)
+}
+
 // UpdateTypes returns the types of updates this datasource can provide.
 func (d *SlotMonitorDatasource) UpdateTypes() []datasource.UpdateType {
 	return []datasource.UpdateType{datasource.UpdateTypeBlockDetails}
 }
 
+// CurrentSlot returns the last slot observed by the monitor, so it can serve as
+// the pipeline's slot reference without additional RPC calls.
+func (d *SlotMonitorDatasource) CurrentSlot(ctx context.Context) (uint64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.lastSlot == 0 {
+		return 0, fmt.Errorf("no slot observed yet")
+	}
+	return d.lastSlot, nil
+}
+
+// SlotProbe reports the current slot of an RPC endpoint with getSlot. It is
+// used as the pipeline's slot reference when no SlotMonitorDatasource runs.
+type SlotProbe struct {
+	config *Config
+	client *rpc.Client
+}
+
+// NewSlotProbe creates a new SlotProbe.
+func NewSlotProbe(config *Config) *SlotProbe {
+	return &SlotProbe{
+		config: config,
+		client: rpc.New(config.RPCURL),
+	}
+}
+
+// CurrentSlot returns the current slot of the RPC endpoint.
+func (p *SlotProbe) CurrentSlot(ctx context.Context) (uint64, error) {
+	slot, err := p.client.GetSlot(ctx, p.config.CommitmentLevel)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get slot: %w", err)
+	}
+	return slot, nil
+}
+
 // Helper functions
 
+// checkHealth calls getHealth on the RPC node.
+func checkHealth(ctx context.Context, client *rpc.Client) error {
+	health, err := client.GetHealth(ctx)
+	if err != nil {
+		return fmt.Errorf("rpc node unhealthy: %w", err)
+	}
+	if health != rpc.HealthOk {
+		return fmt.Errorf("rpc node unhealthy: %s", health)
+	}
+	return nil
+}
+
 // convertAccount converts a solana-go Account to a carbon types.Account.
 func convertAccount(acc *rpc.Account) types.Account {
 	if acc == nil {
@@ -552,6 +644,11 @@ func convertTransactionMeta(meta *rpc.TransactionMeta) types.TransactionStatusMe
 		InnerInstructions: make([]types.InnerInstructions, 0),
 	}
 
+	// Convert the transaction error into its structured form
+	if txErr := types.ParseTransactionError(meta.Err); txErr != nil {
+		result.Err = txErr
+	}
+
 	// Convert inner instructions
 	if meta.InnerInstructions != nil {
 		for _, inner := range meta.InnerInstructions {
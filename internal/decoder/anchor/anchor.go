@@ -1,7 +1,8 @@
 // Package anchor provides a generic Anchor event decoder plugin.
 //
 // Anchor programs emit events with an 8-byte discriminator followed by the event data.
-// This plugin provides utilities to decode Anchor events from "Program data:" logs.
+// This plugin provides utilities to decode Anchor events from "Program data:" logs
+// written by emit! and from the self-CPI instructions of emit_cpi!.
 //
 // Example usage:
 //
@@ -123,8 +124,10 @@ func NewAnchorEventDecoder(
 	}
 }
 
-// Decode implements Decoder interface.
+// Decode implements Decoder interface. It accepts payloads of both emit! and
+// emit_cpi! events.
 func (d *AnchorEventDecoder) Decode(data []byte) (*decoder.Event, error) {
+	data = decoder.StripEventIxTag(data)
 	if !d.CanDecode(data) {
 		return nil, fmt.Errorf("discriminator mismatch for event %s", d.name)
 	}
@@ -149,6 +152,7 @@ func (d *AnchorEventDecoder) Decode(data []byte) (*decoder.Event, error) {
 
 // CanDecode implements Decoder interface.
 func (d *AnchorEventDecoder) CanDecode(data []byte) bool {
+	data = decoder.StripEventIxTag(data)
 	if len(data) < 8 {
 		return false
 	}
@@ -167,6 +171,11 @@ func (d *AnchorEventDecoder) GetProgramID() solana.PublicKey {
 	return d.programID
 }
 
+// Discriminator implements decoder.DiscriminatedDecoder interface.
+func (d *AnchorEventDecoder) Discriminator() []byte {
+	return d.discriminator[:]
+}
+
 // ComputeDiscriminator computes the Anchor event discriminator from event name.
 // Anchor uses: sha256("event:{EventName}")[..8]
 // This is a simplified version - in production, use proper sha256 hashing.
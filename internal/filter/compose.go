@@ -0,0 +1,330 @@
+package filter
+
+import (
+	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+// UpdateKind is a bit set of the update kinds a filter constrains.
+type UpdateKind uint8
+
+const (
+	// KindAccount marks filters that constrain account updates.
+	KindAccount UpdateKind = 1 << iota
+
+	// KindInstruction marks filters that constrain instructions.
+	KindInstruction
+
+	// KindTransaction marks filters that constrain transactions.
+	KindTransaction
+
+	// KindAccountDeletion marks filters that constrain account deletions.
+	KindAccountDeletion
+
+	// KindBlockDetails marks filters that constrain block details.
+	KindBlockDetails
+
+	// KindAll marks filters that constrain every update kind.
+	KindAll = KindAccount | KindInstruction | KindTransaction | KindAccountDeletion | KindBlockDetails
+)
+
+// KindScoped is implemented by filters that only constrain some update kinds
+// and let every other kind pass.
+//
+// Combinators use it so that, for example, Not(NewProgramFilter(...)) negates
+// the program check for instructions and transactions without rejecting every
+// account update. Filters that do not implement KindScoped are assumed to
+// constrain all update kinds.
+type KindScoped interface {
+	FilterKinds() UpdateKind
+}
+
+// KindsOf returns the update kinds f constrains.
+func KindsOf(f Filter) UpdateKind {
+	if scoped, ok := f.(KindScoped); ok {
+		return scoped.FilterKinds()
+	}
+	return KindAll
+}
+
+// AllOf returns a filter that passes an update only if all filters pass it.
+func AllOf(filters ...Filter) *FilterChain {
+	return NewFilterChain(filters...)
+}
+
+// And returns a filter that passes an update only if both a and b pass it.
+func And(a, b Filter) *FilterChain {
+	return AllOf(a, b)
+}
+
+// FilterKinds returns the union of the update kinds of the chained filters.
+func (c *FilterChain) FilterKinds() UpdateKind {
+	var kinds UpdateKind
+	for _, f := range c.filters {
+		kinds |= KindsOf(f)
+	}
+	return kinds
+}
+
+// AnyOfFilter passes an update if any of its filters that constrain the
+// update's kind pass it. Updates of a kind none of its filters constrain pass.
+type AnyOfFilter struct {
+	filters []Filter
+}
+
+// AnyOf returns a filter that passes an update if any of the filters pass it.
+func AnyOf(filters ...Filter) *AnyOfFilter {
+	return &AnyOfFilter{filters: filters}
+}
+
+// Or returns a filter that passes an update if a or b passes it.
+func Or(a, b Filter) *AnyOfFilter {
+	return AnyOf(a, b)
+}
+
+// FilterKinds returns the union of the update kinds of the filters.
+func (f *AnyOfFilter) FilterKinds() UpdateKind {
+	var kinds UpdateKind
+	for _, inner := range f.filters {
+		kinds |= KindsOf(inner)
+	}
+	return kinds
+}
+
+// ProgramIDs returns the union of the program IDs of the filters. It returns
+// false if any filter is not scoped to known programs.
+func (f *AnyOfFilter) ProgramIDs() ([]types.Pubkey, bool) {
+	var programIDs []types.Pubkey
+	for _, inner := range f.filters {
+		scoped, ok := inner.(interface {
+			ProgramIDs() ([]types.Pubkey, bool)
+		})
+		if !ok {
+			return nil, false
+		}
+		ids, ok := scoped.ProgramIDs()
+		if !ok {
+			return nil, false
+		}
+		programIDs = append(programIDs, ids...)
+	}
+	return programIDs, true
+}
+
+// any reports whether any filter of the given kind passes, or true if none
+// of the filters constrain that kind.
+func (f *AnyOfFilter) any(kind UpdateKind, check func(Filter) bool) bool {
+	applicable := false
+	for _, inner := range f.filters {
+		if KindsOf(inner)&kind == 0 {
+			continue
+		}
+		applicable = true
+		if check(inner) {
+			return true
+		}
+	}
+	return !applicable
+}
+
+func (f *AnyOfFilter) FilterAccount(datasourceID datasource.DatasourceID, accountMetadata *AccountMetadata, account *types.Account) bool {
+	return f.any(KindAccount, func(inner Filter) bool {
+		return inner.FilterAccount(datasourceID, accountMetadata, account)
+	})
+}
+
+func (f *AnyOfFilter) FilterInstruction(datasourceID datasource.DatasourceID, nestedInstruction NestedInstruction) bool {
+	return f.any(KindInstruction, func(inner Filter) bool {
+		return inner.FilterInstruction(datasourceID, nestedInstruction)
+	})
+}
+
+func (f *AnyOfFilter) FilterTransaction(datasourceID datasource.DatasourceID, transactionMetadata TransactionMetadata, nestedInstructions NestedInstructions) bool {
+	return f.any(KindTransaction, func(inner Filter) bool {
+		return inner.FilterTransaction(datasourceID, transactionMetadata, nestedInstructions)
+	})
+}
+
+func (f *AnyOfFilter) FilterAccountDeletion(datasourceID datasource.DatasourceID, accountDeletion *datasource.AccountDeletion) bool {
+	return f.any(KindAccountDeletion, func(inner Filter) bool {
+		return inner.FilterAccountDeletion(datasourceID, accountDeletion)
+	})
+}
+
+func (f *AnyOfFilter) FilterBlockDetails(datasourceID datasource.DatasourceID, blockDetails *datasource.BlockDetails) bool {
+	return f.any(KindBlockDetails, func(inner Filter) bool {
+		return inner.FilterBlockDetails(datasourceID, blockDetails)
+	})
+}
+
+// NotFilter negates a filter for the update kinds it constrains and passes
+// updates of every other kind.
+type NotFilter struct {
+	filter Filter
+	kinds  UpdateKind
+}
+
+// Not returns a filter that negates f.
+func Not(f Filter) *NotFilter {
+	return &NotFilter{filter: f, kinds: KindsOf(f)}
+}
+
+// FilterKinds returns the update kinds of the negated filter.
+func (f *NotFilter) FilterKinds() UpdateKind {
+	return f.kinds
+}
+
+func (f *NotFilter) FilterAccount(datasourceID datasource.DatasourceID, accountMetadata *AccountMetadata, account *types.Account) bool {
+	return f.kinds&KindAccount == 0 || !f.filter.FilterAccount(datasourceID, accountMetadata, account)
+}
+
+func (f *NotFilter) FilterInstruction(datasourceID datasource.DatasourceID, nestedInstruction NestedInstruction) bool {
+	return f.kinds&KindInstruction == 0 || !f.filter.FilterInstruction(datasourceID, nestedInstruction)
+}
+
+func (f *NotFilter) FilterTransaction(datasourceID datasource.DatasourceID, transactionMetadata TransactionMetadata, nestedInstructions NestedInstructions) bool {
+	return f.kinds&KindTransaction == 0 || !f.filter.FilterTransaction(datasourceID, transactionMetadata, nestedInstructions)
+}
+
+func (f *NotFilter) FilterAccountDeletion(datasourceID datasource.DatasourceID, accountDeletion *datasource.AccountDeletion) bool {
+	return f.kinds&KindAccountDeletion == 0 || !f.filter.FilterAccountDeletion(datasourceID, accountDeletion)
+}
+
+func (f *NotFilter) FilterBlockDetails(datasourceID datasource.DatasourceID, blockDetails *datasource.BlockDetails) bool {
+	return f.kinds&KindBlockDetails == 0 || !f.filter.FilterBlockDetails(datasourceID, blockDetails)
+}
+
+// AccountFilterFunc is a function type that implements Filter for account
+// updates and passes every other update kind.
+type AccountFilterFunc func(datasourceID datasource.DatasourceID, accountMetadata *AccountMetadata, account *types.Account) bool
+
+// FilterKinds implements KindScoped.
+func (fn AccountFilterFunc) FilterKinds() UpdateKind { return KindAccount }
+
+func (fn AccountFilterFunc) FilterAccount(datasourceID datasource.DatasourceID, accountMetadata *AccountMetadata, account *types.Account) bool {
+	return fn(datasourceID, accountMetadata, account)
+}
+
+func (fn AccountFilterFunc) FilterInstruction(datasource.DatasourceID, NestedInstruction) bool {
+	return true
+}
+
+func (fn AccountFilterFunc) FilterTransaction(datasource.DatasourceID, TransactionMetadata, NestedInstructions) bool {
+	return true
+}
+
+func (fn AccountFilterFunc) FilterAccountDeletion(datasource.DatasourceID, *datasource.AccountDeletion) bool {
+	return true
+}
+
+func (fn AccountFilterFunc) FilterBlockDetails(datasource.DatasourceID, *datasource.BlockDetails) bool {
+	return true
+}
+
+// InstructionFilterFunc is a function type that implements Filter for
+// instructions and passes every other update kind.
+type InstructionFilterFunc func(datasourceID datasource.DatasourceID, nestedInstruction NestedInstruction) bool
+
+// FilterKinds implements KindScoped.
+func (fn InstructionFilterFunc) FilterKinds() UpdateKind { return KindInstruction }
+
+func (fn InstructionFilterFunc) FilterAccount(datasource.DatasourceID, *AccountMetadata, *types.Account) bool {
+	return true
+}
+
+func (fn InstructionFilterFunc) FilterInstruction(datasourceID datasource.DatasourceID, nestedInstruction NestedInstruction) bool {
+	return fn(datasourceID, nestedInstruction)
+}
+
+func (fn InstructionFilterFunc) FilterTransaction(datasource.DatasourceID, TransactionMetadata, NestedInstructions) bool {
+	return true
+}
+
+func (fn InstructionFilterFunc) FilterAccountDeletion(datasource.DatasourceID, *datasource.AccountDeletion) bool {
+	return true
+}
+
+func (fn InstructionFilterFunc) FilterBlockDetails(datasource.DatasourceID, *datasource.BlockDetails) bool {
+	return true
+}
+
+// TransactionFilterFunc is a function type that implements Filter for
+// transactions and passes every other update kind.
+type TransactionFilterFunc func(datasourceID datasource.DatasourceID, transactionMetadata TransactionMetadata, nestedInstructions NestedInstructions) bool
+
+// FilterKinds implements KindScoped.
+func (fn TransactionFilterFunc) FilterKinds() UpdateKind { return KindTransaction }
+
+func (fn TransactionFilterFunc) FilterAccount(datasource.DatasourceID, *AccountMetadata, *types.Account) bool {
+	return true
+}
+
+func (fn TransactionFilterFunc) FilterInstruction(datasource.DatasourceID, NestedInstruction) bool {
+	return true
+}
+
+func (fn TransactionFilterFunc) FilterTransaction(datasourceID datasource.DatasourceID, transactionMetadata TransactionMetadata, nestedInstructions NestedInstructions) bool {
+	return fn(datasourceID, transactionMetadata, nestedInstructions)
+}
+
+func (fn TransactionFilterFunc) FilterAccountDeletion(datasource.DatasourceID, *datasource.AccountDeletion) bool {
+	return true
+}
+
+func (fn TransactionFilterFunc) FilterBlockDetails(datasource.DatasourceID, *datasource.BlockDetails) bool {
+	return true
+}
+
+// AccountDeletionFilterFunc is a function type that implements Filter for
+// account deletions and passes every other update kind.
+type AccountDeletionFilterFunc func(datasourceID datasource.DatasourceID, accountDeletion *datasource.AccountDeletion) bool
+
+// FilterKinds implements KindScoped.
+func (fn AccountDeletionFilterFunc) FilterKinds() UpdateKind { return KindAccountDeletion }
+
+func (fn AccountDeletionFilterFunc) FilterAccount(datasource.DatasourceID, *AccountMetadata, *types.Account) bool {
+	return true
+}
+
+func (fn AccountDeletionFilterFunc) FilterInstruction(datasource.DatasourceID, NestedInstruction) bool {
+	return true
+}
+
+func (fn AccountDeletionFilterFunc) FilterTransaction(datasource.DatasourceID, TransactionMetadata, NestedInstructions) bool {
+	return true
+}
+
+func (fn AccountDeletionFilterFunc) FilterAccountDeletion(datasourceID datasource.DatasourceID, accountDeletion *datasource.AccountDeletion) bool {
+	return fn(datasourceID, accountDeletion)
+}
+
+func (fn AccountDeletionFilterFunc) FilterBlockDetails(datasource.DatasourceID, *datasource.BlockDetails) bool {
+	return true
+}
+
+// BlockDetailsFilterFunc is a function type that implements Filter for block
+// details and passes every other update kind.
+type BlockDetailsFilterFunc func(datasourceID datasource.DatasourceID, blockDetails *datasource.BlockDetails) bool
+
+// FilterKinds implements KindScoped.
+func (fn BlockDetailsFilterFunc) FilterKinds() UpdateKind { return KindBlockDetails }
+
+func (fn BlockDetailsFilterFunc) FilterAccount(datasource.DatasourceID, *AccountMetadata, *types.Account) bool {
+	return true
+}
+
+func (fn BlockDetailsFilterFunc) FilterInstruction(datasource.DatasourceID, NestedInstruction) bool {
+	return true
+}
+
+func (fn BlockDetailsFilterFunc) FilterTransaction(datasource.DatasourceID, TransactionMetadata, NestedInstructions) bool {
+	return true
+}
+
+func (fn BlockDetailsFilterFunc) FilterAccountDeletion(datasource.DatasourceID, *datasource.AccountDeletion) bool {
+	return true
+}
+
+func (fn BlockDetailsFilterFunc) FilterBlockDetails(datasourceID datasource.DatasourceID, blockDetails *datasource.BlockDetails) bool {
+	return fn(datasourceID, blockDetails)
+}
@@ -0,0 +1,57 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+func TestComposeFilters(t *testing.T) {
+	id := datasource.NewNamedDatasourceID("test")
+	raydium, orca := key(10), key(11)
+
+	// Raydium OR Orca, but NOT failed.
+	f := AllOf(Or(NewProgramFilter(raydium), NewProgramFilter(orca)), Not(NewFailedFilter()))
+
+	tests := []struct {
+		name string
+		tx   testTransaction
+		ixs  testInstructions
+		want bool
+	}{
+		{"orca success", testTransaction{}, testInstructions{{programID: orca}}, true},
+		{"raydium failed", testTransaction{failed: true}, testInstructions{{programID: raydium}}, false},
+		{"other program", testTransaction{}, testInstructions{{programID: key(12)}}, false},
+	}
+	for _, tt := range tests {
+		if got := f.FilterTransaction(id, tt.tx, tt.ixs); got != tt.want {
+			t.Errorf("%s: FilterTransaction() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Account updates are not constrained by program or status filters.
+	if !f.FilterAccount(id, &AccountMetadata{}, &types.Account{}) {
+		t.Error("expected account update to pass")
+	}
+	if ids, ok := Or(NewProgramFilter(raydium), NewProgramFilter(orca)).ProgramIDs(); !ok || len(ids) != 2 {
+		t.Errorf("unexpected Or program IDs: %v, %v", ids, ok)
+	}
+}
+
+func TestFilterFuncs(t *testing.T) {
+	id := datasource.NewNamedDatasourceID("test")
+	bigAccounts := AccountFilterFunc(func(_ datasource.DatasourceID, _ *AccountMetadata, account *types.Account) bool {
+		return account.Lamports > 100
+	})
+
+	if !bigAccounts.FilterTransaction(id, testTransaction{}, testInstructions{}) {
+		t.Error("expected account filter func to pass transactions")
+	}
+	if Not(bigAccounts).FilterAccount(id, &AccountMetadata{}, &types.Account{Lamports: 500}) {
+		t.Error("expected negated account filter func to reject large account")
+	}
+	if !Not(bigAccounts).FilterBlockDetails(id, &datasource.BlockDetails{}) {
+		t.Error("expected negated account filter func to pass block details")
+	}
+}
@@ -0,0 +1,418 @@
+package filter
+
+import (
+	"bytes"
+
+	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+// The content filters in this file inspect the update payload rather than its
+// datasource. Each filter only constrains the update types it understands and
+// lets every other update type pass, as reported by FilterKinds, so filters can
+// be combined freely in a pipe's filter list or with AllOf, AnyOf and Not.
+
+// AccountKeysProvider is implemented by transaction metadata that exposes the
+// transaction's account keys.
+type AccountKeysProvider interface {
+	GetAccountKeys() []types.Pubkey
+}
+
+// InnerInstructionsProvider is implemented by nested instructions that expose
+// the instructions they invoked.
+type InnerInstructionsProvider interface {
+	GetInnerInstructions() NestedInstructions
+}
+
+// InvokesProgram reports whether any of nestedInstructions, or any instruction
+// they invoked, targets a program for which match returns true. Inner
+// instructions are only visited when the instructions implement
+// InnerInstructionsProvider.
+func InvokesProgram(nestedInstructions NestedInstructions, match func(types.Pubkey) bool) bool {
+	if nestedInstructions == nil {
+		return false
+	}
+	for i := 0; i < nestedInstructions.Len(); i++ {
+		ix := nestedInstructions.Get(i)
+		if ix == nil {
+			continue
+		}
+		if match(ix.GetProgramID()) {
+			return true
+		}
+		if provider, ok := ix.(InnerInstructionsProvider); ok && InvokesProgram(provider.GetInnerInstructions(), match) {
+			return true
+		}
+	}
+	return false
+}
+
+// StatusProvider is implemented by transaction metadata that exposes the
+// transaction's execution status.
+type StatusProvider interface {
+	IsFailed() bool
+}
+
+// FeeProvider is implemented by transaction metadata that exposes the fee
+// charged for the transaction.
+type FeeProvider interface {
+	GetFee() uint64
+}
+
+// pubkeySet is a set of public keys.
+type pubkeySet map[types.Pubkey]struct{}
+
+// newPubkeySet creates a pubkeySet from a list of public keys.
+func newPubkeySet(pubkeys []types.Pubkey) pubkeySet {
+	set := make(pubkeySet, len(pubkeys))
+	for _, pubkey := range pubkeys {
+		set[pubkey] = struct{}{}
+	}
+	return set
+}
+
+// contains reports whether pubkey is in the set.
+func (s pubkeySet) contains(pubkey types.Pubkey) bool {
+	_, exists := s[pubkey]
+	return exists
+}
+
+// list returns the public keys in the set.
+func (s pubkeySet) list() []types.Pubkey {
+	pubkeys := make([]types.Pubkey, 0, len(s))
+	for pubkey := range s {
+		pubkeys = append(pubkeys, pubkey)
+	}
+	return pubkeys
+}
+
+// OwnerFilter only allows account updates for accounts owned by one of the given programs.
+type OwnerFilter struct {
+	BaseFilter
+	owners pubkeySet
+}
+
+// NewOwnerFilter creates a new filter that allows accounts owned by any of the given programs.
+func NewOwnerFilter(owners ...types.Pubkey) *OwnerFilter {
+	return &OwnerFilter{owners: newPubkeySet(owners)}
+}
+
+// FilterKinds implements KindScoped.
+func (f *OwnerFilter) FilterKinds() UpdateKind {
+	return KindAccount
+}
+
+func (f *OwnerFilter) FilterAccount(datasourceID datasource.DatasourceID, accountMetadata *AccountMetadata, account *types.Account) bool {
+	return account != nil && f.owners.contains(account.Owner)
+}
+
+// PubkeyFilter only allows account updates and deletions for the given accounts.
+type PubkeyFilter struct {
+	BaseFilter
+	pubkeys pubkeySet
+}
+
+// NewPubkeyFilter creates a new filter that allows updates for any of the given accounts.
+func NewPubkeyFilter(pubkeys ...types.Pubkey) *PubkeyFilter {
+	return &PubkeyFilter{pubkeys: newPubkeySet(pubkeys)}
+}
+
+// FilterKinds implements KindScoped.
+func (f *PubkeyFilter) FilterKinds() UpdateKind {
+	return KindAccount | KindAccountDeletion
+}
+
+func (f *PubkeyFilter) FilterAccount(datasourceID datasource.DatasourceID, accountMetadata *AccountMetadata, account *types.Account) bool {
+	return accountMetadata != nil && f.pubkeys.contains(accountMetadata.Pubkey)
+}
+
+func (f *PubkeyFilter) FilterAccountDeletion(datasourceID datasource.DatasourceID, accountDeletion *datasource.AccountDeletion) bool {
+	return accountDeletion != nil && f.pubkeys.contains(accountDeletion.Pubkey)
+}
+
+// MemcmpFilter only allows account updates whose data contains Bytes at Offset,
+// like the memcmp filter of Solana's getProgramAccounts.
+type MemcmpFilter struct {
+	BaseFilter
+
+	// Offset is the byte offset into the account data.
+	Offset int
+
+	// Bytes is the expected data at Offset.
+	Bytes []byte
+}
+
+// NewMemcmpFilter creates a new filter that compares account data at offset.
+func NewMemcmpFilter(offset int, data []byte) *MemcmpFilter {
+	return &MemcmpFilter{Offset: offset, Bytes: data}
+}
+
+// FilterKinds implements KindScoped.
+func (f *MemcmpFilter) FilterKinds() UpdateKind {
+	return KindAccount
+}
+
+func (f *MemcmpFilter) FilterAccount(datasourceID datasource.DatasourceID, accountMetadata *AccountMetadata, account *types.Account) bool {
+	if account == nil || f.Offset < 0 || f.Offset+len(f.Bytes) > len(account.Data) {
+		return false
+	}
+	return bytes.Equal(account.Data[f.Offset:f.Offset+len(f.Bytes)], f.Bytes)
+}
+
+// DataSizeFilter only allows account updates whose data length is within [Min, Max].
+type DataSizeFilter struct {
+	BaseFilter
+
+	// Min is the minimum data length, inclusive.
+	Min int
+
+	// Max is the maximum data length, inclusive.
+	Max int
+}
+
+// NewDataSizeFilter creates a new filter that allows accounts with exactly size bytes of data.
+func NewDataSizeFilter(size int) *DataSizeFilter {
+	return &DataSizeFilter{Min: size, Max: size}
+}
+
+// NewDataSizeRangeFilter creates a new filter that allows accounts with a data length in [min, max].
+func NewDataSizeRangeFilter(min, max int) *DataSizeFilter {
+	return &DataSizeFilter{Min: min, Max: max}
+}
+
+// FilterKinds implements KindScoped.
+func (f *DataSizeFilter) FilterKinds() UpdateKind {
+	return KindAccount
+}
+
+func (f *DataSizeFilter) FilterAccount(datasourceID datasource.DatasourceID, accountMetadata *AccountMetadata, account *types.Account) bool {
+	return account != nil && len(account.Data) >= f.Min && len(account.Data) <= f.Max
+}
+
+// LamportsFilter only allows account updates whose balance is within [Min, Max].
+type LamportsFilter struct {
+	BaseFilter
+
+	// Min is the minimum balance in lamports, inclusive.
+	Min uint64
+
+	// Max is the maximum balance in lamports, inclusive.
+	Max uint64
+}
+
+// NewLamportsRangeFilter creates a new filter that allows accounts with a balance in [min, max].
+func NewLamportsRangeFilter(min, max uint64) *LamportsFilter {
+	return &LamportsFilter{Min: min, Max: max}
+}
+
+// FilterKinds implements KindScoped.
+func (f *LamportsFilter) FilterKinds() UpdateKind {
+	return KindAccount
+}
+
+func (f *LamportsFilter) FilterAccount(datasourceID datasource.DatasourceID, accountMetadata *AccountMetadata, account *types.Account) bool {
+	return account != nil && account.Lamports >= f.Min && account.Lamports <= f.Max
+}
+
+// ProgramFilter only allows instructions of the given programs, and
+// transactions that invoke at least one of them.
+//
+// A transaction invokes a program if one of its instructions, top-level or
+// inner, targets it. Programs only passed as accounts, e.g. oracles or CPI
+// targets that were not called, do not match.
+type ProgramFilter struct {
+	BaseFilter
+	programIDs pubkeySet
+}
+
+// NewProgramFilter creates a new filter that allows instructions of any of the given programs.
+func NewProgramFilter(programIDs ...types.Pubkey) *ProgramFilter {
+	return &ProgramFilter{programIDs: newPubkeySet(programIDs)}
+}
+
+// FilterKinds implements KindScoped.
+func (f *ProgramFilter) FilterKinds() UpdateKind {
+	return KindInstruction | KindTransaction
+}
+
+// ProgramIDs returns the programs this filter allows.
+func (f *ProgramFilter) ProgramIDs() ([]types.Pubkey, bool) {
+	return f.programIDs.list(), true
+}
+
+func (f *ProgramFilter) FilterInstruction(datasourceID datasource.DatasourceID, nestedInstruction NestedInstruction) bool {
+	return f.programIDs.contains(nestedInstruction.GetProgramID())
+}
+
+func (f *ProgramFilter) FilterTransaction(datasourceID datasource.DatasourceID, transactionMetadata TransactionMetadata, nestedInstructions NestedInstructions) bool {
+	return InvokesProgram(nestedInstructions, f.programIDs.contains)
+}
+
+// DiscriminatorFilter only allows instructions whose data starts with one of the given prefixes.
+type DiscriminatorFilter struct {
+	BaseFilter
+	prefixes [][]byte
+}
+
+// NewDiscriminatorFilter creates a new filter that allows instructions whose
+// data starts with any of the given discriminators.
+func NewDiscriminatorFilter(discriminators ...[]byte) *DiscriminatorFilter {
+	return &DiscriminatorFilter{prefixes: discriminators}
+}
+
+// FilterKinds implements KindScoped.
+func (f *DiscriminatorFilter) FilterKinds() UpdateKind {
+	return KindInstruction
+}
+
+func (f *DiscriminatorFilter) FilterInstruction(datasourceID datasource.DatasourceID, nestedInstruction NestedInstruction) bool {
+	data := nestedInstruction.GetData()
+	for _, prefix := range f.prefixes {
+		if bytes.HasPrefix(data, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// AccountKeyFilter only allows transactions that include the given account keys.
+//
+// Transactions whose metadata does not expose account keys are rejected.
+type AccountKeyFilter struct {
+	BaseFilter
+	keys pubkeySet
+
+	// RequireAll requires every key to be included instead of any of them.
+	RequireAll bool
+}
+
+// NewAccountKeyFilter creates a new filter that allows transactions including any of the given keys.
+func NewAccountKeyFilter(keys ...types.Pubkey) *AccountKeyFilter {
+	return &AccountKeyFilter{keys: newPubkeySet(keys)}
+}
+
+// NewAccountKeyFilterAll creates a new filter that allows transactions including all of the given keys.
+func NewAccountKeyFilterAll(keys ...types.Pubkey) *AccountKeyFilter {
+	return &AccountKeyFilter{keys: newPubkeySet(keys), RequireAll: true}
+}
+
+// FilterKinds implements KindScoped.
+func (f *AccountKeyFilter) FilterKinds() UpdateKind {
+	return KindTransaction
+}
+
+func (f *AccountKeyFilter) FilterTransaction(datasourceID datasource.DatasourceID, transactionMetadata TransactionMetadata, nestedInstructions NestedInstructions) bool {
+	provider, ok := transactionMetadata.(AccountKeysProvider)
+	if !ok {
+		return false
+	}
+
+	found := make(pubkeySet, len(f.keys))
+	for _, key := range provider.GetAccountKeys() {
+		if !f.keys.contains(key) {
+			continue
+		}
+		if !f.RequireAll {
+			return true
+		}
+		found[key] = struct{}{}
+	}
+
+	return f.RequireAll && len(found) == len(f.keys)
+}
+
+// FeePayerFilter only allows transactions paid for by one of the given accounts.
+type FeePayerFilter struct {
+	BaseFilter
+	feePayers pubkeySet
+}
+
+// NewFeePayerFilter creates a new filter that allows transactions paid for by any of the given accounts.
+func NewFeePayerFilter(feePayers ...types.Pubkey) *FeePayerFilter {
+	return &FeePayerFilter{feePayers: newPubkeySet(feePayers)}
+}
+
+// FilterKinds implements KindScoped.
+func (f *FeePayerFilter) FilterKinds() UpdateKind {
+	return KindTransaction
+}
+
+func (f *FeePayerFilter) FilterTransaction(datasourceID datasource.DatasourceID, transactionMetadata TransactionMetadata, nestedInstructions NestedInstructions) bool {
+	return f.feePayers.contains(transactionMetadata.GetFeePayer())
+}
+
+// TransactionStatusFilter only allows successful or only failed transactions.
+//
+// Transactions whose metadata does not expose a status are treated as successful.
+type TransactionStatusFilter struct {
+	BaseFilter
+
+	// Failed selects failed transactions instead of successful ones.
+	Failed bool
+}
+
+// NewSuccessFilter creates a new filter that allows only successful transactions.
+func NewSuccessFilter() *TransactionStatusFilter {
+	return &TransactionStatusFilter{}
+}
+
+// NewFailedFilter creates a new filter that allows only failed transactions.
+func NewFailedFilter() *TransactionStatusFilter {
+	return &TransactionStatusFilter{Failed: true}
+}
+
+// FilterKinds implements KindScoped.
+func (f *TransactionStatusFilter) FilterKinds() UpdateKind {
+	return KindTransaction
+}
+
+func (f *TransactionStatusFilter) FilterTransaction(datasourceID datasource.DatasourceID, transactionMetadata TransactionMetadata, nestedInstructions NestedInstructions) bool {
+	failed := false
+	if provider, ok := transactionMetadata.(StatusProvider); ok {
+		failed = provider.IsFailed()
+	}
+	return failed == f.Failed
+}
+
+// SlotRangeFilter only allows account updates, transactions, account deletions
+// and block details with a slot in [Min, Max]. A zero Max is unbounded.
+type SlotRangeFilter struct {
+	BaseFilter
+
+	// Min is the minimum slot, inclusive.
+	Min uint64
+
+	// Max is the maximum slot, inclusive. Zero means no upper bound.
+	Max uint64
+}
+
+// NewSlotRangeFilter creates a new filter that allows updates with a slot in [min, max].
+func NewSlotRangeFilter(min, max uint64) *SlotRangeFilter {
+	return &SlotRangeFilter{Min: min, Max: max}
+}
+
+// FilterKinds implements KindScoped.
+func (f *SlotRangeFilter) FilterKinds() UpdateKind {
+	return KindAccount | KindTransaction | KindAccountDeletion | KindBlockDetails
+}
+
+// contains reports whether slot is within the range.
+func (f *SlotRangeFilter) contains(slot uint64) bool {
+	return slot >= f.Min && (f.Max == 0 || slot <= f.Max)
+}
+
+func (f *SlotRangeFilter) FilterAccount(datasourceID datasource.DatasourceID, accountMetadata *AccountMetadata, account *types.Account) bool {
+	return accountMetadata != nil && f.contains(accountMetadata.Slot)
+}
+
+func (f *SlotRangeFilter) FilterTransaction(datasourceID datasource.DatasourceID, transactionMetadata TransactionMetadata, nestedInstructions NestedInstructions) bool {
+	return f.contains(transactionMetadata.GetSlot())
+}
+
+func (f *SlotRangeFilter) FilterAccountDeletion(datasourceID datasource.DatasourceID, accountDeletion *datasource.AccountDeletion) bool {
+	return accountDeletion != nil && f.contains(accountDeletion.Slot)
+}
+
+func (f *SlotRangeFilter) FilterBlockDetails(datasourceID datasource.DatasourceID, blockDetails *datasource.BlockDetails) bool {
+	return blockDetails != nil && f.contains(blockDetails.Slot)
+}
@@ -0,0 +1,111 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+type testInstruction struct {
+	programID types.Pubkey
+	data      []byte
+	inner     testInstructions
+}
+
+func (i testInstruction) GetProgramID() types.Pubkey               { return i.programID }
+func (i testInstruction) GetData() []byte                          { return i.data }
+func (i testInstruction) GetInnerInstructions() NestedInstructions { return i.inner }
+
+type testTransaction struct {
+	slot        uint64
+	feePayer    types.Pubkey
+	accountKeys []types.Pubkey
+	failed      bool
+}
+
+func (t testTransaction) GetSlot() uint64                { return t.slot }
+func (t testTransaction) GetSignature() types.Signature  { return types.Signature{} }
+func (t testTransaction) GetFeePayer() types.Pubkey      { return t.feePayer }
+func (t testTransaction) GetAccountKeys() []types.Pubkey { return t.accountKeys }
+func (t testTransaction) IsFailed() bool                 { return t.failed }
+
+type testInstructions []testInstruction
+
+func (n testInstructions) Len() int                        { return len(n) }
+func (n testInstructions) Get(index int) NestedInstruction { return n[index] }
+
+func key(b byte) types.Pubkey {
+	var pk types.Pubkey
+	pk[0] = b
+	return pk
+}
+
+func TestAccountContentFilters(t *testing.T) {
+	id := datasource.NewNamedDatasourceID("test")
+	metadata := &AccountMetadata{Slot: 100, Pubkey: key(1)}
+	account := &types.Account{Owner: key(2), Lamports: 500, Data: []byte{1, 2, 3, 4}}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"owner match", NewOwnerFilter(key(2)), true},
+		{"owner mismatch", NewOwnerFilter(key(3)), false},
+		{"pubkey", NewPubkeyFilter(key(1)), true},
+		{"memcmp match", NewMemcmpFilter(1, []byte{2, 3}), true},
+		{"memcmp out of range", NewMemcmpFilter(3, []byte{4, 5}), false},
+		{"data size", NewDataSizeFilter(4), true},
+		{"data size range", NewDataSizeRangeFilter(5, 10), false},
+		{"lamports", NewLamportsRangeFilter(100, 1000), true},
+		{"slot range", NewSlotRangeFilter(101, 0), false},
+		{"chain", NewFilterChain(NewOwnerFilter(key(2)), NewDataSizeFilter(4)), true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.FilterAccount(id, metadata, account); got != tt.want {
+			t.Errorf("%s: FilterAccount() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTransactionContentFilters(t *testing.T) {
+	id := datasource.NewNamedDatasourceID("test")
+	tx := testTransaction{slot: 10, feePayer: key(1), accountKeys: []types.Pubkey{key(1), key(5), key(9)}}
+	ixs := testInstructions{{
+		programID: key(9),
+		data:      []byte{0xaa, 0xbb, 0x01},
+		inner:     testInstructions{{programID: key(8)}},
+	}}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   bool
+	}{
+		{"program", NewProgramFilter(key(9)), true},
+		{"program missing", NewProgramFilter(key(7)), false},
+		{"program inner", NewProgramFilter(key(8)), true},
+		{"program only an account", NewProgramFilter(key(5)), false},
+		{"account key any", NewAccountKeyFilter(key(5), key(7)), true},
+		{"account key all", NewAccountKeyFilterAll(key(5), key(7)), false},
+		{"fee payer", NewFeePayerFilter(key(1)), true},
+		{"success", NewSuccessFilter(), true},
+		{"failed", NewFailedFilter(), false},
+		{"slot range", NewSlotRangeFilter(1, 10), true},
+	}
+
+	for _, tt := range tests {
+		if got := tt.filter.FilterTransaction(id, tx, ixs); got != tt.want {
+			t.Errorf("%s: FilterTransaction() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if !NewDiscriminatorFilter([]byte{0xaa, 0xbb}).FilterInstruction(id, ixs[0]) {
+		t.Error("expected discriminator prefix to match")
+	}
+	if NewProgramFilter(key(7)).FilterInstruction(id, ixs[0]) {
+		t.Error("expected instruction of another program to be rejected")
+	}
+}
@@ -0,0 +1,121 @@
+// Package expr compiles filter expressions into filter.Filter values.
+//
+// Expressions let operators declare filters in configuration instead of code:
+//
+//	program == "JUP6LkbZbjS1jKKwapdHNy74zcZ3tLUZoi5QNyVTaV4" && !tx.failed && tx.fee > 5000
+//
+// # Fields
+//
+// Account updates expose account.pubkey, account.owner, account.lamports,
+// account.data_len, account.executable and account.rent_epoch.
+//
+// Instructions expose ix.data_len and ix.data and ix.discriminator as hex
+// strings. The instruction. prefix is an alias of ix.
+//
+// Transactions expose tx.signature, tx.fee_payer, tx.failed, tx.fee,
+// tx.instructions (the number of top-level instructions) and tx.account_keys.
+// The transaction. prefix is an alias of tx.
+//
+// slot is available for account updates and transactions, and program for
+// instructions and transactions. For a transaction, program is the list of
+// programs its instructions invoke, top-level or inner, so program == "X" tests
+// whether it invokes X.
+//
+// # Operators
+//
+// Expressions support ||, &&, !, ==, !=, <, <=, >, >=, parentheses and
+// membership tests against list literals, e.g. account.owner in ["A", "B"].
+//
+// An expression only constrains the update kinds its fields are available for
+// and lets updates of every other kind pass. The operands of && must share an
+// update kind, so account.owner == "A" && tx.fee > 0 is a compile error. The
+// operands of || need not: account.owner == "A" || tx.fee_payer == "B"
+// constrains accounts and transactions, and an operand whose fields are not
+// available for an update is false.
+package expr
+
+import (
+	"fmt"
+
+	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/internal/filter"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+// Filter is a filter.Filter compiled from an expression.
+type Filter struct {
+	source string
+	root   *node
+}
+
+// Ensure Filter implements filter.Filter and filter.KindScoped.
+var (
+	_ filter.Filter     = (*Filter)(nil)
+	_ filter.KindScoped = (*Filter)(nil)
+)
+
+// Compile compiles an expression into a Filter.
+func Compile(source string) (*Filter, error) {
+	root, err := parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter expression %q: %w", source, err)
+	}
+	if root.typ != typeBool {
+		return nil, fmt.Errorf("invalid filter expression %q: must evaluate to bool, got %s", source, root.typ)
+	}
+	if root.kinds == 0 {
+		return nil, fmt.Errorf("invalid filter expression %q: fields apply to different update kinds", source)
+	}
+
+	return &Filter{source: source, root: root}, nil
+}
+
+// MustCompile is like Compile but panics if the expression is invalid.
+func MustCompile(source string) *Filter {
+	f, err := Compile(source)
+	if err != nil {
+		panic(err)
+	}
+	return f
+}
+
+// String returns the source expression.
+func (f *Filter) String() string {
+	return f.source
+}
+
+// FilterKinds implements filter.KindScoped.
+func (f *Filter) FilterKinds() filter.UpdateKind {
+	return f.root.kinds
+}
+
+// evaluate evaluates the expression for an update of the given kind.
+func (f *Filter) evaluate(e *env) bool {
+	if f.root.kinds&e.kind == 0 {
+		return true
+	}
+	return f.root.eval(e).b
+}
+
+func (f *Filter) FilterAccount(datasourceID datasource.DatasourceID, accountMetadata *filter.AccountMetadata, account *types.Account) bool {
+	if accountMetadata == nil || account == nil {
+		return f.root.kinds&filter.KindAccount == 0
+	}
+	return f.evaluate(&env{kind: filter.KindAccount, accountMetadata: accountMetadata, account: account})
+}
+
+func (f *Filter) FilterInstruction(datasourceID datasource.DatasourceID, nestedInstruction filter.NestedInstruction) bool {
+	return f.evaluate(&env{kind: filter.KindInstruction, instruction: nestedInstruction})
+}
+
+func (f *Filter) FilterTransaction(datasourceID datasource.DatasourceID, transactionMetadata filter.TransactionMetadata, nestedInstructions filter.NestedInstructions) bool {
+	return f.evaluate(&env{kind: filter.KindTransaction, transaction: transactionMetadata, nestedInstructions: nestedInstructions})
+}
+
+func (f *Filter) FilterAccountDeletion(datasourceID datasource.DatasourceID, accountDeletion *datasource.AccountDeletion) bool {
+	return true
+}
+
+func (f *Filter) FilterBlockDetails(datasourceID datasource.DatasourceID, blockDetails *datasource.BlockDetails) bool {
+	return true
+}
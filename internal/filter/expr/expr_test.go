@@ -0,0 +1,143 @@
+package expr
+
+import (
+	"testing"
+
+	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/internal/filter"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+type testTransaction struct {
+	feePayer    types.Pubkey
+	accountKeys []types.Pubkey
+	fee         uint64
+	failed      bool
+}
+
+func (t testTransaction) GetSlot() uint64                { return 7 }
+func (t testTransaction) GetSignature() types.Signature  { return types.Signature{} }
+func (t testTransaction) GetFeePayer() types.Pubkey      { return t.feePayer }
+func (t testTransaction) GetAccountKeys() []types.Pubkey { return t.accountKeys }
+func (t testTransaction) GetFee() uint64                 { return t.fee }
+func (t testTransaction) IsFailed() bool                 { return t.failed }
+
+type testInstruction struct {
+	programID types.Pubkey
+	inner     testInstructions
+}
+
+func (i testInstruction) GetProgramID() types.Pubkey                      { return i.programID }
+func (i testInstruction) GetData() []byte                                 { return nil }
+func (i testInstruction) GetInnerInstructions() filter.NestedInstructions { return i.inner }
+
+type testInstructions []testInstruction
+
+func (n testInstructions) Len() int                               { return len(n) }
+func (n testInstructions) Get(index int) filter.NestedInstruction { return n[index] }
+
+func key(b byte) types.Pubkey {
+	var pk types.Pubkey
+	pk[0] = b
+	return pk
+}
+
+func TestTransactionExpression(t *testing.T) {
+	id := datasource.NewNamedDatasourceID("test")
+	jup := key(9)
+	f, err := Compile(`program == "` + jup.String() + `" && !tx.failed && tx.fee > 5000`)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	if f.FilterKinds() != filter.KindTransaction {
+		t.Errorf("unexpected kinds: %v", f.FilterKinds())
+	}
+
+	viaJupiter := testInstructions{{programID: key(1), inner: testInstructions{{programID: jup}}}}
+	tests := []struct {
+		name string
+		tx   testTransaction
+		ixs  testInstructions
+		want bool
+	}{
+		{"match", testTransaction{fee: 6000}, testInstructions{{programID: jup}}, true},
+		{"inner match", testTransaction{fee: 6000}, viaJupiter, true},
+		{"low fee", testTransaction{fee: 5000}, viaJupiter, false},
+		{"failed", testTransaction{fee: 6000, failed: true}, viaJupiter, false},
+		{"only an account", testTransaction{accountKeys: []types.Pubkey{key(1), jup}, fee: 6000}, testInstructions{{programID: key(1)}}, false},
+	}
+	for _, tt := range tests {
+		if got := f.FilterTransaction(id, tt.tx, tt.ixs); got != tt.want {
+			t.Errorf("%s: FilterTransaction() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Accounts are not constrained by a transaction expression.
+	if !f.FilterAccount(id, &filter.AccountMetadata{}, &types.Account{}) {
+		t.Error("expected account update to pass")
+	}
+}
+
+func TestAccountExpression(t *testing.T) {
+	id := datasource.NewNamedDatasourceID("test")
+	f := MustCompile(`account.owner in ["` + key(2).String() + `"] && (account.data_len == 165 || account.lamports >= 1_000)`)
+
+	if !f.FilterAccount(id, &filter.AccountMetadata{}, &types.Account{Owner: key(2), Lamports: 1000}) {
+		t.Error("expected account to match")
+	}
+	if f.FilterAccount(id, &filter.AccountMetadata{}, &types.Account{Owner: key(3), Data: make([]byte, 165)}) {
+		t.Error("expected account of another owner to be rejected")
+	}
+}
+
+func TestOrAcrossKinds(t *testing.T) {
+	id := datasource.NewNamedDatasourceID("test")
+	f := MustCompile(`account.owner == "` + key(2).String() + `" || tx.fee_payer == "` + key(3).String() + `"`)
+	if f.FilterKinds() != filter.KindAccount|filter.KindTransaction {
+		t.Errorf("unexpected kinds: %v", f.FilterKinds())
+	}
+
+	if !f.FilterAccount(id, &filter.AccountMetadata{}, &types.Account{Owner: key(2)}) {
+		t.Error("expected account of the owner to match")
+	}
+	if f.FilterAccount(id, &filter.AccountMetadata{}, &types.Account{Owner: key(3)}) {
+		t.Error("expected account of another owner to be rejected")
+	}
+	if !f.FilterTransaction(id, testTransaction{feePayer: key(3)}, nil) {
+		t.Error("expected transaction of the fee payer to match")
+	}
+	if f.FilterTransaction(id, testTransaction{feePayer: key(2)}, nil) {
+		t.Error("expected transaction of another fee payer to be rejected")
+	}
+	if !f.FilterInstruction(id, testInstruction{programID: key(1)}) {
+		t.Error("expected instruction to pass")
+	}
+
+	// Under &&, the || only constrains transactions.
+	f = MustCompile(`(account.owner == "` + key(2).String() + `" || tx.fee_payer == "` + key(3).String() + `") && tx.fee > 5000`)
+	if !f.FilterTransaction(id, testTransaction{feePayer: key(3), fee: 6000}, nil) {
+		t.Error("expected transaction to match")
+	}
+	if f.FilterTransaction(id, testTransaction{feePayer: key(2), fee: 6000}, nil) {
+		t.Error("expected transaction of another fee payer to be rejected")
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	invalid := []string{
+		`tx.fee > "high"`,
+		`account.owner == "x" && tx.failed`,
+		`(account.owner == "x" && tx.failed) || ix.data_len > 0`,
+		`account.owner == tx.fee_payer || tx.failed`,
+		`tx.fee`,
+		`unknown.field == 1`,
+		`tx.fee > 1 &&`,
+		`"unterminated`,
+		`account.lamports in ["a", 1]`,
+	}
+	for _, src := range invalid {
+		if _, err := Compile(src); err == nil {
+			t.Errorf("expected Compile(%q) to fail", src)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package expr
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/lugondev/go-carbon/internal/filter"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+// valueType is the static type of an expression.
+type valueType int
+
+const (
+	typeBool valueType = iota
+	typeNumber
+	typeString
+	typeStringList
+	typeNumberList
+)
+
+// String returns the name of the type as used in error messages.
+func (t valueType) String() string {
+	switch t {
+	case typeBool:
+		return "bool"
+	case typeNumber:
+		return "number"
+	case typeString:
+		return "string"
+	case typeStringList:
+		return "string list"
+	case typeNumberList:
+		return "number list"
+	default:
+		return "unknown"
+	}
+}
+
+// value is the result of evaluating an expression. Only the member matching
+// the expression's static type is set.
+type value struct {
+	b       bool
+	n       uint64
+	s       string
+	strings []string
+	numbers []uint64
+}
+
+// env is the update an expression is evaluated against. Only the members of
+// the update kind being filtered are set.
+type env struct {
+	accountMetadata    *filter.AccountMetadata
+	account            *types.Account
+	instruction        filter.NestedInstruction
+	transaction        filter.TransactionMetadata
+	nestedInstructions filter.NestedInstructions
+	kind               filter.UpdateKind
+}
+
+// field is a named value exposed to expressions.
+type field struct {
+	typ   valueType
+	kinds filter.UpdateKind
+	get   func(e *env) value
+}
+
+// fields lists the fields exposed to expressions by name.
+//
+// A field is only available for the update kinds in its kinds set, and is only
+// evaluated for updates of those kinds.
+var fields = map[string]field{
+	// Account fields
+	"account.pubkey": {typeString, filter.KindAccount, func(e *env) value {
+		return value{s: e.accountMetadata.Pubkey.String()}
+	}},
+	"account.owner": {typeString, filter.KindAccount, func(e *env) value {
+		return value{s: e.account.Owner.String()}
+	}},
+	"account.lamports": {typeNumber, filter.KindAccount, func(e *env) value {
+		return value{n: e.account.Lamports}
+	}},
+	"account.data_len": {typeNumber, filter.KindAccount, func(e *env) value {
+		return value{n: uint64(len(e.account.Data))}
+	}},
+	"account.executable": {typeBool, filter.KindAccount, func(e *env) value {
+		return value{b: e.account.Executable}
+	}},
+	"account.rent_epoch": {typeNumber, filter.KindAccount, func(e *env) value {
+		return value{n: e.account.RentEpoch}
+	}},
+
+	// Instruction fields
+	"ix.data_len": {typeNumber, filter.KindInstruction, func(e *env) value {
+		return value{n: uint64(len(e.instruction.GetData()))}
+	}},
+	"ix.data": {typeString, filter.KindInstruction, func(e *env) value {
+		return value{s: hex.EncodeToString(e.instruction.GetData())}
+	}},
+	"ix.discriminator": {typeString, filter.KindInstruction, func(e *env) value {
+		data := e.instruction.GetData()
+		if len(data) > 8 {
+			data = data[:8]
+		}
+		return value{s: hex.EncodeToString(data)}
+	}},
+
+	// Transaction fields
+	"tx.signature": {typeString, filter.KindTransaction, func(e *env) value {
+		return value{s: e.transaction.GetSignature().String()}
+	}},
+	"tx.fee_payer": {typeString, filter.KindTransaction, func(e *env) value {
+		return value{s: e.transaction.GetFeePayer().String()}
+	}},
+	"tx.failed": {typeBool, filter.KindTransaction, func(e *env) value {
+		provider, ok := e.transaction.(filter.StatusProvider)
+		return value{b: ok && provider.IsFailed()}
+	}},
+	"tx.fee": {typeNumber, filter.KindTransaction, func(e *env) value {
+		if provider, ok := e.transaction.(filter.FeeProvider); ok {
+			return value{n: provider.GetFee()}
+		}
+		return value{}
+	}},
+	"tx.instructions": {typeNumber, filter.KindTransaction, func(e *env) value {
+		return value{n: uint64(e.nestedInstructions.Len())}
+	}},
+	"tx.account_keys": {typeStringList, filter.KindTransaction, func(e *env) value {
+		provider, ok := e.transaction.(filter.AccountKeysProvider)
+		if !ok {
+			return value{}
+		}
+		return value{strings: pubkeyStrings(provider.GetAccountKeys())}
+	}},
+
+	// Fields shared by several update kinds
+	"slot": {typeNumber, filter.KindAccount | filter.KindTransaction, func(e *env) value {
+		if e.kind == filter.KindAccount {
+			return value{n: e.accountMetadata.Slot}
+		}
+		return value{n: e.transaction.GetSlot()}
+	}},
+	"program": {typeStringList, filter.KindInstruction | filter.KindTransaction, func(e *env) value {
+		if e.kind == filter.KindInstruction {
+			return value{strings: []string{e.instruction.GetProgramID().String()}}
+		}
+		return value{strings: transactionPrograms(e)}
+	}},
+}
+
+// fieldAliases maps alternative scope names to their canonical names.
+var fieldAliases = map[string]string{
+	"instruction.": "ix.",
+	"transaction.": "tx.",
+}
+
+// lookupField returns the field with the given name, resolving aliases.
+func lookupField(name string) (field, bool) {
+	for alias, canonical := range fieldAliases {
+		if strings.HasPrefix(name, alias) {
+			name = canonical + strings.TrimPrefix(name, alias)
+			break
+		}
+	}
+	f, ok := fields[name]
+	return f, ok
+}
+
+// transactionPrograms returns the programs a transaction invokes, using the
+// same rules as filter.ProgramFilter: the programs of its top-level and inner
+// instructions, without accounts that are never invoked.
+func transactionPrograms(e *env) []string {
+	seen := make(map[types.Pubkey]struct{})
+	var programs []string
+	filter.InvokesProgram(e.nestedInstructions, func(programID types.Pubkey) bool {
+		if _, ok := seen[programID]; !ok {
+			seen[programID] = struct{}{}
+			programs = append(programs, programID.String())
+		}
+		return false
+	})
+	return programs
+}
+
+// pubkeyStrings converts public keys to their base58 strings.
+func pubkeyStrings(pubkeys []types.Pubkey) []string {
+	result := make([]string, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		result[i] = pubkey.String()
+	}
+	return result
+}
@@ -0,0 +1,342 @@
+package expr
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/lugondev/go-carbon/internal/filter"
+)
+
+// node is a type-checked expression compiled into an evaluation function.
+type node struct {
+	typ   valueType
+	kinds filter.UpdateKind
+	eval  func(e *env) value
+}
+
+// parser is a recursive descent parser for filter expressions.
+//
+// Grammar:
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | comparison
+//	comparison = primary [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) primary | "in" list ]
+//	primary    = "(" expr ")" | field | string | number | "true" | "false"
+//	list       = "[" [ literal { "," literal } ] "]"
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse parses and type-checks an expression.
+func parse(src string) (*node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+	return n, nil
+}
+
+// peek returns the current token.
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next consumes and returns the current token.
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// expect consumes a token of the given kind or returns an error.
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, fmt.Errorf("expected %s at position %d", what, tok.pos)
+	}
+	return tok, nil
+}
+
+func (p *parser) parseOr() (*node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOr {
+		tok := p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = logical(tok, left, right, false); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (*node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenAnd {
+		tok := p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if left, err = logical(tok, left, right, true); err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (*node, error) {
+	if p.peek().kind != tokenNot {
+		return p.parseComparison()
+	}
+
+	tok := p.next()
+	operand, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	if operand.typ != typeBool {
+		return nil, fmt.Errorf("operator ! at position %d requires bool, got %s", tok.pos, operand.typ)
+	}
+	return &node{typ: typeBool, kinds: operand.kinds, eval: func(e *env) value {
+		return value{b: !operand.eval(e).b}
+	}}, nil
+}
+
+func (p *parser) parseComparison() (*node, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+
+	tok := p.peek()
+	switch tok.kind {
+	case tokenEq, tokenNeq, tokenLt, tokenLte, tokenGt, tokenGte:
+		p.next()
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return compare(tok, left, right)
+
+	case tokenIn:
+		p.next()
+		right, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		return membership(tok, left, right)
+
+	default:
+		return left, nil
+	}
+}
+
+func (p *parser) parsePrimary() (*node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenLParen:
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen, `")"`); err != nil {
+			return nil, err
+		}
+		return n, nil
+
+	case tokenIdent:
+		f, ok := lookupField(tok.text)
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q at position %d", tok.text, tok.pos)
+		}
+		return &node{typ: f.typ, kinds: f.kinds, eval: f.get}, nil
+
+	case tokenString:
+		v := value{s: tok.text}
+		return constant(typeString, v), nil
+
+	case tokenNumber:
+		v := value{n: tok.num}
+		return constant(typeNumber, v), nil
+
+	case tokenTrue, tokenFalse:
+		v := value{b: tok.kind == tokenTrue}
+		return constant(typeBool, v), nil
+
+	case tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+}
+
+// parseList parses a list literal of strings or numbers.
+func (p *parser) parseList() (*node, error) {
+	open, err := p.expect(tokenLBracket, `"["`)
+	if err != nil {
+		return nil, err
+	}
+
+	var list value
+	typ := typeStringList
+	for i := 0; p.peek().kind != tokenRBracket; i++ {
+		if i > 0 {
+			if _, err := p.expect(tokenComma, `","`); err != nil {
+				return nil, err
+			}
+		}
+
+		tok := p.next()
+		switch {
+		case tok.kind == tokenString && len(list.numbers) == 0:
+			list.strings = append(list.strings, tok.text)
+		case tok.kind == tokenNumber && len(list.strings) == 0:
+			list.numbers = append(list.numbers, tok.num)
+			typ = typeNumberList
+		default:
+			return nil, fmt.Errorf("list at position %d must contain only strings or only numbers", open.pos)
+		}
+	}
+	p.next()
+
+	return constant(typ, list), nil
+}
+
+// constant returns a node that always evaluates to v.
+func constant(typ valueType, v value) *node {
+	return &node{typ: typ, kinds: filter.KindAll, eval: func(*env) value { return v }}
+}
+
+// logical combines two bool nodes with && or ||. An && expression applies to
+// the update kinds both operands apply to, and an || expression to the kinds
+// either operand applies to: an operand that does not apply to an update is
+// false.
+func logical(tok token, left, right *node, and bool) (*node, error) {
+	if left.typ != typeBool || right.typ != typeBool {
+		return nil, fmt.Errorf("operator %s at position %d requires bool operands, got %s and %s",
+			tok.text, tok.pos, left.typ, right.typ)
+	}
+	if left.kinds == 0 || right.kinds == 0 || and && left.kinds&right.kinds == 0 {
+		return nil, fmt.Errorf("operator %s at position %d combines fields of update kinds that share no fields; use || to match either",
+			tok.text, tok.pos)
+	}
+
+	if and {
+		return &node{typ: typeBool, kinds: left.kinds & right.kinds, eval: func(e *env) value {
+			return value{b: left.eval(e).b && right.eval(e).b}
+		}}, nil
+	}
+	return &node{typ: typeBool, kinds: left.kinds | right.kinds, eval: func(e *env) value {
+		return value{b: left.kinds&e.kind != 0 && left.eval(e).b || right.kinds&e.kind != 0 && right.eval(e).b}
+	}}, nil
+}
+
+// compare builds a comparison node. Comparing a string list with a string
+// tests whether the list contains the string.
+func compare(tok token, left, right *node) (*node, error) {
+	kinds := left.kinds & right.kinds
+	invalid := fmt.Errorf("operator %s at position %d cannot compare %s and %s", tok.text, tok.pos, left.typ, right.typ)
+
+	if left.typ == typeString && right.typ == typeStringList {
+		left, right = right, left
+	}
+
+	switch {
+	case left.typ == typeNumber && right.typ == typeNumber:
+		return &node{typ: typeBool, kinds: kinds, eval: func(e *env) value {
+			return value{b: compareOrdered(tok.kind, left.eval(e).n, right.eval(e).n)}
+		}}, nil
+
+	case tok.kind != tokenEq && tok.kind != tokenNeq:
+		return nil, invalid
+
+	case left.typ == typeStringList && right.typ == typeString:
+		return &node{typ: typeBool, kinds: kinds, eval: func(e *env) value {
+			contains := slices.Contains(left.eval(e).strings, right.eval(e).s)
+			return value{b: contains == (tok.kind == tokenEq)}
+		}}, nil
+
+	case left.typ == right.typ && (left.typ == typeString || left.typ == typeBool):
+		return &node{typ: typeBool, kinds: kinds, eval: func(e *env) value {
+			l, r := left.eval(e), right.eval(e)
+			equal := l.s == r.s && l.b == r.b
+			return value{b: equal == (tok.kind == tokenEq)}
+		}}, nil
+
+	default:
+		return nil, invalid
+	}
+}
+
+// compareOrdered applies an ordering operator to two numbers.
+func compareOrdered(kind tokenKind, a, b uint64) bool {
+	switch kind {
+	case tokenEq:
+		return a == b
+	case tokenNeq:
+		return a != b
+	case tokenLt:
+		return a < b
+	case tokenLte:
+		return a <= b
+	case tokenGt:
+		return a > b
+	default:
+		return a >= b
+	}
+}
+
+// membership builds an "in" node. A string list is in a list if any of its
+// elements is.
+func membership(tok token, left, list *node) (*node, error) {
+	kinds := left.kinds & list.kinds
+	elements := list.eval(nil)
+
+	switch {
+	case left.typ == typeNumber && list.typ == typeNumberList:
+		return &node{typ: typeBool, kinds: kinds, eval: func(e *env) value {
+			return value{b: slices.Contains(elements.numbers, left.eval(e).n)}
+		}}, nil
+
+	case left.typ == typeString && list.typ == typeStringList:
+		return &node{typ: typeBool, kinds: kinds, eval: func(e *env) value {
+			return value{b: slices.Contains(elements.strings, left.eval(e).s)}
+		}}, nil
+
+	case left.typ == typeStringList && list.typ == typeStringList:
+		return &node{typ: typeBool, kinds: kinds, eval: func(e *env) value {
+			return value{b: slices.ContainsFunc(left.eval(e).strings, func(s string) bool {
+				return slices.Contains(elements.strings, s)
+			})}
+		}}, nil
+
+	default:
+		return nil, fmt.Errorf("operator in at position %d cannot test %s in %s", tok.pos, left.typ, list.typ)
+	}
+}
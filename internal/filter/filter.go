@@ -163,6 +163,11 @@ func (f *DatasourceFilter) FilterBlockDetails(datasourceID datasource.Datasource
 	return f.isAllowed(datasourceID)
 }
 
+// FilterKinds implements KindScoped.
+func (f *DatasourceFilter) FilterKinds() UpdateKind {
+	return KindAll
+}
+
 // AllowAllFilter is a filter that allows all updates to pass through.
 type AllowAllFilter struct {
 	BaseFilter
@@ -0,0 +1,63 @@
+package instruction
+
+import (
+	"github.com/lugondev/go-carbon/pkg/decoder"
+	"github.com/lugondev/go-carbon/pkg/log"
+)
+
+// CPIEvents extracts the events emitted with Anchor's emit_cpi! by the
+// instructions of a transaction, treating n as its top-level instructions.
+//
+// Decode the events with decoder.Registry.DecodeAllProgramData, alongside the
+// "Program data:" payloads of log.LogParser.ExtractAttributedProgramData.
+func (n *NestedInstructions) CPIEvents() []log.ProgramData {
+	if n == nil {
+		return nil
+	}
+
+	var invoked []decoder.InvokedInstruction
+	for i, nested := range n.Instructions {
+		invoked = nested.appendInvoked(invoked, log.InstructionPath{uint8(i)}, 1)
+	}
+	return decoder.ExtractCPIEvents(invoked)
+}
+
+// CPIEvents extracts the events emitted with Anchor's emit_cpi! by the
+// instruction and the instructions it invoked. Paths are relative to the
+// instruction's AbsolutePath.
+func (n *NestedInstruction) CPIEvents() []log.ProgramData {
+	var path log.InstructionPath
+	stackHeight := 1
+	if n.Metadata != nil {
+		path = append(path, n.Metadata.AbsolutePath...)
+		stackHeight = int(n.Metadata.StackHeight)
+	}
+	return decoder.ExtractCPIEvents(n.appendInvoked(nil, path, stackHeight))
+}
+
+// appendInvoked appends the instructions invoked by n, recursively, to invoked.
+func (n *NestedInstruction) appendInvoked(
+	invoked []decoder.InvokedInstruction,
+	path log.InstructionPath,
+	stackHeight int,
+) []decoder.InvokedInstruction {
+	if n.InnerInstructions == nil {
+		return invoked
+	}
+
+	for i, inner := range n.InnerInstructions.Instructions {
+		innerPath := make(log.InstructionPath, len(path), len(path)+1)
+		copy(innerPath, path)
+		innerPath = append(innerPath, uint8(i))
+
+		invoked = append(invoked, decoder.InvokedInstruction{
+			ProgramID:        inner.Instruction.ProgramID,
+			InvokerProgramID: n.Instruction.ProgramID,
+			StackHeight:      stackHeight + 1,
+			Path:             innerPath,
+			Data:             inner.Instruction.Data,
+		})
+		invoked = inner.appendInvoked(invoked, innerPath, stackHeight+1)
+	}
+	return invoked
+}
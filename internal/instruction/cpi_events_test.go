@@ -0,0 +1,50 @@
+package instruction
+
+import (
+	"testing"
+
+	"github.com/lugondev/go-carbon/pkg/decoder"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+func TestCPIEventsDecodeWithRegistry(t *testing.T) {
+	program, token := testProgram(1), testProgram(2)
+	discriminator := decoder.NewAnchorDiscriminator([]byte{1, 2, 3, 4, 5, 6, 7, 8})
+
+	event := append(append([]byte{}, decoder.EventIxTag[:]...), discriminator[:]...)
+	event = append(event, 42)
+
+	nested := NestInstructions(InstructionsWithMetadata{
+		{Metadata: &InstructionMetadata{StackHeight: 1}, Instruction: &types.Instruction{ProgramID: token}},
+		{Metadata: &InstructionMetadata{StackHeight: 1}, Instruction: &types.Instruction{ProgramID: program}},
+		{Metadata: &InstructionMetadata{StackHeight: 2}, Instruction: &types.Instruction{ProgramID: token, Data: event}},
+		{Metadata: &InstructionMetadata{StackHeight: 2}, Instruction: &types.Instruction{ProgramID: program, Data: event}},
+	})
+
+	events := nested.CPIEvents()
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event from the self-CPI only, got %d", len(events))
+	}
+	if events[0].ProgramID != program.String() || events[0].StackHeight != 1 || events[0].Path.String() != "[1]" {
+		t.Errorf("unexpected attribution: %+v", events[0])
+	}
+
+	registry := decoder.NewRegistry()
+	registry.RegisterForProgram(program, decoder.NewAnchorDecoder("Swapped", program, discriminator,
+		func(data []byte) (interface{}, error) { return data[0], nil },
+	))
+
+	decoded, err := registry.DecodeAllProgramData(events)
+	if err != nil {
+		t.Fatalf("DecodeAllProgramData: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0].Name != "Swapped" || decoded[0].Data != byte(42) {
+		t.Fatalf("unexpected events: %+v", decoded)
+	}
+
+	// The same decoder accepts the raw emit_cpi! instruction data.
+	raw, err := registry.Decode(event, &program)
+	if err != nil || raw.Data != byte(42) {
+		t.Errorf("decoding instruction data: %v, %+v", err, raw)
+	}
+}
@@ -0,0 +1,20 @@
+package instruction
+
+import (
+	"fmt"
+
+	"github.com/lugondev/go-carbon/pkg/decoder"
+)
+
+// NewIDLInstructionDecoder returns an InstructionDecoder decoding the
+// instructions of the program described by an IDL at runtime, without
+// generated code. The program ID is the IDL address.
+func NewIDLInstructionDecoder(idl *decoder.IDLDecoder) *ProgramInstructionDecoder[*decoder.IDLValue] {
+	return NewProgramInstructionDecoder(idl.ProgramID(), func(data []byte) (*decoder.IDLValue, error) {
+		value, err := idl.DecodeInstructionData(data)
+		if err == nil && value == nil {
+			err = fmt.Errorf("unknown instruction discriminator")
+		}
+		return value, err
+	})
+}
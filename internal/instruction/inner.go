@@ -0,0 +1,167 @@
+package instruction
+
+import (
+	"sync"
+
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+// DecodedInnerInstruction is an inner instruction decoded by InnerInstructionDecoders.
+//
+// Inner instructions of different programs decode into different types, so the
+// decoded payload is type-erased. Use InnerInstructionsOfType to recover typed values.
+type DecodedInnerInstruction struct {
+	// Metadata is the metadata associated with the inner instruction.
+	Metadata *InstructionMetadata
+
+	// ProgramID is the program ID that owns the inner instruction.
+	ProgramID types.Pubkey
+
+	// Data is the decoded data payload, or nil if no decoder matched.
+	Data any
+
+	// Accounts is a list of accounts involved in the inner instruction.
+	Accounts []types.AccountMeta
+
+	// RawInstruction is the original inner instruction.
+	RawInstruction *types.Instruction
+
+	// InnerInstructions contains the decoded instructions invoked by this instruction.
+	InnerInstructions []*DecodedInnerInstruction
+}
+
+// IsDecoded returns true if a decoder matched the inner instruction.
+func (d *DecodedInnerInstruction) IsDecoded() bool {
+	return d.Data != nil
+}
+
+// anyInstructionDecoder decodes an instruction into a type-erased payload.
+type anyInstructionDecoder func(instruction *types.Instruction) (any, bool)
+
+// InnerInstructionDecoders is a composite registry of instruction decoders for
+// multiple programs and decoded types, used to decode inner instructions.
+//
+// Decoders registered for a program are tried in registration order before the
+// fallback decoders, and the first decoder that succeeds wins.
+type InnerInstructionDecoders struct {
+	decodersByProgram map[types.Pubkey][]anyInstructionDecoder
+	fallbackDecoders  []anyInstructionDecoder
+	mu                sync.RWMutex
+}
+
+// NewInnerInstructionDecoders creates a new empty InnerInstructionDecoders registry.
+func NewInnerInstructionDecoders() *InnerInstructionDecoders {
+	return &InnerInstructionDecoders{
+		decodersByProgram: make(map[types.Pubkey][]anyInstructionDecoder),
+	}
+}
+
+// RegisterInnerDecoder registers a decoder for inner instructions of a specific program.
+func RegisterInnerDecoder[T any](
+	registry *InnerInstructionDecoders,
+	programID types.Pubkey,
+	decoder InstructionDecoder[T],
+) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.decodersByProgram[programID] = append(registry.decodersByProgram[programID], eraseDecoder(decoder))
+}
+
+// RegisterInnerFallbackDecoder registers a decoder that is tried for inner
+// instructions of any program. The decoder must check the program ID itself.
+func RegisterInnerFallbackDecoder[T any](registry *InnerInstructionDecoders, decoder InstructionDecoder[T]) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	registry.fallbackDecoders = append(registry.fallbackDecoders, eraseDecoder(decoder))
+}
+
+// eraseDecoder wraps a typed decoder into an anyInstructionDecoder.
+func eraseDecoder[T any](decoder InstructionDecoder[T]) anyInstructionDecoder {
+	return func(instruction *types.Instruction) (any, bool) {
+		decoded := decoder.DecodeInstruction(instruction)
+		if decoded == nil {
+			return nil, false
+		}
+		return decoded.Data, true
+	}
+}
+
+// Decode decodes a single instruction using the registered decoders.
+func (r *InnerInstructionDecoders) Decode(instruction *types.Instruction) (any, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, decode := range r.decodersByProgram[instruction.ProgramID] {
+		if data, ok := decode(instruction); ok {
+			return data, true
+		}
+	}
+
+	for _, decode := range r.fallbackDecoders {
+		if data, ok := decode(instruction); ok {
+			return data, true
+		}
+	}
+
+	return nil, false
+}
+
+// DecodeAll recursively decodes nested instructions, preserving their structure.
+// Instructions no decoder matches are kept with a nil Data payload.
+func (r *InnerInstructionDecoders) DecodeAll(nestedInstructions *NestedInstructions) []*DecodedInnerInstruction {
+	if nestedInstructions == nil || nestedInstructions.IsEmpty() {
+		return nil
+	}
+
+	result := make([]*DecodedInnerInstruction, 0, nestedInstructions.Len())
+	for _, nested := range nestedInstructions.Instructions {
+		decoded := &DecodedInnerInstruction{
+			Metadata:          nested.Metadata,
+			ProgramID:         nested.Instruction.ProgramID,
+			Accounts:          nested.Instruction.Accounts,
+			RawInstruction:    nested.Instruction,
+			InnerInstructions: r.DecodeAll(nested.InnerInstructions),
+		}
+		if data, ok := r.Decode(nested.Instruction); ok {
+			decoded.Data = data
+		}
+		result = append(result, decoded)
+	}
+
+	return result
+}
+
+// InnerInstructionOf is a decoded inner instruction whose payload is of type U.
+type InnerInstructionOf[U any] struct {
+	DecodedInstruction[U]
+
+	// Metadata is the metadata associated with the inner instruction.
+	Metadata *InstructionMetadata
+}
+
+// InnerInstructionsOfType returns the decoded inner instructions whose payload
+// is of type U, in depth-first execution order.
+func InnerInstructionsOfType[U any](innerInstructions []*DecodedInnerInstruction) []*InnerInstructionOf[U] {
+	var result []*InnerInstructionOf[U]
+	collectInnerInstructionsOfType(innerInstructions, &result)
+	return result
+}
+
+// collectInnerInstructionsOfType appends the inner instructions of type U to result.
+func collectInnerInstructionsOfType[U any](innerInstructions []*DecodedInnerInstruction, result *[]*InnerInstructionOf[U]) {
+	for _, inner := range innerInstructions {
+		if data, ok := inner.Data.(U); ok {
+			*result = append(*result, &InnerInstructionOf[U]{
+				DecodedInstruction: DecodedInstruction[U]{
+					ProgramID: inner.ProgramID,
+					Data:      data,
+					Accounts:  inner.Accounts,
+				},
+				Metadata: inner.Metadata,
+			})
+		}
+		collectInnerInstructionsOfType(inner.InnerInstructions, result)
+	}
+}
@@ -0,0 +1,113 @@
+package instruction
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lugondev/go-carbon/internal/metrics"
+	"github.com/lugondev/go-carbon/internal/processor"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+type testSwap struct{ Amount byte }
+
+type testTransfer struct{ Amount byte }
+
+func testProgram(b byte) types.Pubkey {
+	var pk types.Pubkey
+	pk[0] = b
+	return pk
+}
+
+func TestInstructionPipeDecodesInnerInstructions(t *testing.T) {
+	amm, token, other := testProgram(1), testProgram(2), testProgram(3)
+
+	ammDecoder := NewProgramInstructionDecoder(amm, func(data []byte) (testSwap, error) {
+		return testSwap{Amount: data[0]}, nil
+	})
+	tokenDecoder := NewProgramInstructionDecoder(token, func(data []byte) (testTransfer, error) {
+		if len(data) == 0 {
+			return testTransfer{}, errors.New("empty data")
+		}
+		return testTransfer{Amount: data[0]}, nil
+	})
+
+	innerDecoders := NewInnerInstructionDecoders()
+	RegisterInnerDecoder[testTransfer](innerDecoders, token, tokenDecoder)
+
+	nested := NestInstructions(InstructionsWithMetadata{
+		{Metadata: &InstructionMetadata{StackHeight: 1}, Instruction: &types.Instruction{ProgramID: amm, Data: []byte{9}}},
+		{Metadata: &InstructionMetadata{StackHeight: 2}, Instruction: &types.Instruction{ProgramID: token, Data: []byte{4}}},
+		{Metadata: &InstructionMetadata{StackHeight: 2}, Instruction: &types.Instruction{ProgramID: other}},
+		{Metadata: &InstructionMetadata{StackHeight: 3}, Instruction: &types.Instruction{ProgramID: token, Data: []byte{5}}},
+	})
+
+	var transfers []*InnerInstructionOf[testTransfer]
+	var inner []*DecodedInnerInstruction
+	proc := processor.ProcessorFunc[InstructionProcessorInput[testSwap]](
+		func(_ context.Context, input InstructionProcessorInput[testSwap], _ *metrics.Collection) error {
+			inner = input.DecodedInnerInstructions
+			transfers = InnerInstructionsOfType[testTransfer](input.DecodedInnerInstructions)
+			return nil
+		},
+	)
+
+	pipe := NewInstructionPipeWithInnerDecoders[testSwap](ammDecoder, innerDecoders, proc)
+	if err := pipe.Run(context.Background(), nested.Instructions[0], metrics.NewCollection()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if len(inner) != 2 || inner[1].IsDecoded() {
+		t.Fatalf("expected 2 inner instructions with the second undecoded, got %+v", inner)
+	}
+	if len(transfers) != 2 || transfers[0].Data.Amount != 4 || transfers[1].Data.Amount != 5 {
+		t.Fatalf("unexpected transfers: %+v", transfers)
+	}
+	if transfers[0].Metadata.StackHeight != 2 || transfers[1].Metadata.StackHeight != 3 {
+		t.Errorf("expected transfers to keep their metadata, got %+v, %+v", transfers[0].Metadata, transfers[1].Metadata)
+	}
+}
+
+func TestInstructionPipeDecodesInnerInstructionsOnce(t *testing.T) {
+	program := testProgram(1)
+
+	decoder := NewProgramInstructionDecoder(program, func(data []byte) (testSwap, error) {
+		return testSwap{Amount: data[0]}, nil
+	})
+	innerCalls := 0
+	innerDecoders := NewInnerInstructionDecoders()
+	RegisterInnerDecoder[testSwap](innerDecoders, program, InstructionDecoderFunc[testSwap](
+		func(instruction *types.Instruction) *DecodedInstruction[testSwap] {
+			innerCalls++
+			return decoder.DecodeInstruction(instruction)
+		},
+	))
+
+	// The program invokes itself, so the pipe processes every level.
+	nested := NestInstructions(InstructionsWithMetadata{
+		{Metadata: &InstructionMetadata{StackHeight: 1}, Instruction: &types.Instruction{ProgramID: program, Data: []byte{1}}},
+		{Metadata: &InstructionMetadata{StackHeight: 2}, Instruction: &types.Instruction{ProgramID: program, Data: []byte{2}}},
+		{Metadata: &InstructionMetadata{StackHeight: 3}, Instruction: &types.Instruction{ProgramID: program, Data: []byte{3}}},
+	})
+
+	var innerCounts []int
+	proc := processor.ProcessorFunc[InstructionProcessorInput[testSwap]](
+		func(_ context.Context, input InstructionProcessorInput[testSwap], _ *metrics.Collection) error {
+			innerCounts = append(innerCounts, len(InnerInstructionsOfType[testSwap](input.DecodedInnerInstructions)))
+			return nil
+		},
+	)
+
+	pipe := NewInstructionPipeWithInnerDecoders[testSwap](decoder, innerDecoders, proc)
+	if err := pipe.Run(context.Background(), nested.Instructions[0], metrics.NewCollection()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if len(innerCounts) != 3 || innerCounts[0] != 2 || innerCounts[1] != 1 || innerCounts[2] != 0 {
+		t.Errorf("unexpected inner instruction counts per level: %v", innerCounts)
+	}
+	if innerCalls != 2 {
+		t.Errorf("expected each inner instruction to be decoded once, got %d decodes", innerCalls)
+	}
+}
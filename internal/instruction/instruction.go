@@ -20,12 +20,15 @@ import (
 	"log/slog"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/lugondev/go-carbon/internal/datasource"
 	"github.com/lugondev/go-carbon/internal/filter"
 	"github.com/lugondev/go-carbon/internal/metrics"
 	"github.com/lugondev/go-carbon/internal/processor"
+	"github.com/lugondev/go-carbon/internal/tracing"
 	"github.com/lugondev/go-carbon/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // MaxInstructionStackDepth is the maximum depth of instruction nesting.
@@ -56,11 +59,12 @@ type InstructionMetadata struct {
 // TransactionMetadataRef is a reference to transaction metadata.
 // This avoids circular imports with the transaction package.
 type TransactionMetadataRef struct {
-	Slot        uint64
-	Signature   types.Signature
-	FeePayer    types.Pubkey
-	LogMessages []string
-	Meta        *types.TransactionStatusMeta
+	Slot          uint64
+	Signature     types.Signature
+	FeePayer      types.Pubkey
+	LogMessages   []string
+	LogsTruncated bool
+	Meta          *types.TransactionStatusMeta
 }
 
 // GetSlot returns the transaction slot.
@@ -255,13 +259,13 @@ type InstructionWithMetadata struct {
 // Type parameter T is the type representing the decoded data for the instruction.
 type DecodedInstruction[T any] struct {
 	// ProgramID is the program ID that owns the instruction.
-	ProgramID types.Pubkey
+	ProgramID types.Pubkey `json:"program_id"`
 
 	// Data is the decoded data payload for the instruction.
-	Data T
+	Data T `json:"data"`
 
 	// Accounts is a list of accounts involved in the instruction.
-	Accounts []types.AccountMeta
+	Accounts []types.AccountMeta `json:"accounts"`
 }
 
 // InstructionDecoder defines an interface for decoding Solana instructions into structured types.
@@ -292,6 +296,10 @@ type InstructionProcessorInput[T any] struct {
 	// InnerInstructions contains nested instructions.
 	InnerInstructions *NestedInstructions
 
+	// DecodedInnerInstructions contains the inner instructions decoded by the
+	// pipe's InnerDecoders. It is nil if the pipe has no inner decoders.
+	DecodedInnerInstructions []*DecodedInnerInstruction
+
 	// RawInstruction is the original instruction.
 	RawInstruction *types.Instruction
 }
@@ -306,6 +314,9 @@ type InstructionPipe[T any] struct {
 	// Processor handles decoded instructions.
 	Processor processor.Processor[InstructionProcessorInput[T]]
 
+	// InnerDecoders decodes inner instructions before processing (optional).
+	InnerDecoders *InnerInstructionDecoders
+
 	// Filters determine which instruction updates should be processed.
 	Filters []filter.Filter
 
@@ -340,12 +351,37 @@ func NewInstructionPipeWithFilters[T any](
 	}
 }
 
+// NewInstructionPipeWithInnerDecoders creates a new InstructionPipe that decodes
+// inner instructions with innerDecoders, so the processor receives them as
+// DecodedInnerInstructions alongside the decoded instruction.
+func NewInstructionPipeWithInnerDecoders[T any](
+	decoder InstructionDecoder[T],
+	innerDecoders *InnerInstructionDecoders,
+	proc processor.Processor[InstructionProcessorInput[T]],
+) *InstructionPipe[T] {
+	pipe := NewInstructionPipe(decoder, proc)
+	pipe.InnerDecoders = innerDecoders
+	return pipe
+}
+
+// WithInnerDecoders sets the registry used to decode inner instructions.
+func (p *InstructionPipe[T]) WithInnerDecoders(innerDecoders *InnerInstructionDecoders) *InstructionPipe[T] {
+	p.InnerDecoders = innerDecoders
+	return p
+}
+
 // WithLogger sets a custom logger for the InstructionPipe.
 func (p *InstructionPipe[T]) WithLogger(logger *slog.Logger) *InstructionPipe[T] {
 	p.Logger = logger
 	return p
 }
 
+// ProgramIDs returns the programs whose instructions this pipe decodes, as
+// reported by its decoder.
+func (p *InstructionPipe[T]) ProgramIDs() ([]types.Pubkey, bool) {
+	return ProgramIDsOf(p.Decoder)
+}
+
 // GetFilters returns the filters associated with this pipe.
 func (p *InstructionPipe[T]) GetFilters() []filter.Filter {
 	return p.Filters
@@ -356,6 +392,19 @@ func (p *InstructionPipe[T]) Run(
 	ctx context.Context,
 	nestedInstruction *NestedInstruction,
 	metricsCollection *metrics.Collection,
+) error {
+	return p.run(ctx, nestedInstruction, nil, metricsCollection)
+}
+
+// run processes a NestedInstruction and its inner instructions. decodedInner
+// holds its inner instructions already decoded by InnerDecoders, or is nil if
+// they have not been decoded yet, so each inner instruction is decoded once
+// however deeply it is nested.
+func (p *InstructionPipe[T]) run(
+	ctx context.Context,
+	nestedInstruction *NestedInstruction,
+	decodedInner []*DecodedInnerInstruction,
+	metricsCollection *metrics.Collection,
 ) error {
 	p.Logger.Debug("InstructionPipe.Run",
 		"program_id", nestedInstruction.Instruction.ProgramID.String(),
@@ -363,7 +412,16 @@ func (p *InstructionPipe[T]) Run(
 	)
 
 	// Try to decode the instruction
+	_, decodeSpan := tracing.Start(ctx, tracing.SpanDecodeInstruction, func() []attribute.KeyValue {
+		attrs := []attribute.KeyValue{tracing.ProgramID(nestedInstruction.Instruction.ProgramID)}
+		if tx := nestedInstruction.Metadata.TransactionMetadata; tx != nil {
+			attrs = append(attrs, tracing.Signature(tx.Signature), tracing.Slot(tx.Slot))
+		}
+		return attrs
+	})
 	decoded := p.Decoder.DecodeInstruction(nestedInstruction.Instruction)
+	decodeSpan.End()
+	metrics.RecordPipeDecode(ctx, metricsCollection, decoded != nil)
 	if decoded != nil {
 		input := InstructionProcessorInput[T]{
 			Metadata:           nestedInstruction.Metadata,
@@ -372,14 +430,30 @@ func (p *InstructionPipe[T]) Run(
 			RawInstruction:     nestedInstruction.Instruction,
 		}
 
-		if err := p.Processor.Process(ctx, input, metricsCollection); err != nil {
+		if p.InnerDecoders != nil {
+			if decodedInner == nil {
+				decodedInner = p.InnerDecoders.DecodeAll(nestedInstruction.InnerInstructions)
+			}
+			input.DecodedInnerInstructions = decodedInner
+		}
+
+		processCtx, span := tracing.Start(ctx, tracing.SpanProcess, nil)
+		start := time.Now()
+		err := p.Processor.Process(processCtx, input, metricsCollection)
+		metrics.RecordPipeProcess(ctx, metricsCollection, time.Since(start), err)
+		tracing.End(span, err)
+		if err != nil {
 			return err
 		}
 	}
 
-	// Recursively process inner instructions
-	for _, innerInstruction := range nestedInstruction.InnerInstructions.Instructions {
-		if err := p.Run(ctx, innerInstruction, metricsCollection); err != nil {
+	// Recursively process inner instructions, reusing their decoded subtrees
+	for i, innerInstruction := range nestedInstruction.InnerInstructions.Instructions {
+		var decodedChildren []*DecodedInnerInstruction
+		if decodedInner != nil {
+			decodedChildren = decodedInner[i].InnerInstructions
+		}
+		if err := p.run(ctx, innerInstruction, decodedChildren, metricsCollection); err != nil {
 			return err
 		}
 	}
@@ -438,6 +512,14 @@ func (n *NestedInstruction) GetData() []byte {
 	return n.Instruction.Data
 }
 
+// GetInnerInstructions returns the instructions invoked by the instruction.
+func (n *NestedInstruction) GetInnerInstructions() filter.NestedInstructions {
+	if n.InnerInstructions == nil {
+		return nil
+	}
+	return n.InnerInstructions
+}
+
 // NestedInstructions is a collection of nested instructions.
 type NestedInstructions struct {
 	Instructions []*NestedInstruction
@@ -640,6 +722,28 @@ func (d *ProgramInstructionDecoder[T]) DecodeInstruction(instruction *types.Inst
 	}
 }
 
+// ProgramIDs returns the program ID this decoder handles.
+func (d *ProgramInstructionDecoder[T]) ProgramIDs() ([]types.Pubkey, bool) {
+	return []types.Pubkey{d.ProgramID}, true
+}
+
+// ProgramScoped is implemented by decoders, pipes and filters that only act on
+// instructions of known programs. ProgramIDs returns false if the set of
+// programs is unknown or unrestricted.
+type ProgramScoped interface {
+	ProgramIDs() ([]types.Pubkey, bool)
+}
+
+// ProgramIDsOf returns the program IDs v is scoped to, or false if v is not
+// ProgramScoped or not restricted to known programs.
+func ProgramIDsOf(v any) ([]types.Pubkey, bool) {
+	scoped, ok := v.(ProgramScoped)
+	if !ok {
+		return nil, false
+	}
+	return scoped.ProgramIDs()
+}
+
 // CompositeInstructionDecoder tries multiple decoders in sequence.
 // It returns the result from the first decoder that succeeds.
 type CompositeInstructionDecoder[T any] struct {
@@ -667,3 +771,17 @@ func (c *CompositeInstructionDecoder[T]) DecodeInstruction(instruction *types.In
 	}
 	return nil
 }
+
+// ProgramIDs returns the union of the program IDs of all decoders. It returns
+// false if any decoder is not scoped to known programs.
+func (c *CompositeInstructionDecoder[T]) ProgramIDs() ([]types.Pubkey, bool) {
+	var programIDs []types.Pubkey
+	for _, decoder := range c.decoders {
+		ids, ok := ProgramIDsOf(decoder)
+		if !ok {
+			return nil, false
+		}
+		programIDs = append(programIDs, ids...)
+	}
+	return programIDs, true
+}
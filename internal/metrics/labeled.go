@@ -0,0 +1,54 @@
+package metrics
+
+import "context"
+
+// LabeledMetrics is a Metrics implementation that records metrics with labels.
+//
+// Backends that do not implement LabeledMetrics can still be used with labels
+// through Labeled, which passes the labels in the context instead.
+type LabeledMetrics interface {
+	Metrics
+
+	// UpdateGaugeWith sets a gauge metric with the given labels to the specified value.
+	UpdateGaugeWith(ctx context.Context, name string, labels Labels, value float64) error
+
+	// IncrementCounterWith increments a counter metric with the given labels by the specified value.
+	IncrementCounterWith(ctx context.Context, name string, labels Labels, value uint64) error
+
+	// RecordHistogramWith records a value in a histogram metric with the given labels.
+	RecordHistogramWith(ctx context.Context, name string, labels Labels, value float64) error
+}
+
+// Ensure the built-in backends implement LabeledMetrics.
+var (
+	_ LabeledMetrics = (*Collection)(nil)
+	_ LabeledMetrics = (*LogMetrics)(nil)
+)
+
+// Labeled returns m as a LabeledMetrics. If m does not implement LabeledMetrics,
+// the returned adapter attaches the labels to the context with WithLabels and
+// calls the unlabeled method, so backends that ignore context labels keep
+// recording the metric by name only.
+func Labeled(m Metrics) LabeledMetrics {
+	if lm, ok := m.(LabeledMetrics); ok {
+		return lm
+	}
+	return labeledAdapter{m}
+}
+
+// labeledAdapter adapts a Metrics to LabeledMetrics through context labels.
+type labeledAdapter struct {
+	Metrics
+}
+
+func (a labeledAdapter) UpdateGaugeWith(ctx context.Context, name string, labels Labels, value float64) error {
+	return a.UpdateGauge(WithLabels(ctx, labels), name, value)
+}
+
+func (a labeledAdapter) IncrementCounterWith(ctx context.Context, name string, labels Labels, value uint64) error {
+	return a.IncrementCounter(WithLabels(ctx, labels), name, value)
+}
+
+func (a labeledAdapter) RecordHistogramWith(ctx context.Context, name string, labels Labels, value float64) error {
+	return a.RecordHistogram(WithLabels(ctx, labels), name, value)
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+)
+
+// recordingMetrics is an unlabeled backend that records the context labels it sees.
+type recordingMetrics struct {
+	NoopMetrics
+	labels Labels
+}
+
+func (r *recordingMetrics) IncrementCounter(ctx context.Context, name string, value uint64) error {
+	r.labels = LabelsFromContext(ctx)
+	return nil
+}
+
+func TestCollectionLabeledAdapters(t *testing.T) {
+	legacy := &recordingMetrics{}
+	logMetrics := NewLogMetrics(nil)
+	c := NewCollection(legacy, logMetrics)
+
+	ctx := WithLabels(context.Background(), Labels{LabelDatasourceID: "rpc"})
+	if err := c.IncrementCounterWith(ctx, "updates", Labels{LabelPipe: "swaps"}, 3); err != nil {
+		t.Fatalf("IncrementCounterWith: %v", err)
+	}
+
+	if legacy.labels[LabelDatasourceID] != "rpc" || legacy.labels[LabelPipe] != "swaps" {
+		t.Errorf("legacy backend got labels %v", legacy.labels)
+	}
+
+	series := `updates{datasource_id="rpc",pipe="swaps"}`
+	if got := logMetrics.counters[series]; got != 3 {
+		t.Errorf("counters[%s] = %d, want 3", series, got)
+	}
+
+	_ = c.IncrementCounter(context.Background(), "updates", 1)
+	if got := logMetrics.counters["updates"]; got != 1 {
+		t.Errorf("unlabeled counter = %d, want 1", got)
+	}
+}
+
+func TestWithLabelsMerges(t *testing.T) {
+	ctx := WithLabels(context.Background(), Labels{LabelPipe: "a", LabelUpdateType: "account"})
+	ctx = WithLabels(ctx, Labels{LabelPipe: "b"})
+
+	labels := LabelsFromContext(ctx)
+	if labels[LabelPipe] != "b" || labels[LabelUpdateType] != "account" {
+		t.Errorf("unexpected labels %v", labels)
+	}
+}
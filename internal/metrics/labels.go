@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"maps"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Label names used by the pipeline.
+const (
+	LabelDatasourceID = "datasource_id"
+	LabelPipe         = "pipe"
+	LabelUpdateType   = "update_type"
+)
+
+// Labels are dimensions attached to a metric, such as the datasource or pipe
+// that produced it.
+type Labels map[string]string
+
+// labelsKey is the context key for Labels.
+type labelsKey struct{}
+
+// WithLabels returns a context carrying labels merged over any labels already
+// in ctx. Backends that support labels, such as Prometheus, attach them to
+// every metric recorded with the returned context.
+func WithLabels(ctx context.Context, labels Labels) context.Context {
+	if len(labels) == 0 {
+		return ctx
+	}
+
+	merged := make(Labels, len(labels))
+	if existing, ok := ctx.Value(labelsKey{}).(Labels); ok {
+		maps.Copy(merged, existing)
+	}
+	maps.Copy(merged, labels)
+	return context.WithValue(ctx, labelsKey{}, merged)
+}
+
+// LabelsFromContext returns the labels carried by ctx, or nil if there are none.
+func LabelsFromContext(ctx context.Context) Labels {
+	labels, _ := ctx.Value(labelsKey{}).(Labels)
+	return labels
+}
+
+// Keys returns the label names in sorted order.
+func (l Labels) Keys() []string {
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// String formats the labels in sorted order as {name="value",...}, or returns
+// an empty string if there are no labels.
+func (l Labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range l.Keys() {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(k)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(l[k]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
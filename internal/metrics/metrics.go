@@ -135,6 +135,45 @@ func (c *Collection) RecordHistogram(ctx context.Context, name string, value flo
 	return nil
 }
 
+// UpdateGaugeWith updates a labeled gauge metric across all implementations.
+func (c *Collection) UpdateGaugeWith(ctx context.Context, name string, labels Labels, value float64) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, m := range c.metrics {
+		if err := Labeled(m).UpdateGaugeWith(ctx, name, labels, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IncrementCounterWith increments a labeled counter across all implementations.
+func (c *Collection) IncrementCounterWith(ctx context.Context, name string, labels Labels, value uint64) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, m := range c.metrics {
+		if err := Labeled(m).IncrementCounterWith(ctx, name, labels, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordHistogramWith records a labeled histogram value across all implementations.
+func (c *Collection) RecordHistogramWith(ctx context.Context, name string, labels Labels, value float64) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, m := range c.metrics {
+		if err := Labeled(m).RecordHistogramWith(ctx, name, labels, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Len returns the number of metrics implementations in the collection.
 func (c *Collection) Len() int {
 	c.mu.RLock()
@@ -209,30 +248,53 @@ func (l *LogMetrics) Shutdown(ctx context.Context) error {
 
 // UpdateGauge logs the gauge update.
 func (l *LogMetrics) UpdateGauge(ctx context.Context, name string, value float64) error {
+	return l.UpdateGaugeWith(ctx, name, nil, value)
+}
+
+// IncrementCounter logs the counter increment.
+func (l *LogMetrics) IncrementCounter(ctx context.Context, name string, value uint64) error {
+	return l.IncrementCounterWith(ctx, name, nil, value)
+}
+
+// RecordHistogram logs the histogram record.
+func (l *LogMetrics) RecordHistogram(ctx context.Context, name string, value float64) error {
+	return l.RecordHistogramWith(ctx, name, nil, value)
+}
+
+// UpdateGaugeWith logs the gauge update. Each label set is tracked as its own
+// series, keyed by the metric name followed by its labels.
+func (l *LogMetrics) UpdateGaugeWith(ctx context.Context, name string, labels Labels, value float64) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	l.gauges[name] = value
-	l.logger.Debug("gauge updated", "name", name, "value", value)
+	series := seriesName(ctx, name, labels)
+	l.gauges[series] = value
+	l.logger.Debug("gauge updated", "name", series, "value", value)
 	return nil
 }
 
-// IncrementCounter logs the counter increment.
-func (l *LogMetrics) IncrementCounter(ctx context.Context, name string, value uint64) error {
+// IncrementCounterWith logs the counter increment.
+func (l *LogMetrics) IncrementCounterWith(ctx context.Context, name string, labels Labels, value uint64) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 
-	l.counters[name] += value
-	l.logger.Debug("counter incremented", "name", name, "value", value, "total", l.counters[name])
+	series := seriesName(ctx, name, labels)
+	l.counters[series] += value
+	l.logger.Debug("counter incremented", "name", series, "value", value, "total", l.counters[series])
 	return nil
 }
 
-// RecordHistogram logs the histogram record.
-func (l *LogMetrics) RecordHistogram(ctx context.Context, name string, value float64) error {
-	l.logger.Debug("histogram recorded", "name", name, "value", value)
+// RecordHistogramWith logs the histogram record.
+func (l *LogMetrics) RecordHistogramWith(ctx context.Context, name string, labels Labels, value float64) error {
+	l.logger.Debug("histogram recorded", "name", seriesName(ctx, name, labels), "value", value)
 	return nil
 }
 
+// seriesName returns name followed by the context labels merged with labels.
+func seriesName(ctx context.Context, name string, labels Labels) string {
+	return name + LabelsFromContext(WithLabels(ctx, labels)).String()
+}
+
 // Metric names used by the pipeline.
 const (
 	MetricUpdatesReceived                = "updates_received"
@@ -246,4 +308,37 @@ const (
 	MetricTransactionUpdatesProcessed    = "transaction_updates_processed"
 	MetricAccountDeletionsProcessed      = "account_deletions_processed"
 	MetricBlockDetailsProcessed          = "block_details_processed"
+	MetricFailedTransactionsProcessed    = "failed_transactions_processed"
+	MetricTransactionsSkippedByStatus    = "transactions_skipped_by_status"
+	MetricTransactionsSkippedByProgram   = "transactions_skipped_by_program"
+	MetricVoteTransactionsSkipped        = "vote_transactions_skipped"
+	MetricUpdatesSkippedByFilter         = "updates_skipped_by_filter"
+)
+
+// Metric names for transactions whose logs were truncated by the runtime.
+const (
+	MetricTransactionLogsTruncated      = "transaction_logs_truncated"
+	MetricTruncatedLogsRefetched        = "truncated_logs_refetched"
+	MetricTruncatedLogsRefetchFailed    = "truncated_logs_refetch_failed"
+	MetricTransactionEventsPossiblyLost = "transaction_events_possibly_lost"
+)
+
+// Metric names for slot lag and data freshness.
+const (
+	MetricSlotHighestSeen           = "slot_highest_seen"
+	MetricSlotHighestProcessed      = "slot_highest_processed"
+	MetricSlotReference             = "slot_reference"
+	MetricSlotLag                   = "slot_lag"
+	MetricBlockTimeStalenessSeconds = "block_time_staleness_seconds"
+)
+
+// Metric names recorded per pipe, labeled with the pipe name.
+const (
+	MetricPipeRunTimeNanoseconds     = "pipe_run_time_nanoseconds"
+	MetricPipeProcessTimeNanoseconds = "pipe_process_time_nanoseconds"
+	MetricPipeSkippedByFilter        = "pipe_skipped_by_filter"
+	MetricPipeDecoded                = "pipe_decoded"
+	MetricPipeDecodeMiss             = "pipe_decode_miss"
+	MetricPipeProcessed              = "pipe_processed"
+	MetricPipeFailed                 = "pipe_failed"
 )
@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"context"
+	"time"
+)
+
+// RecordPipeDecode records whether a pipe's decoder recognized an update.
+// It is a no-op if m is nil.
+func RecordPipeDecode(ctx context.Context, m *Collection, decoded bool) {
+	if m == nil {
+		return
+	}
+
+	name := MetricPipeDecodeMiss
+	if decoded {
+		name = MetricPipeDecoded
+	}
+	_ = m.IncrementCounter(ctx, name, 1)
+}
+
+// RecordPipeProcess records the outcome and duration of a pipe's processor call.
+// It is a no-op if m is nil.
+func RecordPipeProcess(ctx context.Context, m *Collection, elapsed time.Duration, err error) {
+	if m == nil {
+		return
+	}
+
+	_ = m.RecordHistogram(ctx, MetricPipeProcessTimeNanoseconds, float64(elapsed.Nanoseconds()))
+	if err != nil {
+		_ = m.IncrementCounter(ctx, MetricPipeFailed, 1)
+		return
+	}
+	_ = m.IncrementCounter(ctx, MetricPipeProcessed, 1)
+}
@@ -0,0 +1,357 @@
+// Package prometheus provides a Prometheus backend for carbon pipeline metrics.
+//
+// PrometheusMetrics implements metrics.Metrics with real counters, gauges and
+// histograms, and serves them on an HTTP /metrics endpoint. Labels attached to
+// the context with metrics.WithLabels, such as datasource_id, pipe and
+// update_type, become Prometheus labels.
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lugondev/go-carbon/internal/metrics"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// DefaultAddr is the default address of the metrics HTTP server.
+const DefaultAddr = ":9090"
+
+// DefaultPath is the default path of the metrics endpoint.
+const DefaultPath = "/metrics"
+
+// DefaultNamespace is the default namespace prefixed to every metric name.
+const DefaultNamespace = "carbon"
+
+// DefaultBuckets are the default histogram buckets, in seconds for durations.
+var DefaultBuckets = prom.DefBuckets
+
+// unitScales converts buckets in seconds to the unit named by the suffix of a
+// histogram name, such as the pipeline's *_nanoseconds histograms.
+var unitScales = []struct {
+	suffix string
+	scale  float64
+}{
+	{"_nanoseconds", 1e9},
+	{"_microseconds", 1e6},
+	{"_milliseconds", 1e3},
+}
+
+// DefaultLabelNames are the label names every metric is registered with.
+var DefaultLabelNames = []string{
+	metrics.LabelDatasourceID,
+	metrics.LabelPipe,
+	metrics.LabelUpdateType,
+}
+
+// Config holds the configuration for the Prometheus backend.
+type Config struct {
+	// Addr is the listen address of the metrics HTTP server.
+	// Leave empty to not start a server and mount Handler elsewhere.
+	Addr string
+
+	// Path is the path of the metrics endpoint.
+	Path string
+
+	// Namespace is prefixed to every metric name.
+	Namespace string
+
+	// Buckets are the default histogram buckets, in seconds for durations.
+	// Histograms whose names end in _milliseconds, _microseconds or
+	// _nanoseconds get them scaled to that unit.
+	Buckets []float64
+
+	// HistogramBuckets overrides the buckets for specific histograms by name.
+	HistogramBuckets map[string][]float64
+
+	// LabelNames are the label names every metric is registered with. Labels in
+	// the context that are not listed are dropped, and missing ones are empty.
+	LabelNames []string
+
+	// ConstLabels are attached to every metric, e.g. the indexer instance.
+	ConstLabels map[string]string
+
+	// Registry is the registry metrics are registered in. A new registry is
+	// created if nil.
+	Registry *prom.Registry
+}
+
+// DefaultConfig returns a default configuration.
+func DefaultConfig() *Config {
+	return &Config{
+		Addr:       DefaultAddr,
+		Path:       DefaultPath,
+		Namespace:  DefaultNamespace,
+		Buckets:    DefaultBuckets,
+		LabelNames: DefaultLabelNames,
+	}
+}
+
+// PrometheusMetrics is a metrics.Metrics implementation backed by Prometheus.
+//
+// Metric vectors are created lazily on first use, so any metric name used by
+// the pipeline, datasources or processors is exported without registration.
+type PrometheusMetrics struct {
+	config     *Config
+	registry   *prom.Registry
+	counters   map[string]*prom.CounterVec
+	gauges     map[string]*prom.GaugeVec
+	histograms map[string]*prom.HistogramVec
+	server     *http.Server
+	logger     *slog.Logger
+	mu         sync.RWMutex
+}
+
+// Ensure PrometheusMetrics implements metrics.LabeledMetrics.
+var _ metrics.LabeledMetrics = (*PrometheusMetrics)(nil)
+
+// NewPrometheusMetrics creates a new PrometheusMetrics.
+func NewPrometheusMetrics(config *Config) *PrometheusMetrics {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if config.Path == "" {
+		config.Path = DefaultPath
+	}
+	if len(config.Buckets) == 0 {
+		config.Buckets = DefaultBuckets
+	}
+	if config.LabelNames == nil {
+		config.LabelNames = DefaultLabelNames
+	}
+
+	registry := config.Registry
+	if registry == nil {
+		registry = prom.NewRegistry()
+	}
+
+	return &PrometheusMetrics{
+		config:     config,
+		registry:   registry,
+		counters:   make(map[string]*prom.CounterVec),
+		gauges:     make(map[string]*prom.GaugeVec),
+		histograms: make(map[string]*prom.HistogramVec),
+		logger:     slog.Default(),
+	}
+}
+
+// WithLogger sets a custom logger.
+func (p *PrometheusMetrics) WithLogger(logger *slog.Logger) *PrometheusMetrics {
+	p.logger = logger
+	return p
+}
+
+// Registry returns the registry metrics are registered in.
+func (p *PrometheusMetrics) Registry() *prom.Registry {
+	return p.registry
+}
+
+// Handler returns an HTTP handler serving the metrics in the Prometheus text format.
+func (p *PrometheusMetrics) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+// Initialize starts the metrics HTTP server if an address is configured.
+func (p *PrometheusMetrics) Initialize(ctx context.Context) error {
+	if p.config.Addr == "" {
+		return nil
+	}
+
+	listener, err := net.Listen("tcp", p.config.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", p.config.Addr, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(p.config.Path, p.Handler())
+	p.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := p.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			p.logger.Error("prometheus metrics server failed", "error", err)
+		}
+	}()
+
+	p.logger.Info("prometheus metrics server started",
+		"addr", listener.Addr().String(),
+		"path", p.config.Path,
+	)
+	return nil
+}
+
+// Flush is a no-op; Prometheus scrapes metrics on demand.
+func (p *PrometheusMetrics) Flush(ctx context.Context) error {
+	return nil
+}
+
+// Shutdown stops the metrics HTTP server.
+func (p *PrometheusMetrics) Shutdown(ctx context.Context) error {
+	if p.server == nil {
+		return nil
+	}
+	return p.server.Shutdown(ctx)
+}
+
+// UpdateGauge sets a gauge metric to the specified value.
+func (p *PrometheusMetrics) UpdateGauge(ctx context.Context, name string, value float64) error {
+	gauge, err := p.gauge(name)
+	if err != nil {
+		return err
+	}
+	gauge.With(p.labelValues(ctx)).Set(value)
+	return nil
+}
+
+// IncrementCounter increments a counter metric by the specified value.
+func (p *PrometheusMetrics) IncrementCounter(ctx context.Context, name string, value uint64) error {
+	counter, err := p.counter(name)
+	if err != nil {
+		return err
+	}
+	counter.With(p.labelValues(ctx)).Add(float64(value))
+	return nil
+}
+
+// RecordHistogram records a value in a histogram metric.
+func (p *PrometheusMetrics) RecordHistogram(ctx context.Context, name string, value float64) error {
+	histogram, err := p.histogram(name)
+	if err != nil {
+		return err
+	}
+	histogram.With(p.labelValues(ctx)).Observe(value)
+	return nil
+}
+
+// UpdateGaugeWith sets a gauge metric with the given labels to the specified value.
+func (p *PrometheusMetrics) UpdateGaugeWith(ctx context.Context, name string, labels metrics.Labels, value float64) error {
+	return p.UpdateGauge(metrics.WithLabels(ctx, labels), name, value)
+}
+
+// IncrementCounterWith increments a counter metric with the given labels by the specified value.
+func (p *PrometheusMetrics) IncrementCounterWith(ctx context.Context, name string, labels metrics.Labels, value uint64) error {
+	return p.IncrementCounter(metrics.WithLabels(ctx, labels), name, value)
+}
+
+// RecordHistogramWith records a value in a histogram metric with the given labels.
+func (p *PrometheusMetrics) RecordHistogramWith(ctx context.Context, name string, labels metrics.Labels, value float64) error {
+	return p.RecordHistogram(metrics.WithLabels(ctx, labels), name, value)
+}
+
+// labelValues returns the configured labels with their values from ctx.
+func (p *PrometheusMetrics) labelValues(ctx context.Context) prom.Labels {
+	labels := metrics.LabelsFromContext(ctx)
+	values := make(prom.Labels, len(p.config.LabelNames))
+	for _, name := range p.config.LabelNames {
+		values[name] = labels[name]
+	}
+	return values
+}
+
+// counter returns the counter vector for name, registering it on first use.
+func (p *PrometheusMetrics) counter(name string) (*prom.CounterVec, error) {
+	return getOrRegister(p, p.counters, name, func(opts metricOpts) *prom.CounterVec {
+		return prom.NewCounterVec(prom.CounterOpts(opts.Opts), opts.labelNames)
+	})
+}
+
+// gauge returns the gauge vector for name, registering it on first use.
+func (p *PrometheusMetrics) gauge(name string) (*prom.GaugeVec, error) {
+	return getOrRegister(p, p.gauges, name, func(opts metricOpts) *prom.GaugeVec {
+		return prom.NewGaugeVec(prom.GaugeOpts(opts.Opts), opts.labelNames)
+	})
+}
+
+// histogram returns the histogram vector for name, registering it on first use.
+func (p *PrometheusMetrics) histogram(name string) (*prom.HistogramVec, error) {
+	buckets, ok := p.config.HistogramBuckets[name]
+	if !ok {
+		buckets = scaledBuckets(p.config.Buckets, name)
+	}
+
+	return getOrRegister(p, p.histograms, name, func(opts metricOpts) *prom.HistogramVec {
+		return prom.NewHistogramVec(prom.HistogramOpts{
+			Namespace:   opts.Namespace,
+			Name:        opts.Name,
+			Help:        opts.Help,
+			ConstLabels: opts.ConstLabels,
+			Buckets:     buckets,
+		}, opts.labelNames)
+	})
+}
+
+// scaledBuckets returns buckets converted from seconds to the unit of the
+// histogram name, or buckets unchanged if the name has no unit suffix.
+func scaledBuckets(buckets []float64, name string) []float64 {
+	for _, unit := range unitScales {
+		if !strings.HasSuffix(name, unit.suffix) {
+			continue
+		}
+		scaled := make([]float64, len(buckets))
+		for i, bucket := range buckets {
+			scaled[i] = bucket * unit.scale
+		}
+		return scaled
+	}
+	return buckets
+}
+
+// metricOpts are the options shared by all metric vectors.
+type metricOpts struct {
+	prom.Opts
+	labelNames []string
+}
+
+// invalidNameChars matches characters not allowed in Prometheus metric names.
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_:]`)
+
+// getOrRegister returns the vector for name from vecs, creating and
+// registering it with newVec on first use.
+func getOrRegister[V prom.Collector](
+	p *PrometheusMetrics,
+	vecs map[string]V,
+	name string,
+	newVec func(metricOpts) V,
+) (V, error) {
+	p.mu.RLock()
+	vec, exists := vecs[name]
+	p.mu.RUnlock()
+	if exists {
+		return vec, nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if vec, exists := vecs[name]; exists {
+		return vec, nil
+	}
+
+	vec = newVec(metricOpts{
+		Opts: prom.Opts{
+			Namespace:   p.config.Namespace,
+			Name:        invalidNameChars.ReplaceAllString(name, "_"),
+			Help:        fmt.Sprintf("carbon pipeline metric %s", name),
+			ConstLabels: p.config.ConstLabels,
+		},
+		labelNames: p.config.LabelNames,
+	})
+	if err := p.registry.Register(vec); err != nil {
+		var zero V
+		return zero, fmt.Errorf("failed to register metric %s: %w", name, err)
+	}
+
+	vecs[name] = vec
+	return vec, nil
+}
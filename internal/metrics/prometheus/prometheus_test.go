@@ -0,0 +1,74 @@
+package prometheus
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lugondev/go-carbon/internal/metrics"
+)
+
+func TestPrometheusMetricsHandler(t *testing.T) {
+	p := NewPrometheusMetrics(&Config{
+		Namespace:        DefaultNamespace,
+		HistogramBuckets: map[string][]float64{"latency": {1, 10}},
+	})
+
+	ctx := metrics.WithLabels(context.Background(), metrics.Labels{
+		metrics.LabelDatasourceID: "rpc",
+		metrics.LabelPipe:         "swaps",
+		"unknown":                 "dropped",
+	})
+
+	if err := p.IncrementCounter(ctx, "updates.processed", 2); err != nil {
+		t.Fatalf("IncrementCounter: %v", err)
+	}
+	if err := p.UpdateGauge(ctx, "queue_size", 7); err != nil {
+		t.Fatalf("UpdateGauge: %v", err)
+	}
+	if err := p.RecordHistogram(ctx, "latency", 5); err != nil {
+		t.Fatalf("RecordHistogram: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	out := string(body)
+
+	for _, want := range []string{
+		`carbon_updates_processed{datasource_id="rpc",pipe="swaps",update_type=""} 2`,
+		`carbon_queue_size{datasource_id="rpc",pipe="swaps",update_type=""} 7`,
+		`carbon_latency_bucket{datasource_id="rpc",pipe="swaps",update_type="",le="1"} 0`,
+		`carbon_latency_bucket{datasource_id="rpc",pipe="swaps",update_type="",le="10"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in output:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "dropped") {
+		t.Error("unknown label should be dropped")
+	}
+}
+
+func TestPrometheusMetricsScalesDefaultBuckets(t *testing.T) {
+	p := NewPrometheusMetrics(&Config{Namespace: DefaultNamespace})
+
+	metrics.RecordPipeProcess(context.Background(), metrics.NewCollection(p), 2*time.Millisecond, nil)
+
+	rec := httptest.NewRecorder()
+	p.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	out := string(body)
+
+	// 2ms lands in the 5ms bucket, expressed in nanoseconds.
+	want := `carbon_pipe_process_time_nanoseconds_bucket{datasource_id="",pipe="",update_type="",le="5e+06"} 1`
+	if !strings.Contains(out, want) {
+		t.Errorf("missing %q in output:\n%s", want, out)
+	}
+	if strings.Contains(out, `le="0.005"`) {
+		t.Error("expected buckets in nanoseconds, not seconds")
+	}
+}
@@ -0,0 +1,384 @@
+// Package statsd provides a DogStatsD backend for carbon pipeline metrics.
+//
+// StatsdMetrics buffers metrics in memory and ships them over UDP on Flush.
+// Counters are summed, only the last value of each gauge is sent and histogram
+// values are packed into multi-value lines, so the pipeline's per-update
+// metrics cost a few packets per flush instead of one per update. Labels attached with metrics.WithLabels or the labeled methods are
+// sent as DogStatsD tags.
+package statsd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lugondev/go-carbon/internal/metrics"
+)
+
+// DefaultAddr is the default address of the DogStatsD agent.
+const DefaultAddr = "127.0.0.1:8125"
+
+// DefaultPrefix is the default prefix of every metric name.
+const DefaultPrefix = "carbon."
+
+// DefaultMaxPacketSize is the default maximum size of a UDP packet. It keeps
+// packets within the MTU of most networks.
+const DefaultMaxPacketSize = 1432
+
+// DefaultMaxHistogramValues is the default number of values buffered per
+// histogram series between flushes.
+const DefaultMaxHistogramValues = 1024
+
+// Config holds the configuration for the DogStatsD backend.
+type Config struct {
+	// Addr is the UDP address of the DogStatsD agent.
+	Addr string
+
+	// Prefix is prepended to every metric name.
+	Prefix string
+
+	// Tags are sent with every metric, e.g. "env:prod".
+	Tags []string
+
+	// SampleRates sets the sample rate of specific counters and histograms by
+	// name. Metrics without a sample rate are always recorded.
+	SampleRates map[string]float64
+
+	// MaxPacketSize is the maximum size of a UDP packet.
+	MaxPacketSize int
+
+	// MaxHistogramValues caps the values buffered per histogram series between
+	// flushes. Past the cap, a uniform sample of the values is kept and sent
+	// with the matching sample rate, so the agent still counts every value.
+	MaxHistogramValues int
+}
+
+// DefaultConfig returns a default configuration.
+func DefaultConfig() *Config {
+	return &Config{
+		Addr:               DefaultAddr,
+		Prefix:             DefaultPrefix,
+		MaxPacketSize:      DefaultMaxPacketSize,
+		MaxHistogramValues: DefaultMaxHistogramValues,
+	}
+}
+
+// tagReplacer replaces the characters that delimit DogStatsD packets, fields
+// and tags in a tag, as the Datadog client does. Colons are kept in values,
+// since a tag is split at its first colon.
+var tagReplacer = strings.NewReplacer("|", "_", ",", "_", "\n", "_", "\r", "_")
+
+// tagKeyReplacer is tagReplacer for tag keys, which must not contain colons.
+var tagKeyReplacer = strings.NewReplacer("|", "_", ",", "_", "\n", "_", "\r", "_", ":", "_")
+
+// series identifies a metric by name and tags.
+type series struct {
+	name string
+	tags string
+}
+
+// histogram is a reservoir sample of the values recorded in a histogram series.
+type histogram struct {
+	values []float64
+	count  int // values recorded, including those not kept
+}
+
+// StatsdMetrics is a metrics.Metrics implementation that ships metrics to a
+// DogStatsD agent.
+type StatsdMetrics struct {
+	config     *Config
+	tags       []string // sanitized config.Tags
+	conn       net.Conn
+	counters   map[series]uint64
+	gauges     map[series]float64
+	histograms map[series]*histogram
+	logger     *slog.Logger
+	sample     func() float64
+	mu         sync.Mutex
+}
+
+// Ensure StatsdMetrics implements metrics.LabeledMetrics.
+var _ metrics.LabeledMetrics = (*StatsdMetrics)(nil)
+
+// NewStatsdMetrics creates a new StatsdMetrics.
+func NewStatsdMetrics(config *Config) *StatsdMetrics {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	if config.Addr == "" {
+		config.Addr = DefaultAddr
+	}
+	if config.MaxPacketSize <= 0 {
+		config.MaxPacketSize = DefaultMaxPacketSize
+	}
+	if config.MaxHistogramValues <= 0 {
+		config.MaxHistogramValues = DefaultMaxHistogramValues
+	}
+
+	tags := make([]string, len(config.Tags))
+	for i, tag := range config.Tags {
+		tags[i] = tagReplacer.Replace(tag)
+	}
+
+	return &StatsdMetrics{
+		config:     config,
+		tags:       tags,
+		counters:   make(map[series]uint64),
+		gauges:     make(map[series]float64),
+		histograms: make(map[series]*histogram),
+		logger:     slog.Default(),
+		sample:     rand.Float64,
+	}
+}
+
+// WithLogger sets a custom logger.
+func (s *StatsdMetrics) WithLogger(logger *slog.Logger) *StatsdMetrics {
+	s.logger = logger
+	return s
+}
+
+// Initialize opens the UDP connection to the agent.
+func (s *StatsdMetrics) Initialize(ctx context.Context) error {
+	conn, err := net.Dial("udp", s.config.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to statsd at %s: %w", s.config.Addr, err)
+	}
+
+	s.mu.Lock()
+	s.conn = conn
+	s.mu.Unlock()
+
+	s.logger.Info("statsd metrics initialized", "addr", s.config.Addr)
+	return nil
+}
+
+// Flush sends the metrics buffered since the last flush.
+func (s *StatsdMetrics) Flush(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	lines := s.drain()
+	return s.send(lines)
+}
+
+// Shutdown flushes the remaining metrics and closes the connection.
+func (s *StatsdMetrics) Shutdown(ctx context.Context) error {
+	if err := s.Flush(ctx); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+// UpdateGauge sets a gauge metric to the specified value.
+func (s *StatsdMetrics) UpdateGauge(ctx context.Context, name string, value float64) error {
+	return s.UpdateGaugeWith(ctx, name, nil, value)
+}
+
+// IncrementCounter increments a counter metric by the specified value.
+func (s *StatsdMetrics) IncrementCounter(ctx context.Context, name string, value uint64) error {
+	return s.IncrementCounterWith(ctx, name, nil, value)
+}
+
+// RecordHistogram records a value in a histogram metric.
+func (s *StatsdMetrics) RecordHistogram(ctx context.Context, name string, value float64) error {
+	return s.RecordHistogramWith(ctx, name, nil, value)
+}
+
+// UpdateGaugeWith sets a gauge metric with the given labels to the specified value.
+func (s *StatsdMetrics) UpdateGaugeWith(ctx context.Context, name string, labels metrics.Labels, value float64) error {
+	key := s.series(ctx, name, labels)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.gauges[key] = value
+	return nil
+}
+
+// IncrementCounterWith increments a counter metric with the given labels by the specified value.
+func (s *StatsdMetrics) IncrementCounterWith(ctx context.Context, name string, labels metrics.Labels, value uint64) error {
+	if !s.sampled(name) {
+		return nil
+	}
+	key := s.series(ctx, name, labels)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counters[key] += value
+	return nil
+}
+
+// RecordHistogramWith records a value in a histogram metric with the given labels.
+func (s *StatsdMetrics) RecordHistogramWith(ctx context.Context, name string, labels metrics.Labels, value float64) error {
+	if !s.sampled(name) {
+		return nil
+	}
+	key := s.series(ctx, name, labels)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h, exists := s.histograms[key]
+	if !exists {
+		h = &histogram{}
+		s.histograms[key] = h
+	}
+	h.count++
+	if len(h.values) < s.config.MaxHistogramValues {
+		h.values = append(h.values, value)
+	} else if i := int(s.sample() * float64(h.count)); i < len(h.values) {
+		h.values[i] = value
+	}
+	return nil
+}
+
+// sampled reports whether a value of the named metric should be recorded.
+func (s *StatsdMetrics) sampled(name string) bool {
+	rate, ok := s.config.SampleRates[name]
+	return !ok || rate >= 1 || s.sample() < rate
+}
+
+// series returns the series of a metric with the context labels merged with
+// labels, sanitized so they cannot corrupt the packet.
+func (s *StatsdMetrics) series(ctx context.Context, name string, labels metrics.Labels) series {
+	merged := metrics.LabelsFromContext(metrics.WithLabels(ctx, labels))
+
+	tags := make([]string, 0, len(s.tags)+len(merged))
+	tags = append(tags, s.tags...)
+	for _, k := range merged.Keys() {
+		tags = append(tags, tagKeyReplacer.Replace(k)+":"+tagReplacer.Replace(merged[k]))
+	}
+	sort.Strings(tags)
+
+	return series{name: name, tags: strings.Join(tags, ",")}
+}
+
+// drain formats the buffered metrics as DogStatsD lines and resets the buffers.
+// Gauges are only sent when they were updated since the last flush.
+func (s *StatsdMetrics) drain() []string {
+	lines := make([]string, 0, len(s.counters)+len(s.gauges)+len(s.histograms))
+
+	for key, value := range s.counters {
+		lines = append(lines, s.format(key, strconv.FormatUint(value, 10), "c", s.rate(key.name)))
+	}
+	for key, value := range s.gauges {
+		lines = append(lines, s.format(key, strconv.FormatFloat(value, 'f', -1, 64), "g", 1))
+	}
+	for key, h := range s.histograms {
+		rate := s.rate(key.name) * float64(len(h.values)) / float64(h.count)
+		lines = append(lines, s.formatValues(key, h.values, "h", rate)...)
+	}
+	sort.Strings(lines)
+
+	clear(s.counters)
+	clear(s.gauges)
+	clear(s.histograms)
+	return lines
+}
+
+// rate returns the sample rate of the named metric, or 1 if it has none.
+func (s *StatsdMetrics) rate(name string) float64 {
+	if rate, ok := s.config.SampleRates[name]; ok && rate < 1 {
+		return rate
+	}
+	return 1
+}
+
+// format formats a DogStatsD line: name:value|type|@rate|#tags.
+func (s *StatsdMetrics) format(key series, value, typ string, rate float64) string {
+	return s.prefix(key) + value + s.suffix(key, typ, rate)
+}
+
+// formatValues formats values as multi-value DogStatsD lines,
+// name:v1:v2:...|type|@rate|#tags, each fitting in a packet.
+func (s *StatsdMetrics) formatValues(key series, values []float64, typ string, rate float64) []string {
+	prefix, suffix := s.prefix(key), s.suffix(key, typ, rate)
+
+	var lines []string
+	var b strings.Builder
+	for _, value := range values {
+		formatted := strconv.FormatFloat(value, 'f', -1, 64)
+		if b.Len() > 0 && b.Len()+1+len(formatted)+len(suffix) > s.config.MaxPacketSize {
+			b.WriteString(suffix)
+			lines = append(lines, b.String())
+			b.Reset()
+		}
+		if b.Len() == 0 {
+			b.WriteString(prefix)
+		} else {
+			b.WriteByte(':')
+		}
+		b.WriteString(formatted)
+	}
+	if b.Len() > 0 {
+		b.WriteString(suffix)
+		lines = append(lines, b.String())
+	}
+	return lines
+}
+
+// prefix returns the start of the lines of key, up to the first value.
+func (s *StatsdMetrics) prefix(key series) string {
+	return s.config.Prefix + key.name + ":"
+}
+
+// suffix returns the end of the lines of key, after the values.
+func (s *StatsdMetrics) suffix(key series, typ string, rate float64) string {
+	var b strings.Builder
+	b.WriteByte('|')
+	b.WriteString(typ)
+
+	if rate < 1 {
+		b.WriteString("|@")
+		b.WriteString(strconv.FormatFloat(rate, 'f', -1, 64))
+	}
+	if key.tags != "" {
+		b.WriteString("|#")
+		b.WriteString(key.tags)
+	}
+	return b.String()
+}
+
+// send writes lines to the agent, packing as many lines as fit in each packet.
+func (s *StatsdMetrics) send(lines []string) error {
+	var packet []byte
+	for _, line := range lines {
+		if len(packet) > 0 && len(packet)+1+len(line) > s.config.MaxPacketSize {
+			if _, err := s.conn.Write(packet); err != nil {
+				return fmt.Errorf("failed to send statsd packet: %w", err)
+			}
+			packet = packet[:0]
+		}
+		if len(packet) > 0 {
+			packet = append(packet, '\n')
+		}
+		packet = append(packet, line...)
+	}
+
+	if len(packet) > 0 {
+		if _, err := s.conn.Write(packet); err != nil {
+			return fmt.Errorf("failed to send statsd packet: %w", err)
+		}
+	}
+	return nil
+}
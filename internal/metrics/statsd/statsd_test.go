@@ -0,0 +1,133 @@
+package statsd
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lugondev/go-carbon/internal/metrics"
+)
+
+func TestStatsdMetricsFlush(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	s := NewStatsdMetrics(&Config{
+		Addr:        listener.LocalAddr().String(),
+		Prefix:      "carbon.",
+		Tags:        []string{"env:test"},
+		SampleRates: map[string]float64{"latency": 0.5},
+	})
+	s.sample = func() float64 { return 0.25 }
+
+	ctx := context.Background()
+	if err := s.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	defer s.Shutdown(ctx)
+
+	labeled := metrics.WithLabels(ctx, metrics.Labels{metrics.LabelPipe: "swaps"})
+	_ = s.IncrementCounter(labeled, "processed", 2)
+	_ = s.IncrementCounter(labeled, "processed", 3)
+	_ = s.UpdateGauge(ctx, "queue", 1)
+	_ = s.UpdateGauge(ctx, "queue", 7)
+	_ = s.RecordHistogramWith(ctx, "latency", metrics.Labels{metrics.LabelPipe: "swaps"}, 1.5)
+
+	if err := s.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	buf := make([]byte, DefaultMaxPacketSize)
+	_ = listener.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	got := strings.Split(string(buf[:n]), "\n")
+	want := []string{
+		"carbon.latency:1.5|h|@0.5|#env:test,pipe:swaps",
+		"carbon.processed:5|c|#env:test,pipe:swaps",
+		"carbon.queue:7|g|#env:test",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("packet =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestStatsdMetricsPacketSize(t *testing.T) {
+	s := NewStatsdMetrics(&Config{MaxPacketSize: 40})
+	lines := []string{strings.Repeat("a", 20), strings.Repeat("b", 20), strings.Repeat("c", 10)}
+
+	conn := &recordingConn{}
+	s.conn = conn
+	if err := s.send(lines); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	if len(conn.packets) != 2 {
+		t.Fatalf("sent %d packets, want 2", len(conn.packets))
+	}
+}
+
+// recordingConn is a net.Conn that records written packets.
+type recordingConn struct {
+	net.Conn
+	packets []string
+}
+
+func (c *recordingConn) Write(b []byte) (int, error) {
+	c.packets = append(c.packets, string(b))
+	return len(b), nil
+}
+
+func TestStatsdMetricsSanitizesTags(t *testing.T) {
+	s := NewStatsdMetrics(&Config{Tags: []string{"env:a|b"}})
+
+	key := s.series(context.Background(), "processed", metrics.Labels{
+		metrics.LabelPipe:         "swaps|#x,y\nz",
+		metrics.LabelDatasourceID: "rpc:mainnet",
+		"bad:key":                 "v",
+	})
+	if want := "bad_key:v,datasource_id:rpc:mainnet,env:a_b,pipe:swaps_#x_y_z"; key.tags != want {
+		t.Errorf("tags = %q, want %q", key.tags, want)
+	}
+}
+
+func TestStatsdMetricsPacksHistograms(t *testing.T) {
+	s := NewStatsdMetrics(&Config{MaxPacketSize: 40, MaxHistogramValues: 8})
+	s.sample = func() float64 { return 0 }
+
+	ctx := context.Background()
+	for i := 0; i < 16; i++ {
+		_ = s.RecordHistogram(ctx, "latency", float64(i))
+	}
+
+	// The first 8 values are kept, then each one replaces the first value.
+	got := s.drain()
+	want := []string{
+		"latency:15:1:2:3:4:5:6:7|h|@0.5",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("lines =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	s = NewStatsdMetrics(&Config{MaxPacketSize: 20})
+	for i := 100; i < 106; i++ {
+		_ = s.RecordHistogram(ctx, "latency", float64(i))
+	}
+	values := 0
+	for _, line := range s.drain() {
+		if len(line) > 20 {
+			t.Errorf("line %q exceeds the packet size", line)
+		}
+		values += strings.Count(line, ":")
+	}
+	if values != 6 {
+		t.Errorf("sent %d values, want 6", values)
+	}
+}
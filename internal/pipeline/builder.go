@@ -7,9 +7,12 @@ import (
 
 	"github.com/lugondev/go-carbon/internal/account"
 	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/internal/filter"
 	"github.com/lugondev/go-carbon/internal/instruction"
 	"github.com/lugondev/go-carbon/internal/metrics"
 	"github.com/lugondev/go-carbon/internal/transaction"
+	"github.com/lugondev/go-carbon/pkg/types"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // PipelineBuilder provides a fluent API for constructing a Pipeline.
@@ -90,6 +93,51 @@ func (b *PipelineBuilder) ChannelBufferSize(size int) *PipelineBuilder {
 	return b
 }
 
+// FailedTransactionPolicy sets how the pipeline routes failed transactions.
+func (b *PipelineBuilder) FailedTransactionPolicy(policy transaction.FailedTransactionPolicy) *PipelineBuilder {
+	b.pipeline.FailedTransactionPolicy = policy
+	return b
+}
+
+// Filters adds filters applied to every update before the filters of each
+// pipe, such as those compiled from configuration:
+//
+//	filters, err := cfg.CompileFilters()
+//	if err != nil {
+//	    return err
+//	}
+//	builder.Filters(filters["jupiter-swaps"])
+func (b *PipelineBuilder) Filters(filters ...filter.Filter) *PipelineBuilder {
+	b.pipeline.Filters = append(b.pipeline.Filters, filters...)
+	return b
+}
+
+// ProgramErrors sets the registry used to name custom program errors of failed transactions.
+func (b *PipelineBuilder) ProgramErrors(registry *types.ProgramErrorRegistry) *PipelineBuilder {
+	b.pipeline.ProgramErrors = registry
+	return b
+}
+
+// TruncatedLogs sets how the pipeline handles transactions whose logs were
+// truncated by the runtime.
+func (b *PipelineBuilder) TruncatedLogs(policy TruncatedLogsPolicy) *PipelineBuilder {
+	b.pipeline.TruncatedLogs = policy
+	return b
+}
+
+// SlotReference sets the source of the chain tip slot the pipeline computes
+// its slot lag against, such as an rpc.SlotMonitorDatasource or rpc.SlotProbe.
+func (b *PipelineBuilder) SlotReference(reference SlotReference) *PipelineBuilder {
+	b.pipeline.Slots = NewSlotTracker(reference)
+	return b
+}
+
+// TracerProvider sets the OpenTelemetry tracer provider used for update spans.
+func (b *PipelineBuilder) TracerProvider(provider trace.TracerProvider) *PipelineBuilder {
+	b.pipeline.TracerProvider = provider
+	return b
+}
+
 // Logger sets a custom logger for the pipeline.
 func (b *PipelineBuilder) Logger(logger *slog.Logger) *PipelineBuilder {
 	b.pipeline.Logger = logger
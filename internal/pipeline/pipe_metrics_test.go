@@ -0,0 +1,114 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lugondev/go-carbon/internal/account"
+	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/internal/filter"
+	"github.com/lugondev/go-carbon/internal/metrics"
+	"github.com/lugondev/go-carbon/internal/processor"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+// counterRecorder records counters by name and context labels.
+type counterRecorder struct {
+	metrics.NoopMetrics
+	counts map[string]uint64
+}
+
+func (r *counterRecorder) IncrementCounter(ctx context.Context, name string, value uint64) error {
+	r.counts[name+metrics.LabelsFromContext(ctx).String()] += value
+	return nil
+}
+
+func TestPipeMetrics(t *testing.T) {
+	recorder := &counterRecorder{counts: make(map[string]uint64)}
+
+	miss := account.AccountDecoderFunc[[]byte](func(*types.Account) *account.DecodedAccount[[]byte] { return nil })
+	hit := account.AccountDecoderFunc[[]byte](func(acc *types.Account) *account.DecodedAccount[[]byte] {
+		return &account.DecodedAccount[[]byte]{Data: acc.Data}
+	})
+	noop := processor.NewNoopProcessor[account.AccountProcessorInput[[]byte]]()
+	failing := processor.ProcessorFunc[account.AccountProcessorInput[[]byte]](
+		func(context.Context, account.AccountProcessorInput[[]byte], *metrics.Collection) error {
+			return errors.New("boom")
+		})
+	reject := filter.AccountFilterFunc(func(datasource.DatasourceID, *filter.AccountMetadata, *types.Account) bool {
+		return false
+	})
+
+	p := Builder().
+		AccountPipe(account.NewAccountPipe(miss, noop)).
+		AccountPipe(account.NewAccountPipe(hit, noop)).
+		AccountPipe(account.NewAccountPipeWithFilters(hit, noop, []filter.Filter{reject})).
+		AccountPipe(account.NewAccountPipe(hit, failing)).
+		Metrics(metrics.NewCollection(recorder)).
+		Build()
+
+	_ = p.process(context.Background(), datasource.UpdateWithSource{
+		DatasourceID: datasource.NewNamedDatasourceID("rpc"),
+		Update:       datasource.NewAccountUpdate(&datasource.AccountUpdate{}),
+	})
+
+	series := func(name, pipe string) string {
+		return name + metrics.Labels{
+			metrics.LabelDatasourceID: "rpc",
+			metrics.LabelUpdateType:   datasource.UpdateTypeAccount.String(),
+			metrics.LabelPipe:         pipe,
+		}.String()
+	}
+
+	for _, want := range []string{
+		series(metrics.MetricPipeDecodeMiss, "account_0"),
+		series(metrics.MetricPipeDecoded, "account_1"),
+		series(metrics.MetricPipeProcessed, "account_1"),
+		series(metrics.MetricPipeSkippedByFilter, "account_2"),
+		series(metrics.MetricPipeFailed, "account_3"),
+	} {
+		if recorder.counts[want] != 1 {
+			t.Errorf("counts[%s] = %d, want 1", want, recorder.counts[want])
+		}
+	}
+}
+
+func TestPipelineFilters(t *testing.T) {
+	recorder := &counterRecorder{counts: make(map[string]uint64)}
+
+	var processed int
+	hit := account.AccountDecoderFunc[[]byte](func(acc *types.Account) *account.DecodedAccount[[]byte] {
+		return &account.DecodedAccount[[]byte]{Data: acc.Data}
+	})
+	count := processor.ProcessorFunc[account.AccountProcessorInput[[]byte]](
+		func(context.Context, account.AccountProcessorInput[[]byte], *metrics.Collection) error {
+			processed++
+			return nil
+		})
+	rich := filter.NewLamportsRangeFilter(1_000, ^uint64(0))
+
+	p := Builder().
+		AccountPipe(account.NewAccountPipe(hit, count)).
+		Filters(rich).
+		Metrics(metrics.NewCollection(recorder)).
+		Build()
+
+	for _, lamports := range []uint64{10, 5_000} {
+		_ = p.process(context.Background(), datasource.UpdateWithSource{
+			DatasourceID: datasource.NewNamedDatasourceID("rpc"),
+			Update:       datasource.NewAccountUpdate(&datasource.AccountUpdate{Account: types.Account{Lamports: lamports}}),
+		})
+	}
+
+	if processed != 1 {
+		t.Errorf("processed %d updates, want 1", processed)
+	}
+	skipped := metrics.MetricUpdatesSkippedByFilter + metrics.Labels{
+		metrics.LabelDatasourceID: "rpc",
+		metrics.LabelUpdateType:   datasource.UpdateTypeAccount.String(),
+	}.String()
+	if recorder.counts[skipped] != 1 {
+		t.Errorf("counts[%s] = %d, want 1", skipped, recorder.counts[skipped])
+	}
+}
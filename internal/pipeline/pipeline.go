@@ -24,9 +24,11 @@ package pipeline
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"os"
 	"os/signal"
+	"strconv"
 	"sync"
 	"syscall"
 	"time"
@@ -37,7 +39,12 @@ import (
 	"github.com/lugondev/go-carbon/internal/filter"
 	"github.com/lugondev/go-carbon/internal/instruction"
 	"github.com/lugondev/go-carbon/internal/metrics"
+	"github.com/lugondev/go-carbon/internal/tracing"
 	"github.com/lugondev/go-carbon/internal/transaction"
+	"github.com/lugondev/go-carbon/pkg/types"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ShutdownStrategy defines the shutdown behavior for the pipeline.
@@ -94,12 +101,50 @@ type Pipeline struct {
 	// ChannelBufferSize is the size of the channel buffer for updates.
 	ChannelBufferSize int
 
+	// FailedTransactionPolicy determines whether failed transactions are routed
+	// to instruction and transaction pipes. Pipes may narrow this further.
+	FailedTransactionPolicy transaction.FailedTransactionPolicy
+
+	// ProgramErrors maps custom program error codes of failed transactions to
+	// their IDL names. Set to nil to disable error resolution.
+	ProgramErrors *types.ProgramErrorRegistry
+
+	// TruncatedLogs determines how transactions whose logs were truncated by
+	// the runtime are handled.
+	TruncatedLogs TruncatedLogsPolicy
+
+	// Filters apply to every update before the filters of each pipe, e.g.
+	// those compiled from configuration with config.Config.CompileFilters.
+	// Updates they reject reach no pipe.
+	Filters []filter.Filter
+
+	// Slots tracks the slots seen and processed and how far behind the chain
+	// tip the pipeline is.
+	Slots *SlotTracker
+
+	// TracerProvider provides the tracer for update spans. The global
+	// OpenTelemetry tracer provider is used if nil.
+	TracerProvider trace.TracerProvider
+
 	// Logger is used for logging.
 	Logger *slog.Logger
 
+	// routingIndex is built on Run and skips transactions no pipe is interested in.
+	routingIndex *programRoutingIndex
+
 	// cancelFunc is used to cancel the pipeline context.
 	cancelFunc context.CancelFunc
 
+	// updates is the update channel of the running pipeline.
+	updates chan datasource.UpdateWithSource
+
+	// running and startedAt describe the current run.
+	running   bool
+	startedAt time.Time
+
+	// datasourceStates tracks the lifecycle state of each datasource by ID.
+	datasourceStates map[string]DatasourceStatus
+
 	// mu protects pipeline state during modifications.
 	mu sync.RWMutex
 }
@@ -130,6 +175,12 @@ type BlockDetailsPipeRunner interface {
 	GetFilters() []filter.Filter
 }
 
+// NamedPipe is implemented by pipes that provide their own name for the pipe
+// metric label. Unnamed pipes are labeled by kind and position, e.g. "instruction_0".
+type NamedPipe interface {
+	Name() string
+}
+
 // NewPipeline creates a new Pipeline with default settings.
 func NewPipeline() *Pipeline {
 	return &Pipeline{
@@ -143,6 +194,9 @@ func NewPipeline() *Pipeline {
 		MetricsFlushInterval: DefaultMetricsFlushInterval,
 		ShutdownStrategy:     ShutdownStrategyProcessPending,
 		ChannelBufferSize:    DefaultChannelBufferSize,
+		ProgramErrors:        types.DefaultProgramErrors,
+		Slots:                NewSlotTracker(nil),
+		datasourceStates:     make(map[string]DatasourceStatus),
 		Logger:               slog.Default(),
 	}
 }
@@ -173,6 +227,12 @@ func (p *Pipeline) Run(ctx context.Context) error {
 		return cerrors.Wrap(err, "failed to initialize metrics")
 	}
 
+	// Index the programs the pipes are interested in
+	p.routingIndex = newProgramRoutingIndex(p.InstructionPipes, p.TransactionPipes)
+	if !p.routingIndex.all {
+		p.Logger.Debug("program routing index enabled", "num_programs", len(p.routingIndex.programs))
+	}
+
 	// Create a cancellable context
 	ctx, cancel := context.WithCancel(ctx)
 	p.cancelFunc = cancel
@@ -181,18 +241,35 @@ func (p *Pipeline) Run(ctx context.Context) error {
 	// Create the update channel
 	updateChan := make(chan datasource.UpdateWithSource, p.ChannelBufferSize)
 
+	p.mu.Lock()
+	p.updates = updateChan
+	p.running = true
+	p.startedAt = time.Now()
+	p.mu.Unlock()
+	defer func() {
+		p.mu.Lock()
+		p.running = false
+		p.mu.Unlock()
+	}()
+
 	// Start datasources
 	var wg sync.WaitGroup
 	for _, ds := range p.Datasources {
 		wg.Add(1)
 		go func(dsWithID DatasourceWithID) {
 			defer wg.Done()
+			p.setDatasourceState(dsWithID.ID, DatasourceStateRunning, nil)
 			if err := dsWithID.Datasource.Consume(ctx, dsWithID.ID, updateChan, p.Metrics); err != nil {
 				p.Logger.Error("error consuming datasource",
 					"datasource_id", dsWithID.ID.String(),
 					"error", err,
 				)
+				if !errors.Is(err, context.Canceled) {
+					p.setDatasourceState(dsWithID.ID, DatasourceStateFailed, err)
+					return
+				}
 			}
+			p.setDatasourceState(dsWithID.ID, DatasourceStateStopped, nil)
 		}(ds)
 	}
 
@@ -202,6 +279,9 @@ func (p *Pipeline) Run(ctx context.Context) error {
 		close(updateChan)
 	}()
 
+	// Poll the reference slot for the slot lag
+	go p.refreshReferenceSlot(ctx)
+
 	// Set up metrics flush ticker
 	flushTicker := time.NewTicker(p.MetricsFlushInterval)
 	defer flushTicker.Stop()
@@ -230,6 +310,7 @@ func (p *Pipeline) Run(ctx context.Context) error {
 			// Continue processing until channel is closed
 
 		case <-flushTicker.C:
+			p.Slots.Report(ctx, p.Metrics)
 			if err := p.Metrics.Flush(ctx); err != nil {
 				p.Logger.Error("failed to flush metrics", "error", err)
 			}
@@ -242,7 +323,9 @@ func (p *Pipeline) Run(ctx context.Context) error {
 			}
 
 			// Record metrics
-			if err := p.Metrics.IncrementCounter(ctx, metrics.MetricUpdatesReceived, 1); err != nil {
+			labels := updateLabels(update)
+			p.Slots.Observe(update)
+			if err := p.Metrics.IncrementCounterWith(ctx, metrics.MetricUpdatesReceived, labels, 1); err != nil {
 				p.Logger.Error("failed to increment counter", "error", err)
 			}
 
@@ -250,27 +333,50 @@ func (p *Pipeline) Run(ctx context.Context) error {
 			start := time.Now()
 			err := p.process(ctx, update)
 			elapsed := time.Since(start)
+			p.Slots.MarkProcessed(update)
 
 			// Record processing time
-			_ = p.Metrics.RecordHistogram(ctx, metrics.MetricUpdatesProcessTimeNanoseconds, float64(elapsed.Nanoseconds()))
-			_ = p.Metrics.RecordHistogram(ctx, metrics.MetricUpdatesProcessTimeMilliseconds, float64(elapsed.Milliseconds()))
+			_ = p.Metrics.RecordHistogramWith(ctx, metrics.MetricUpdatesProcessTimeNanoseconds, labels, float64(elapsed.Nanoseconds()))
+			_ = p.Metrics.RecordHistogramWith(ctx, metrics.MetricUpdatesProcessTimeMilliseconds, labels, float64(elapsed.Milliseconds()))
 
 			if err != nil {
 				p.Logger.Error("error processing update",
 					"type", update.Update.Type.String(),
 					"error", err,
 				)
-				_ = p.Metrics.IncrementCounter(ctx, metrics.MetricUpdatesFailed, 1)
+				_ = p.Metrics.IncrementCounterWith(ctx, metrics.MetricUpdatesFailed, labels, 1)
 			} else {
-				_ = p.Metrics.IncrementCounter(ctx, metrics.MetricUpdatesSuccessful, 1)
+				_ = p.Metrics.IncrementCounterWith(ctx, metrics.MetricUpdatesSuccessful, labels, 1)
 			}
 
-			_ = p.Metrics.IncrementCounter(ctx, metrics.MetricUpdatesProcessed, 1)
+			_ = p.Metrics.IncrementCounterWith(ctx, metrics.MetricUpdatesProcessed, labels, 1)
 			_ = p.Metrics.UpdateGauge(ctx, metrics.MetricUpdatesQueued, float64(len(updateChan)))
 		}
 	}
 }
 
+// refreshReferenceSlot polls the reference slot until ctx is done.
+func (p *Pipeline) refreshReferenceSlot(ctx context.Context) {
+	if p.Slots.reference == nil {
+		return
+	}
+
+	ticker := time.NewTicker(p.MetricsFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.Slots.RefreshReference(ctx); err != nil && ctx.Err() == nil {
+			p.Logger.Warn("failed to refresh reference slot", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
 // Stop gracefully stops the pipeline.
 func (p *Pipeline) Stop() {
 	if p.cancelFunc != nil {
@@ -283,6 +389,7 @@ func (p *Pipeline) shutdown(ctx context.Context) error {
 	p.Logger.Info("pipeline shutdown starting")
 
 	// Flush final metrics
+	p.Slots.Report(ctx, p.Metrics)
 	if err := p.Metrics.Flush(ctx); err != nil {
 		p.Logger.Error("failed to flush metrics during shutdown", "error", err)
 	}
@@ -297,12 +404,20 @@ func (p *Pipeline) shutdown(ctx context.Context) error {
 }
 
 // process handles a single update, routing it to the appropriate pipes.
-func (p *Pipeline) process(ctx context.Context, update datasource.UpdateWithSource) error {
+func (p *Pipeline) process(ctx context.Context, update datasource.UpdateWithSource) (err error) {
 	p.Logger.Debug("processing update",
 		"type", update.Update.Type.String(),
 		"datasource_id", update.DatasourceID.String(),
 	)
 
+	ctx = metrics.WithLabels(ctx, updateLabels(update))
+
+	ctx, span := p.tracer().Start(ctx, tracing.SpanProcessUpdate)
+	if span.IsRecording() {
+		span.SetAttributes(updateAttributes(update)...)
+	}
+	defer func() { tracing.End(span, err) }()
+
 	switch update.Update.Type {
 	case datasource.UpdateTypeAccount:
 		return p.processAccountUpdate(ctx, update.DatasourceID, update.Update.Account)
@@ -333,19 +448,27 @@ func (p *Pipeline) processAccountUpdate(
 	}
 
 	metadata := account.NewAccountMetadata(update)
+	accountMetadata := &filter.AccountMetadata{
+		Slot:                 metadata.Slot,
+		Pubkey:               metadata.Pubkey,
+		TransactionSignature: metadata.TransactionSignature,
+	}
 
-	for _, pipe := range p.AccountPipes {
-		accountMetadata := &filter.AccountMetadata{
-			Slot:                 metadata.Slot,
-			Pubkey:               metadata.Pubkey,
-			TransactionSignature: metadata.TransactionSignature,
-		}
+	if !filter.CheckAccountFilters(datasourceID, p.Filters, accountMetadata, &update.Account) {
+		_ = p.Metrics.IncrementCounter(ctx, metrics.MetricUpdatesSkippedByFilter, 1)
+		return nil
+	}
 
+	for i, pipe := range p.AccountPipes {
 		if !filter.CheckAccountFilters(datasourceID, pipe.GetFilters(), accountMetadata, &update.Account) {
+			p.skipPipe(ctx, "account", i, pipe)
 			continue
 		}
 
-		if err := pipe.RunAccount(ctx, metadata, &update.Account, p.Metrics); err != nil {
+		err := p.runPipe(ctx, "account", i, pipe, func(pipeCtx context.Context) error {
+			return pipe.RunAccount(pipeCtx, metadata, &update.Account, p.Metrics)
+		})
+		if err != nil {
 			return err
 		}
 	}
@@ -364,6 +487,23 @@ func (p *Pipeline) processTransactionUpdate(
 		return nil
 	}
 
+	// Skip transactions that invoke no program any pipe is interested in
+	if p.routingIndex != nil && !p.routingIndex.Interested(update) {
+		if update.IsVote {
+			_ = p.Metrics.IncrementCounter(ctx, metrics.MetricVoteTransactionsSkipped, 1)
+		}
+		_ = p.Metrics.IncrementCounter(ctx, metrics.MetricTransactionsSkippedByProgram, 1)
+		return nil
+	}
+
+	if !p.FailedTransactionPolicy.Allows(&update.Meta) {
+		_ = p.Metrics.IncrementCounter(ctx, metrics.MetricTransactionsSkippedByStatus, 1)
+		return nil
+	}
+
+	// Refetch transactions whose logs were truncated, if enabled
+	update = p.refetchTruncated(ctx, update)
+
 	// Create transaction metadata
 	txMetadata, err := transaction.NewTransactionMetadataFromUpdate(update)
 	if err != nil {
@@ -374,26 +514,50 @@ func (p *Pipeline) processTransactionUpdate(
 	instructionsWithMetadata := p.extractInstructionsWithMetadata(txMetadata, update)
 	nestedInstructions := instruction.NestInstructions(instructionsWithMetadata)
 
+	// Attribute failed transactions to the erroring program
+	if txMetadata.IsFailed() {
+		transaction.ResolveTransactionError(txMetadata, nestedInstructions, p.ProgramErrors)
+		_ = p.Metrics.IncrementCounter(ctx, metrics.MetricFailedTransactionsProcessed, 1)
+	}
+
+	p.handleTruncated(ctx, txMetadata, nestedInstructions)
+
+	if !filter.CheckTransactionFilters(datasourceID, p.Filters, txMetadata, nestedInstructions) {
+		_ = p.Metrics.IncrementCounter(ctx, metrics.MetricUpdatesSkippedByFilter, 1)
+		return nil
+	}
+
 	// Process through instruction pipes
-	for _, pipe := range p.InstructionPipes {
+	for i, pipe := range p.InstructionPipes {
 		for _, nestedIx := range nestedInstructions.Instructions {
+			if !filter.CheckInstructionFilters(datasourceID, p.Filters, nestedIx) {
+				continue
+			}
 			if !filter.CheckInstructionFilters(datasourceID, pipe.GetFilters(), nestedIx) {
+				p.skipPipe(ctx, "instruction", i, pipe)
 				continue
 			}
 
-			if err := pipe.RunInstruction(ctx, nestedIx, p.Metrics); err != nil {
+			err := p.runPipe(ctx, "instruction", i, pipe, func(pipeCtx context.Context) error {
+				return pipe.RunInstruction(pipeCtx, nestedIx, p.Metrics)
+			})
+			if err != nil {
 				return err
 			}
 		}
 	}
 
 	// Process through transaction pipes
-	for _, pipe := range p.TransactionPipes {
+	for i, pipe := range p.TransactionPipes {
 		if !filter.CheckTransactionFilters(datasourceID, pipe.GetFilters(), txMetadata, nestedInstructions) {
+			p.skipPipe(ctx, "transaction", i, pipe)
 			continue
 		}
 
-		if err := pipe.RunTransaction(ctx, txMetadata, nestedInstructions, p.Metrics); err != nil {
+		err := p.runPipe(ctx, "transaction", i, pipe, func(pipeCtx context.Context) error {
+			return pipe.RunTransaction(pipeCtx, txMetadata, nestedInstructions, p.Metrics)
+		})
+		if err != nil {
 			return err
 		}
 	}
@@ -480,12 +644,21 @@ func (p *Pipeline) processAccountDeletion(
 		return nil
 	}
 
-	for _, pipe := range p.AccountDeletionPipes {
+	if !filter.CheckAccountDeletionFilters(datasourceID, p.Filters, deletion) {
+		_ = p.Metrics.IncrementCounter(ctx, metrics.MetricUpdatesSkippedByFilter, 1)
+		return nil
+	}
+
+	for i, pipe := range p.AccountDeletionPipes {
 		if !filter.CheckAccountDeletionFilters(datasourceID, pipe.GetFilters(), deletion) {
+			p.skipPipe(ctx, "account_deletion", i, pipe)
 			continue
 		}
 
-		if err := pipe.RunAccountDeletion(ctx, deletion, p.Metrics); err != nil {
+		err := p.runPipe(ctx, "account_deletion", i, pipe, func(pipeCtx context.Context) error {
+			return pipe.RunAccountDeletion(pipeCtx, deletion, p.Metrics)
+		})
+		if err != nil {
 			return err
 		}
 	}
@@ -504,12 +677,21 @@ func (p *Pipeline) processBlockDetails(
 		return nil
 	}
 
-	for _, pipe := range p.BlockDetailsPipes {
+	if !filter.CheckBlockDetailsFilters(datasourceID, p.Filters, details) {
+		_ = p.Metrics.IncrementCounter(ctx, metrics.MetricUpdatesSkippedByFilter, 1)
+		return nil
+	}
+
+	for i, pipe := range p.BlockDetailsPipes {
 		if !filter.CheckBlockDetailsFilters(datasourceID, pipe.GetFilters(), details) {
+			p.skipPipe(ctx, "block_details", i, pipe)
 			continue
 		}
 
-		if err := pipe.RunBlockDetails(ctx, details, p.Metrics); err != nil {
+		err := p.runPipe(ctx, "block_details", i, pipe, func(pipeCtx context.Context) error {
+			return pipe.RunBlockDetails(pipeCtx, details, p.Metrics)
+		})
+		if err != nil {
 			return err
 		}
 	}
@@ -517,3 +699,73 @@ func (p *Pipeline) processBlockDetails(
 	_ = p.Metrics.IncrementCounter(ctx, metrics.MetricBlockDetailsProcessed, 1)
 	return nil
 }
+
+// updateLabels returns the metric labels identifying the source and type of an update.
+func updateLabels(update datasource.UpdateWithSource) metrics.Labels {
+	return metrics.Labels{
+		metrics.LabelDatasourceID: update.DatasourceID.String(),
+		metrics.LabelUpdateType:   update.Update.Type.String(),
+	}
+}
+
+// pipeName returns the name a pipe is labeled with in metrics and spans.
+func pipeName(kind string, index int, pipe any) string {
+	if named, ok := pipe.(NamedPipe); ok {
+		return named.Name()
+	}
+	return kind + "_" + strconv.Itoa(index)
+}
+
+// runPipe runs a pipe within its span, labeling the metrics it records with its
+// name and recording how long it ran.
+func (p *Pipeline) runPipe(ctx context.Context, kind string, index int, pipe any, run func(ctx context.Context) error) error {
+	name := pipeName(kind, index, pipe)
+	ctx = metrics.WithLabels(ctx, metrics.Labels{metrics.LabelPipe: name})
+	ctx, span := tracing.Start(ctx, tracing.SpanPipe, func() []attribute.KeyValue {
+		return []attribute.KeyValue{tracing.AttrPipe.String(name)}
+	})
+
+	start := time.Now()
+	err := run(ctx)
+	_ = p.Metrics.RecordHistogram(ctx, metrics.MetricPipeRunTimeNanoseconds, float64(time.Since(start).Nanoseconds()))
+
+	tracing.End(span, err)
+	return err
+}
+
+// skipPipe records that a pipe's filters rejected an update.
+func (p *Pipeline) skipPipe(ctx context.Context, kind string, index int, pipe any) {
+	labels := metrics.Labels{metrics.LabelPipe: pipeName(kind, index, pipe)}
+	_ = p.Metrics.IncrementCounterWith(ctx, metrics.MetricPipeSkippedByFilter, labels, 1)
+}
+
+// tracer returns the tracer used for update spans.
+func (p *Pipeline) tracer() trace.Tracer {
+	provider := p.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return provider.Tracer(tracing.TracerName)
+}
+
+// updateAttributes returns the span attributes identifying an update.
+func updateAttributes(update datasource.UpdateWithSource) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		tracing.AttrDatasourceID.String(update.DatasourceID.String()),
+		tracing.AttrUpdateType.String(update.Update.Type.String()),
+	}
+
+	if slot, ok := updateSlot(update.Update); ok {
+		attrs = append(attrs, tracing.Slot(slot))
+	}
+
+	switch u := update.Update; {
+	case u.Account != nil:
+		attrs = append(attrs, tracing.Pubkey(u.Account.Pubkey))
+	case u.Transaction != nil:
+		attrs = append(attrs, tracing.Signature(u.Transaction.Signature))
+	case u.AccountDeletion != nil:
+		attrs = append(attrs, tracing.Pubkey(u.AccountDeletion.Pubkey))
+	}
+	return attrs
+}
@@ -0,0 +1,117 @@
+package pipeline
+
+import (
+	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/internal/instruction"
+	"github.com/lugondev/go-carbon/internal/transaction"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+// programRoutingIndex is the set of programs any instruction or transaction
+// pipe is interested in. It lets the pipeline skip instruction compilation and
+// nesting for transactions, such as votes, that invoke none of them.
+type programRoutingIndex struct {
+	// all is set when at least one pipe is not scoped to known programs.
+	all bool
+
+	programs map[types.Pubkey]struct{}
+}
+
+// newProgramRoutingIndex builds the routing index from the pipeline's pipes.
+//
+// A pipe is scoped to the programs reported by its decoder. A pipe that cannot
+// be scoped disables the index. Filters are not used for scoping: a program
+// filter may require a program the decoder's programs only reach through CPI,
+// so routing on it could skip transactions the pipe would process. They run
+// on the routed transactions as usual.
+func newProgramRoutingIndex(
+	instructionPipes []instruction.InstructionPipeRunner,
+	transactionPipes []transaction.TransactionPipeRunner,
+) *programRoutingIndex {
+	index := &programRoutingIndex{
+		programs: make(map[types.Pubkey]struct{}),
+	}
+
+	add := func(pipe any) {
+		programIDs, ok := instruction.ProgramIDsOf(pipe)
+		if !ok {
+			index.all = true
+			return
+		}
+		for _, programID := range programIDs {
+			index.programs[programID] = struct{}{}
+		}
+	}
+
+	for _, pipe := range instructionPipes {
+		add(pipe)
+	}
+	for _, pipe := range transactionPipes {
+		add(pipe)
+	}
+
+	return index
+}
+
+// Interested reports whether any pipe may process the transaction, based on
+// the programs invoked by its outer and inner instructions.
+func (r *programRoutingIndex) Interested(update *datasource.TransactionUpdate) bool {
+	if r.all {
+		return true
+	}
+	if len(r.programs) == 0 || update.Transaction == nil {
+		return false
+	}
+
+	// Top-level program IDs are always static account keys, since the runtime
+	// rejects programs loaded from lookup tables. Inner instructions index the
+	// loaded addresses too, so CPIs may invoke a program from a lookup table.
+	accountKeys := update.Transaction.Message.AccountKeys
+	for _, ix := range update.Transaction.Message.Instructions {
+		if r.routes(accountKeys, int(ix.ProgramIDIndex)) {
+			return true
+		}
+	}
+
+	if len(update.Meta.InnerInstructions) == 0 {
+		return false
+	}
+	loaded := update.Meta.LoadedAddresses
+	if len(loaded.Writable) > 0 || len(loaded.Readonly) > 0 {
+		allKeys := make([]types.Pubkey, 0, len(accountKeys)+len(loaded.Writable)+len(loaded.Readonly))
+		allKeys = append(allKeys, accountKeys...)
+		allKeys = append(allKeys, loaded.Writable...)
+		accountKeys = append(allKeys, loaded.Readonly...)
+	}
+	for _, group := range update.Meta.InnerInstructions {
+		for _, ix := range group.Instructions {
+			if r.routes(accountKeys, int(ix.Instruction.ProgramIDIndex)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// routes reports whether an instruction invoking the program at index of
+// accountKeys must be routed to the pipes.
+func (r *programRoutingIndex) routes(accountKeys []types.Pubkey, index int) bool {
+	programID, ok := programAt(accountKeys, index)
+	if !ok {
+		// The program cannot be resolved, so whether a pipe is interested is
+		// unknown. Route the transaction so the malformed instruction reaches
+		// the pipes and their error handling instead of being dropped here.
+		return true
+	}
+	_, exists := r.programs[programID]
+	return exists
+}
+
+// programAt returns the account key at index, or false if index is out of range.
+func programAt(accountKeys []types.Pubkey, index int) (types.Pubkey, bool) {
+	if index < 0 || index >= len(accountKeys) {
+		return types.Pubkey{}, false
+	}
+	return accountKeys[index], true
+}
@@ -0,0 +1,101 @@
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/internal/filter"
+	"github.com/lugondev/go-carbon/internal/instruction"
+	"github.com/lugondev/go-carbon/internal/processor"
+	"github.com/lugondev/go-carbon/internal/transaction"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+func transactionInvoking(accountKeys []types.Pubkey, programIndex uint16) *datasource.TransactionUpdate {
+	return &datasource.TransactionUpdate{
+		Transaction: &solana.Transaction{
+			Message: solana.Message{
+				AccountKeys:  accountKeys,
+				Instructions: []solana.CompiledInstruction{{ProgramIDIndex: programIndex}},
+			},
+		},
+	}
+}
+
+func TestProgramRoutingIndex(t *testing.T) {
+	payer, program := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+
+	decoder := instruction.NewProgramInstructionDecoder(program, func(data []byte) ([]byte, error) {
+		return data, nil
+	})
+	pipe := instruction.NewInstructionPipe[[]byte](decoder, processor.NewNoopProcessor[instruction.InstructionProcessorInput[[]byte]]())
+
+	index := newProgramRoutingIndex([]instruction.InstructionPipeRunner{pipe}, nil)
+	if index.all {
+		t.Fatal("expected index to be scoped to the decoder program")
+	}
+
+	if !index.Interested(transactionInvoking([]types.Pubkey{payer, program}, 1)) {
+		t.Error("expected transaction invoking the program to be routed")
+	}
+
+	vote := transactionInvoking([]types.Pubkey{payer, solana.VoteProgramID}, 1)
+	vote.IsVote = true
+	if index.Interested(vote) {
+		t.Error("expected vote transaction to be skipped")
+	}
+
+	// Inner instructions may invoke a program loaded from a lookup table.
+	cpi := transactionInvoking([]types.Pubkey{payer, solana.SystemProgramID}, 1)
+	cpi.Meta.LoadedAddresses.Readonly = []types.Pubkey{program}
+	cpi.Meta.InnerInstructions = []types.InnerInstructions{{
+		Instructions: []types.InnerInstruction{{Instruction: types.CompiledInstruction{ProgramIDIndex: 2}}},
+	}}
+	if !index.Interested(cpi) {
+		t.Error("expected transaction invoking the program from a lookup table to be routed")
+	}
+	cpi.Meta.LoadedAddresses.Readonly = []types.Pubkey{solana.TokenProgramID}
+	if index.Interested(cpi) {
+		t.Error("expected transaction invoking another program from a lookup table to be skipped")
+	}
+
+	// Unresolvable program indexes reach the pipes.
+	if !index.Interested(transactionInvoking([]types.Pubkey{payer}, 5)) {
+		t.Error("expected transaction with an out of range program index to be routed")
+	}
+
+	// A transaction pipe without a schema processes every transaction.
+	txPipe := transaction.NewTransactionPipe[[]byte, any](nil, decoder,
+		processor.NewNoopProcessor[transaction.TransactionProcessorInput[[]byte, any]]())
+	index = newProgramRoutingIndex(nil, []transaction.TransactionPipeRunner{txPipe})
+	if !index.Interested(vote) {
+		t.Error("expected unscoped transaction pipe to disable the index")
+	}
+}
+
+func TestProgramRoutingIndexIgnoresProgramFilters(t *testing.T) {
+	payer := solana.NewWallet().PublicKey()
+	programA, programB := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+
+	decoder := instruction.NewProgramInstructionDecoder(programA, func(data []byte) ([]byte, error) {
+		return data, nil
+	})
+	pipe := instruction.NewInstructionPipeWithFilters[[]byte](decoder,
+		processor.NewNoopProcessor[instruction.InstructionProcessorInput[[]byte]](),
+		[]filter.Filter{filter.NewProgramFilter(programB)})
+
+	index := newProgramRoutingIndex([]instruction.InstructionPipeRunner{pipe}, nil)
+
+	// B invokes A through CPI.
+	cpi := transactionInvoking([]types.Pubkey{payer, programB, programA}, 1)
+	cpi.Meta.InnerInstructions = []types.InnerInstructions{{
+		Instructions: []types.InnerInstruction{{Instruction: types.CompiledInstruction{ProgramIDIndex: 2}}},
+	}}
+	if !index.Interested(cpi) {
+		t.Error("expected transaction invoking the decoder program under the filter program to be routed")
+	}
+	if index.Interested(transactionInvoking([]types.Pubkey{payer, programB}, 1)) {
+		t.Error("expected transaction not invoking the decoder program to be skipped")
+	}
+}
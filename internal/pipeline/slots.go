@@ -0,0 +1,210 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/internal/metrics"
+)
+
+// SlotReference reports the current slot of the chain tip, against which the
+// pipeline computes how far behind it is.
+//
+// rpc.SlotMonitorDatasource implements SlotReference with the last slot it
+// observed, and rpc.SlotProbe with a getSlot call.
+type SlotReference interface {
+	CurrentSlot(ctx context.Context) (uint64, error)
+}
+
+// SlotStatus is a snapshot of the slots seen and processed by the pipeline.
+type SlotStatus struct {
+	// HighestSeen is the highest slot received from each datasource.
+	HighestSeen map[string]uint64
+
+	// HighestProcessed is the highest slot of any account or transaction
+	// update fully processed. Block details, such as those a slot monitor
+	// emits, do not count, so they cannot hide that data processing lags.
+	HighestProcessed uint64
+
+	// ReferenceSlot is the last slot reported by the SlotReference, or zero if
+	// there is none or it has not reported yet.
+	ReferenceSlot uint64
+
+	// Lag is the number of slots between ReferenceSlot and HighestProcessed.
+	// It is zero when there is no reference slot.
+	Lag uint64
+
+	// LatestBlockTime is the most recent block time seen on an update, or the
+	// zero time if no update carried a block time.
+	LatestBlockTime time.Time
+
+	// Staleness is the wall-clock time elapsed since LatestBlockTime.
+	Staleness time.Duration
+}
+
+// CheckFreshness returns an error if the pipeline is more than maxLag slots
+// behind the reference slot or its latest block time is older than
+// maxStaleness. A zero limit disables the corresponding check.
+func (s SlotStatus) CheckFreshness(maxLag uint64, maxStaleness time.Duration) error {
+	if maxLag > 0 && s.Lag > maxLag {
+		return fmt.Errorf("slot lag %d exceeds %d", s.Lag, maxLag)
+	}
+	if maxStaleness > 0 && !s.LatestBlockTime.IsZero() && s.Staleness > maxStaleness {
+		return fmt.Errorf("latest block time is %s old, exceeds %s", s.Staleness.Truncate(time.Second), maxStaleness)
+	}
+	return nil
+}
+
+// SlotTracker tracks the highest slots seen per datasource and processed by
+// the pipeline, and the freshness of the data against a SlotReference.
+type SlotTracker struct {
+	reference        SlotReference
+	highestSeen      map[string]uint64
+	highestProcessed uint64
+	referenceSlot    uint64
+	latestBlockTime  int64
+	now              func() time.Time
+	mu               sync.RWMutex
+}
+
+// NewSlotTracker creates a new SlotTracker. reference may be nil, in which case
+// no slot lag is computed.
+func NewSlotTracker(reference SlotReference) *SlotTracker {
+	return &SlotTracker{
+		reference:   reference,
+		highestSeen: make(map[string]uint64),
+		now:         time.Now,
+	}
+}
+
+// Observe records an update received from a datasource.
+func (t *SlotTracker) Observe(update datasource.UpdateWithSource) {
+	slot, ok := updateSlot(update.Update)
+	if !ok {
+		return
+	}
+
+	id := update.DatasourceID.String()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if slot > t.highestSeen[id] {
+		t.highestSeen[id] = slot
+	}
+	if blockTime, ok := updateBlockTime(update.Update); ok && blockTime > t.latestBlockTime {
+		t.latestBlockTime = blockTime
+	}
+}
+
+// MarkProcessed records that an update has been fully processed. Only
+// account and transaction updates advance the highest processed slot.
+func (t *SlotTracker) MarkProcessed(update datasource.UpdateWithSource) {
+	if update.Update.BlockDetails != nil {
+		return
+	}
+	slot, ok := updateSlot(update.Update)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if slot > t.highestProcessed {
+		t.highestProcessed = slot
+	}
+}
+
+// RefreshReference queries the SlotReference for the current slot.
+func (t *SlotTracker) RefreshReference(ctx context.Context) error {
+	if t.reference == nil {
+		return nil
+	}
+
+	slot, err := t.reference.CurrentSlot(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get reference slot: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if slot > t.referenceSlot {
+		t.referenceSlot = slot
+	}
+	return nil
+}
+
+// Status returns a snapshot of the tracked slots.
+func (t *SlotTracker) Status() SlotStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	status := SlotStatus{
+		HighestSeen:      make(map[string]uint64, len(t.highestSeen)),
+		HighestProcessed: t.highestProcessed,
+		ReferenceSlot:    t.referenceSlot,
+	}
+	for id, slot := range t.highestSeen {
+		status.HighestSeen[id] = slot
+	}
+	if t.referenceSlot > t.highestProcessed {
+		status.Lag = t.referenceSlot - t.highestProcessed
+	}
+	if t.latestBlockTime > 0 {
+		status.LatestBlockTime = time.Unix(t.latestBlockTime, 0)
+		status.Staleness = t.now().Sub(status.LatestBlockTime)
+	}
+	return status
+}
+
+// Report updates the slot and freshness gauges.
+func (t *SlotTracker) Report(ctx context.Context, m *metrics.Collection) {
+	status := t.Status()
+
+	for id, slot := range status.HighestSeen {
+		labels := metrics.Labels{metrics.LabelDatasourceID: id}
+		_ = m.UpdateGaugeWith(ctx, metrics.MetricSlotHighestSeen, labels, float64(slot))
+	}
+	_ = m.UpdateGauge(ctx, metrics.MetricSlotHighestProcessed, float64(status.HighestProcessed))
+
+	if status.ReferenceSlot > 0 {
+		_ = m.UpdateGauge(ctx, metrics.MetricSlotReference, float64(status.ReferenceSlot))
+		_ = m.UpdateGauge(ctx, metrics.MetricSlotLag, float64(status.Lag))
+	}
+	if !status.LatestBlockTime.IsZero() {
+		_ = m.UpdateGauge(ctx, metrics.MetricBlockTimeStalenessSeconds, status.Staleness.Seconds())
+	}
+}
+
+// updateSlot returns the slot of an update.
+func updateSlot(update datasource.Update) (uint64, bool) {
+	switch {
+	case update.Account != nil:
+		return update.Account.Slot, true
+	case update.Transaction != nil:
+		return update.Transaction.Slot, true
+	case update.AccountDeletion != nil:
+		return update.AccountDeletion.Slot, true
+	case update.BlockDetails != nil:
+		return update.BlockDetails.Slot, true
+	default:
+		return 0, false
+	}
+}
+
+// updateBlockTime returns the block time of an update, if it carries one.
+func updateBlockTime(update datasource.Update) (int64, bool) {
+	switch {
+	case update.Transaction != nil && update.Transaction.BlockTime != nil:
+		return *update.Transaction.BlockTime, true
+	case update.BlockDetails != nil && update.BlockDetails.BlockTime != nil:
+		return *update.BlockDetails.BlockTime, true
+	default:
+		return 0, false
+	}
+}
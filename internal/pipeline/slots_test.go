@@ -0,0 +1,60 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lugondev/go-carbon/internal/datasource"
+)
+
+type fixedSlot uint64
+
+func (s fixedSlot) CurrentSlot(context.Context) (uint64, error) {
+	return uint64(s), nil
+}
+
+func TestSlotTracker(t *testing.T) {
+	tracker := NewSlotTracker(fixedSlot(120))
+	now := time.Unix(1_700_000_100, 0)
+	tracker.now = func() time.Time { return now }
+
+	blockTime := now.Add(-30 * time.Second).Unix()
+	block := datasource.UpdateWithSource{
+		DatasourceID: datasource.NewNamedDatasourceID("slots"),
+		Update:       datasource.NewBlockDetailsUpdate(&datasource.BlockDetails{Slot: 110, BlockTime: &blockTime}),
+	}
+	account := datasource.UpdateWithSource{
+		DatasourceID: datasource.NewNamedDatasourceID("accounts"),
+		Update:       datasource.NewAccountUpdate(&datasource.AccountUpdate{Slot: 100}),
+	}
+
+	tracker.Observe(block)
+	tracker.Observe(account)
+	tracker.MarkProcessed(block)
+	tracker.MarkProcessed(account)
+	if err := tracker.RefreshReference(context.Background()); err != nil {
+		t.Fatalf("RefreshReference: %v", err)
+	}
+
+	status := tracker.Status()
+	if status.HighestSeen["slots"] != 110 || status.HighestSeen["accounts"] != 100 {
+		t.Errorf("unexpected highest seen %v", status.HighestSeen)
+	}
+	if status.HighestProcessed != 100 || status.Lag != 20 {
+		t.Errorf("processed = %d, lag = %d, want 100 and 20", status.HighestProcessed, status.Lag)
+	}
+	if status.Staleness != 30*time.Second {
+		t.Errorf("staleness = %s, want 30s", status.Staleness)
+	}
+
+	if err := status.CheckFreshness(50, time.Minute); err != nil {
+		t.Errorf("expected fresh status, got %v", err)
+	}
+	if err := status.CheckFreshness(10, 0); err == nil {
+		t.Error("expected slot lag error")
+	}
+	if err := status.CheckFreshness(0, 10*time.Second); err == nil {
+		t.Error("expected staleness error")
+	}
+}
@@ -0,0 +1,157 @@
+package pipeline
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lugondev/go-carbon/internal/datasource"
+)
+
+// DatasourceState is the lifecycle state of a datasource in a running pipeline.
+type DatasourceState string
+
+const (
+	// DatasourceStatePending means the pipeline has not started the datasource yet.
+	DatasourceStatePending DatasourceState = "pending"
+
+	// DatasourceStateRunning means the datasource is consuming updates.
+	DatasourceStateRunning DatasourceState = "running"
+
+	// DatasourceStateStopped means the datasource returned without error.
+	DatasourceStateStopped DatasourceState = "stopped"
+
+	// DatasourceStateFailed means the datasource returned an error.
+	DatasourceStateFailed DatasourceState = "failed"
+)
+
+// DatasourceStatus describes a datasource of the pipeline.
+type DatasourceStatus struct {
+	ID    string          `json:"id"`
+	State DatasourceState `json:"state"`
+	Error string          `json:"error,omitempty"`
+}
+
+// PipeStatus describes a pipe of the pipeline.
+type PipeStatus struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// Status is a snapshot of a pipeline for health checks and admin endpoints.
+type Status struct {
+	Running          bool               `json:"running"`
+	StartedAt        time.Time          `json:"started_at,omitzero"`
+	Datasources      []DatasourceStatus `json:"datasources"`
+	Pipes            []PipeStatus       `json:"pipes"`
+	QueueDepth       int                `json:"queue_depth"`
+	QueueCapacity    int                `json:"queue_capacity"`
+	HighestSeen      map[string]uint64  `json:"highest_seen_slots"`
+	HighestProcessed uint64             `json:"last_processed_slot"`
+	ReferenceSlot    uint64             `json:"reference_slot,omitempty"`
+	SlotLag          uint64             `json:"slot_lag"`
+	LatestBlockTime  time.Time          `json:"latest_block_time,omitzero"`
+}
+
+// DatasourceHealthChecker is implemented by datasources that can report whether
+// they are connected to their upstream, e.g. with an RPC health probe.
+type DatasourceHealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// Status returns a snapshot of the pipeline.
+func (p *Pipeline) Status() Status {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	status := Status{
+		Running:   p.running,
+		StartedAt: p.startedAt,
+	}
+
+	for _, ds := range p.Datasources {
+		dsStatus := DatasourceStatus{ID: ds.ID.String(), State: DatasourceStatePending}
+		if state, ok := p.datasourceStates[ds.ID.String()]; ok {
+			dsStatus = state
+		}
+		status.Datasources = append(status.Datasources, dsStatus)
+	}
+
+	status.Pipes = p.pipeStatuses()
+
+	if p.updates != nil {
+		status.QueueDepth = len(p.updates)
+		status.QueueCapacity = cap(p.updates)
+	}
+
+	slots := p.Slots.Status()
+	status.HighestSeen = slots.HighestSeen
+	status.HighestProcessed = slots.HighestProcessed
+	status.ReferenceSlot = slots.ReferenceSlot
+	status.SlotLag = slots.Lag
+	status.LatestBlockTime = slots.LatestBlockTime
+
+	return status
+}
+
+// CheckDatasources returns an error unless every datasource is running and,
+// for those implementing DatasourceHealthChecker, reports itself healthy.
+func (p *Pipeline) CheckDatasources(ctx context.Context) error {
+	for _, ds := range p.Status().Datasources {
+		if ds.State != DatasourceStateRunning {
+			return fmt.Errorf("datasource %s is %s", ds.ID, ds.State)
+		}
+	}
+
+	for _, ds := range p.Datasources {
+		checker, ok := ds.Datasource.(DatasourceHealthChecker)
+		if !ok {
+			continue
+		}
+		if err := checker.CheckHealth(ctx); err != nil {
+			return fmt.Errorf("datasource %s is unhealthy: %w", ds.ID, err)
+		}
+	}
+	return nil
+}
+
+// setDatasourceState records the lifecycle state of a datasource.
+func (p *Pipeline) setDatasourceState(id datasource.DatasourceID, state DatasourceState, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.datasourceStates == nil {
+		p.datasourceStates = make(map[string]DatasourceStatus)
+	}
+
+	status := DatasourceStatus{ID: id.String(), State: state}
+	if err != nil {
+		status.Error = err.Error()
+	}
+	p.datasourceStates[id.String()] = status
+}
+
+// pipeStatuses lists the pipes of the pipeline by kind and name.
+func (p *Pipeline) pipeStatuses() []PipeStatus {
+	var pipes []PipeStatus
+	add := func(kind string, index int, pipe any) {
+		pipes = append(pipes, PipeStatus{Kind: kind, Name: pipeName(kind, index, pipe)})
+	}
+
+	for i, pipe := range p.AccountPipes {
+		add("account", i, pipe)
+	}
+	for i, pipe := range p.AccountDeletionPipes {
+		add("account_deletion", i, pipe)
+	}
+	for i, pipe := range p.BlockDetailsPipes {
+		add("block_details", i, pipe)
+	}
+	for i, pipe := range p.InstructionPipes {
+		add("instruction", i, pipe)
+	}
+	for i, pipe := range p.TransactionPipes {
+		add("transaction", i, pipe)
+	}
+	return pipes
+}
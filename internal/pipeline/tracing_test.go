@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/internal/account"
+	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/internal/metrics"
+	"github.com/lugondev/go-carbon/internal/processor"
+	"github.com/lugondev/go-carbon/internal/tracing"
+	"github.com/lugondev/go-carbon/pkg/types"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestProcessCreatesSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	decoder := account.AccountDecoderFunc[[]byte](func(acc *types.Account) *account.DecodedAccount[[]byte] {
+		return &account.DecodedAccount[[]byte]{Data: acc.Data}
+	})
+	proc := processor.ProcessorFunc[account.AccountProcessorInput[[]byte]](
+		func(ctx context.Context, input account.AccountProcessorInput[[]byte], m *metrics.Collection) error {
+			_, span := tracing.Start(ctx, "user.store", nil)
+			span.End()
+			return nil
+		})
+
+	p := Builder().
+		AccountPipe(account.NewAccountPipe(decoder, proc)).
+		TracerProvider(provider).
+		Build()
+
+	pubkey := solana.NewWallet().PublicKey()
+	err := p.process(context.Background(), datasource.UpdateWithSource{
+		DatasourceID: datasource.NewNamedDatasourceID("rpc"),
+		Update: datasource.NewAccountUpdate(&datasource.AccountUpdate{
+			Pubkey: pubkey,
+			Slot:   42,
+		}),
+	})
+	if err != nil {
+		t.Fatalf("process: %v", err)
+	}
+
+	spans := exporter.GetSpans()
+	byName := make(map[string]tracetest.SpanStub, len(spans))
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+
+	root, ok := byName[tracing.SpanProcessUpdate]
+	if !ok {
+		t.Fatalf("missing %s span in %d spans", tracing.SpanProcessUpdate, len(spans))
+	}
+	attrs := make(map[string]string)
+	for _, attr := range root.Attributes {
+		attrs[string(attr.Key)] = attr.Value.Emit()
+	}
+	if attrs[string(tracing.AttrSlot)] != "42" || attrs[string(tracing.AttrPubkey)] != pubkey.String() {
+		t.Errorf("unexpected root attributes %v", attrs)
+	}
+
+	// Each span is a child of the one before it.
+	parent := root
+	for _, name := range []string{tracing.SpanPipe, tracing.SpanProcess, "user.store"} {
+		span, ok := byName[name]
+		if !ok {
+			t.Fatalf("missing %s span", name)
+		}
+		if span.Parent.SpanID() != parent.SpanContext.SpanID() {
+			t.Errorf("%s span is not a child of %s", name, parent.Name)
+		}
+		parent = span
+	}
+
+	if decode := byName[tracing.SpanDecodeAccount]; decode.Parent.SpanID() != byName[tracing.SpanPipe].SpanContext.SpanID() {
+		t.Errorf("%s span is not a child of the pipe span", tracing.SpanDecodeAccount)
+	}
+}
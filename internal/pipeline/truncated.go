@@ -0,0 +1,89 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/internal/instruction"
+	"github.com/lugondev/go-carbon/internal/metrics"
+	"github.com/lugondev/go-carbon/internal/transaction"
+	"github.com/lugondev/go-carbon/pkg/log"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+// TransactionRefetcher fetches a transaction by signature.
+//
+// rpc.TransactionFetcherDatasource implements TransactionRefetcher, e.g. against
+// an RPC node configured with a higher log size limit.
+type TransactionRefetcher interface {
+	RefetchTransaction(ctx context.Context, signature types.Signature) (*datasource.TransactionUpdate, error)
+}
+
+// TruncatedLogsPolicy determines how the pipeline handles transactions whose
+// log messages were truncated by the runtime, dropping the events logged after
+// the truncation point.
+//
+// The zero value processes such transactions as they are. Either way, the
+// pipeline counts transactions whose events may have been lost.
+type TruncatedLogsPolicy struct {
+	// Refetcher refetches truncated transactions. The refetched transaction
+	// replaces the update unless its logs are truncated too. Disabled if nil.
+	Refetcher TransactionRefetcher
+
+	// CPIFallback extracts the emit_cpi! events of transactions whose logs
+	// remain truncated into TransactionMetadata.CPIEvents.
+	CPIFallback bool
+}
+
+// refetchTruncated returns update, or the refetched transaction if its logs are
+// truncated and the policy refetches it successfully.
+func (p *Pipeline) refetchTruncated(ctx context.Context, update *datasource.TransactionUpdate) *datasource.TransactionUpdate {
+	if !log.IsTruncated(update.Meta.LogMessages) {
+		return update
+	}
+	_ = p.Metrics.IncrementCounter(ctx, metrics.MetricTransactionLogsTruncated, 1)
+
+	refetcher := p.TruncatedLogs.Refetcher
+	if refetcher == nil {
+		return update
+	}
+
+	refetched, err := refetcher.RefetchTransaction(ctx, update.Signature)
+	if err != nil || refetched == nil {
+		p.Logger.Warn("failed to refetch transaction with truncated logs",
+			"signature", update.Signature.String(),
+			"error", err,
+		)
+		_ = p.Metrics.IncrementCounter(ctx, metrics.MetricTruncatedLogsRefetchFailed, 1)
+		return update
+	}
+	if log.IsTruncated(refetched.Meta.LogMessages) {
+		_ = p.Metrics.IncrementCounter(ctx, metrics.MetricTruncatedLogsRefetchFailed, 1)
+		return update
+	}
+
+	_ = p.Metrics.IncrementCounter(ctx, metrics.MetricTruncatedLogsRefetched, 1)
+	return refetched
+}
+
+// handleTruncated records that events of a transaction with truncated logs may
+// have been lost and, if enabled, extracts its emit_cpi! events instead.
+func (p *Pipeline) handleTruncated(
+	ctx context.Context,
+	txMetadata *transaction.TransactionMetadata,
+	nestedInstructions *instruction.NestedInstructions,
+) {
+	if !txMetadata.LogsTruncated {
+		return
+	}
+
+	if p.TruncatedLogs.CPIFallback {
+		txMetadata.CPIEvents = nestedInstructions.CPIEvents()
+	}
+
+	p.Logger.Warn("transaction logs truncated, events may be lost",
+		"signature", txMetadata.Signature.String(),
+		"cpi_events", len(txMetadata.CPIEvents),
+	)
+	_ = p.Metrics.IncrementCounter(ctx, metrics.MetricTransactionEventsPossiblyLost, 1)
+}
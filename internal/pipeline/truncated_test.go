@@ -0,0 +1,122 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/internal/datasource"
+	"github.com/lugondev/go-carbon/internal/instruction"
+	"github.com/lugondev/go-carbon/internal/metrics"
+	"github.com/lugondev/go-carbon/internal/processor"
+	"github.com/lugondev/go-carbon/internal/transaction"
+	"github.com/lugondev/go-carbon/pkg/decoder"
+	"github.com/lugondev/go-carbon/pkg/log"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+type refetcherFunc func(ctx context.Context, signature types.Signature) (*datasource.TransactionUpdate, error)
+
+func (f refetcherFunc) RefetchTransaction(ctx context.Context, signature types.Signature) (*datasource.TransactionUpdate, error) {
+	return f(ctx, signature)
+}
+
+func TestTruncatedLogs(t *testing.T) {
+	payer, program := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	event := append(append([]byte{}, decoder.EventIxTag[:]...), 1, 2, 3, 4, 5, 6, 7, 8)
+
+	truncatedUpdate := func() *datasource.TransactionUpdate {
+		update := transactionInvoking([]types.Pubkey{payer, program}, 1)
+		update.Meta.LogMessages = []string{"Program " + program.String() + " invoke [1]", log.TruncatedLogMessage}
+		update.Meta.InnerInstructions = []types.InnerInstructions{{
+			Index: 0,
+			Instructions: []types.InnerInstruction{{
+				Instruction: types.CompiledInstruction{ProgramIDIndex: 1, Data: event},
+			}},
+		}}
+		return update
+	}
+
+	run := func(policy TruncatedLogsPolicy) (*transaction.TransactionMetadata, map[string]uint64) {
+		recorder := &counterRecorder{counts: make(map[string]uint64)}
+
+		var got *transaction.TransactionMetadata
+		capture := processor.ProcessorFunc[transaction.TransactionProcessorInput[[]byte, any]](
+			func(_ context.Context, input transaction.TransactionProcessorInput[[]byte, any], _ *metrics.Collection) error {
+				got = input.Metadata
+				return nil
+			})
+		ixDecoder := instruction.NewProgramInstructionDecoder(program, func(data []byte) ([]byte, error) {
+			return data, nil
+		})
+
+		p := Builder().
+			TransactionPipe(transaction.NewTransactionPipe[[]byte, any](nil, ixDecoder, capture)).
+			TruncatedLogs(policy).
+			Metrics(metrics.NewCollection(recorder)).
+			Build()
+
+		if err := p.processTransactionUpdate(context.Background(), datasource.NewNamedDatasourceID("rpc"), truncatedUpdate()); err != nil {
+			t.Fatalf("processTransactionUpdate: %v", err)
+		}
+		if got == nil {
+			t.Fatal("transaction was not processed")
+		}
+		return got, recorder.counts
+	}
+
+	t.Run("refetch", func(t *testing.T) {
+		metadata, counts := run(TruncatedLogsPolicy{
+			Refetcher: refetcherFunc(func(context.Context, types.Signature) (*datasource.TransactionUpdate, error) {
+				update := truncatedUpdate()
+				update.Meta.LogMessages = update.Meta.LogMessages[:1]
+				return update, nil
+			}),
+		})
+		if metadata.LogsTruncated {
+			t.Error("expected refetched transaction to replace the truncated one")
+		}
+		if counts[metrics.MetricTruncatedLogsRefetched] != 1 || counts[metrics.MetricTransactionEventsPossiblyLost] != 0 {
+			t.Errorf("unexpected counters: %v", counts)
+		}
+	})
+
+	t.Run("cpi fallback", func(t *testing.T) {
+		metadata, counts := run(TruncatedLogsPolicy{CPIFallback: true})
+		if !metadata.LogsTruncated {
+			t.Error("expected metadata to be flagged as truncated")
+		}
+		if len(metadata.CPIEvents) != 1 || metadata.CPIEvents[0].ProgramID != program.String() {
+			t.Errorf("unexpected CPI events: %+v", metadata.CPIEvents)
+		}
+		if counts[metrics.MetricTransactionLogsTruncated] != 1 || counts[metrics.MetricTransactionEventsPossiblyLost] != 1 {
+			t.Errorf("unexpected counters: %v", counts)
+		}
+	})
+}
+
+func TestTruncatedLogsNotRefetchedWhenSkipped(t *testing.T) {
+	payer, program := solana.NewWallet().PublicKey(), solana.NewWallet().PublicKey()
+	update := transactionInvoking([]types.Pubkey{payer, program}, 1)
+	update.Meta.LogMessages = []string{"Program " + program.String() + " invoke [1]", log.TruncatedLogMessage}
+	update.Meta.Err = errors.New("InstructionError")
+
+	var refetches int
+	p := Builder().
+		FailedTransactionPolicy(transaction.FailedTransactionsExclude).
+		TruncatedLogs(TruncatedLogsPolicy{
+			Refetcher: refetcherFunc(func(context.Context, types.Signature) (*datasource.TransactionUpdate, error) {
+				refetches++
+				return nil, errors.New("unexpected refetch")
+			}),
+		}).
+		Build()
+
+	if err := p.processTransactionUpdate(context.Background(), datasource.NewNamedDatasourceID("rpc"), update); err != nil {
+		t.Fatalf("processTransactionUpdate: %v", err)
+	}
+	if refetches != 0 {
+		t.Errorf("refetched %d times a transaction the policy skips", refetches)
+	}
+}
@@ -74,7 +74,25 @@ func (p *DatasourceProcessor) ProcessTransactionUpdate(ctx context.Context, upda
 	return nil
 }
 
-func (p *DatasourceProcessor) ProcessEvent(ctx context.Context, event *decoder.Event, signature, slot string, blockTime *int64) error {
+// ProcessEvent saves an event decoded with its transaction context, as set by
+// decoder.Registry.DecodeTransactionProgramData. Events are keyed by
+// event.ID, so reprocessing a transaction overwrites its events.
+func (p *DatasourceProcessor) ProcessEvent(ctx context.Context, event *decoder.Event) error {
+	model, err := eventToModel(event)
+	if err != nil {
+		return err
+	}
+	return p.saveEvent(ctx, model)
+}
+
+// ProcessEventWithSignature saves an event decoded without its transaction
+// context, keyed by signature and event name as ProcessEvent was before it
+// read the context from the event.
+//
+// Deprecated: Decode events with decoder.Registry.DecodeTransactionProgramData
+// and use ProcessEvent. Events of the same name in a transaction overwrite
+// each other here.
+func (p *DatasourceProcessor) ProcessEventWithSignature(ctx context.Context, event *decoder.Event, signature, slot string, blockTime *int64) error {
 	data, ok := event.Data.(map[string]interface{})
 	if !ok {
 		data = map[string]interface{}{
@@ -87,7 +105,7 @@ func (p *DatasourceProcessor) ProcessEvent(ctx context.Context, event *decoder.E
 		fmt.Sscanf(slot, "%d", &slotNum)
 	}
 
-	model := &storage.EventModel{
+	return p.saveEvent(ctx, &storage.EventModel{
 		ID:        signature + "_" + event.Name,
 		Signature: signature,
 		ProgramID: event.ProgramID.String(),
@@ -95,22 +113,24 @@ func (p *DatasourceProcessor) ProcessEvent(ctx context.Context, event *decoder.E
 		Data:      data,
 		Slot:      slotNum,
 		BlockTime: blockTime,
-	}
+	})
+}
 
+func (p *DatasourceProcessor) saveEvent(ctx context.Context, model *storage.EventModel) error {
 	if err := p.repo.Events().Save(ctx, model); err != nil {
 		p.logger.Error("failed to save event",
-			"signature", signature,
-			"program_id", event.ProgramID.String(),
-			"event_name", event.Name,
+			"id", model.ID,
+			"program_id", model.ProgramID,
+			"event_name", model.EventName,
 			"error", err,
 		)
 		return fmt.Errorf("failed to save event: %w", err)
 	}
 
 	p.logger.Debug("event saved to database",
-		"signature", signature,
-		"program_id", event.ProgramID.String(),
-		"event_name", event.Name,
+		"id", model.ID,
+		"program_id", model.ProgramID,
+		"event_name", model.EventName,
 	)
 
 	return nil
@@ -175,6 +195,20 @@ func (p *BatchDatasourceProcessor) ProcessTransactionUpdate(ctx context.Context,
 	return nil
 }
 
+func (p *BatchDatasourceProcessor) ProcessEvent(ctx context.Context, event *decoder.Event) error {
+	model, err := eventToModel(event)
+	if err != nil {
+		return err
+	}
+	p.eventBatch = append(p.eventBatch, model)
+
+	if len(p.eventBatch) >= p.eventBatchSize {
+		return p.FlushEvents(ctx)
+	}
+
+	return nil
+}
+
 func (p *BatchDatasourceProcessor) FlushAccounts(ctx context.Context) error {
 	if len(p.accountBatch) == 0 {
 		return nil
@@ -239,6 +273,30 @@ func (p *BatchDatasourceProcessor) FlushAll(ctx context.Context) error {
 	return p.FlushEvents(ctx)
 }
 
+func eventToModel(event *decoder.Event) (*storage.EventModel, error) {
+	id := event.ID()
+	if id == "" {
+		return nil, fmt.Errorf("event %s has no transaction signature or source", event.Name)
+	}
+
+	data, ok := event.Data.(map[string]interface{})
+	if !ok {
+		data = map[string]interface{}{
+			"raw": event.Data,
+		}
+	}
+
+	return &storage.EventModel{
+		ID:        id,
+		Signature: event.Signature.String(),
+		ProgramID: event.ProgramID.String(),
+		EventName: event.Name,
+		Data:      data,
+		Slot:      event.Slot,
+		BlockTime: event.BlockTime,
+	}, nil
+}
+
 func TokenAccountToModel(address, mint, owner solana.PublicKey, amount uint64, decimals uint8, slot uint64) *storage.TokenAccountModel {
 	return storage.TokenAccountToModel(address, mint, owner, amount, decimals, nil, 0, false, nil, slot)
 }
@@ -0,0 +1,89 @@
+// Package tracing provides OpenTelemetry tracing helpers for the carbon pipeline.
+//
+// The pipeline starts a span for every update using its configured
+// trace.TracerProvider. Pipes, decoders and processors start child spans with
+// Start, which uses the tracer provider of the span already in the context, so
+// no tracer needs to be threaded through the pipes. When tracing is disabled
+// the context carries no recording span and Start is a cheap no-op.
+//
+// Processors receive the context of their span in Process and can start their
+// own child spans with Start or any OpenTelemetry tracer.
+package tracing
+
+import (
+	"context"
+
+	"github.com/lugondev/go-carbon/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name of the carbon tracer.
+const TracerName = "github.com/lugondev/go-carbon"
+
+// Span names used by the pipeline.
+const (
+	SpanProcessUpdate     = "carbon.process_update"
+	SpanPipe              = "carbon.pipe"
+	SpanDecodeAccount     = "carbon.decode_account"
+	SpanDecodeInstruction = "carbon.decode_instruction"
+	SpanParseTransaction  = "carbon.parse_transaction"
+	SpanProcess           = "carbon.process"
+)
+
+// Attribute keys used by the pipeline.
+const (
+	AttrDatasourceID = attribute.Key("carbon.datasource_id")
+	AttrUpdateType   = attribute.Key("carbon.update_type")
+	AttrPipe         = attribute.Key("carbon.pipe")
+	AttrSignature    = attribute.Key("solana.signature")
+	AttrSlot         = attribute.Key("solana.slot")
+	AttrProgramID    = attribute.Key("solana.program_id")
+	AttrPubkey       = attribute.Key("solana.pubkey")
+)
+
+// Signature returns the signature attribute.
+func Signature(signature types.Signature) attribute.KeyValue {
+	return AttrSignature.String(signature.String())
+}
+
+// Slot returns the slot attribute.
+func Slot(slot uint64) attribute.KeyValue {
+	return AttrSlot.Int64(int64(slot))
+}
+
+// ProgramID returns the program ID attribute.
+func ProgramID(programID types.Pubkey) attribute.KeyValue {
+	return AttrProgramID.String(programID.String())
+}
+
+// Pubkey returns the account pubkey attribute.
+func Pubkey(pubkey types.Pubkey) attribute.KeyValue {
+	return AttrPubkey.String(pubkey.String())
+}
+
+// Tracer returns the carbon tracer of the tracer provider of the span in ctx.
+func Tracer(ctx context.Context) trace.Tracer {
+	return trace.SpanFromContext(ctx).TracerProvider().Tracer(TracerName)
+}
+
+// Start starts a child span of the span in ctx. attrs is only called when the
+// span is recording, so attributes that are costly to build, such as base58
+// encoded keys, are skipped when tracing is disabled.
+func Start(ctx context.Context, name string, attrs func() []attribute.KeyValue) (context.Context, trace.Span) {
+	ctx, span := Tracer(ctx).Start(ctx, name)
+	if attrs != nil && span.IsRecording() {
+		span.SetAttributes(attrs()...)
+	}
+	return ctx, span
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
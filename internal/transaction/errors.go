@@ -0,0 +1,124 @@
+package transaction
+
+import (
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/internal/instruction"
+	"github.com/lugondev/go-carbon/pkg/log"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+// FailedTransactionPolicy determines how failed transactions are routed to pipes.
+type FailedTransactionPolicy int
+
+const (
+	// FailedTransactionsInclude processes failed transactions alongside successful ones.
+	// This is the default behavior.
+	FailedTransactionsInclude FailedTransactionPolicy = iota
+
+	// FailedTransactionsExclude skips failed transactions.
+	FailedTransactionsExclude
+
+	// FailedTransactionsOnly processes only failed transactions.
+	FailedTransactionsOnly
+)
+
+// String returns the string representation of the FailedTransactionPolicy.
+func (p FailedTransactionPolicy) String() string {
+	switch p {
+	case FailedTransactionsInclude:
+		return "Include"
+	case FailedTransactionsExclude:
+		return "Exclude"
+	case FailedTransactionsOnly:
+		return "Only"
+	default:
+		return "Unknown"
+	}
+}
+
+// Allows reports whether a transaction with the given status should be processed.
+func (p FailedTransactionPolicy) Allows(meta *types.TransactionStatusMeta) bool {
+	failed := meta != nil && !meta.IsSuccess()
+
+	switch p {
+	case FailedTransactionsExclude:
+		return !failed
+	case FailedTransactionsOnly:
+		return failed
+	default:
+		return true
+	}
+}
+
+// IsFailed reports whether the transaction failed.
+func (m *TransactionMetadata) IsFailed() bool {
+	return m.Meta != nil && !m.Meta.IsSuccess()
+}
+
+// TransactionError returns the structured error of a failed transaction, or nil.
+//
+// Errors reported as a plain error value are wrapped in a TransactionError whose
+// Kind and Raw are the error text.
+func (m *TransactionMetadata) TransactionError() *types.TransactionError {
+	if !m.IsFailed() {
+		return nil
+	}
+
+	if txErr, ok := m.Meta.Err.(*types.TransactionError); ok {
+		return txErr
+	}
+	text := m.Meta.Err.Error()
+	return &types.TransactionError{Kind: text, Raw: text}
+}
+
+// ResolveTransactionError attributes a failed transaction's instruction error
+// to the program that returned it and maps custom error codes to IDL names
+// using registry.
+//
+// The erroring program is taken from the first "Program X failed" log, which is
+// the innermost failing invocation, falling back to the program of the failing
+// top-level instruction. It is a no-op for successful transactions.
+func ResolveTransactionError(
+	metadata *TransactionMetadata,
+	nestedInstructions *instruction.NestedInstructions,
+	registry *types.ProgramErrorRegistry,
+) {
+	if registry == nil || !metadata.IsFailed() {
+		return
+	}
+
+	txErr, ok := metadata.Meta.Err.(*types.TransactionError)
+	if !ok || txErr.Instruction == nil {
+		return
+	}
+
+	ixErr := txErr.Instruction
+	if programID, ok := failingProgramFromLogs(metadata.Meta.LogMessages); ok {
+		registry.Resolve(ixErr, programID)
+		return
+	}
+
+	if nestedInstructions != nil && int(ixErr.Index) < nestedInstructions.Len() {
+		registry.Resolve(ixErr, nestedInstructions.Instructions[ixErr.Index].Instruction.ProgramID)
+	}
+}
+
+// failedLogParser is shared by failingProgramFromLogs; LogParser is stateless.
+var failedLogParser = log.NewParser()
+
+// failingProgramFromLogs returns the program of the first "Program X failed" log.
+func failingProgramFromLogs(logMessages []string) (types.Pubkey, bool) {
+	for _, msg := range logMessages {
+		parsed := failedLogParser.Parse(msg)
+		if parsed.Type != log.LogTypeFailed {
+			continue
+		}
+
+		programID, err := solana.PublicKeyFromBase58(parsed.ProgramID)
+		if err != nil {
+			return types.Pubkey{}, false
+		}
+		return programID, true
+	}
+	return types.Pubkey{}, false
+}
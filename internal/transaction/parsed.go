@@ -0,0 +1,256 @@
+package transaction
+
+import (
+	"encoding/json"
+	"math/big"
+
+	"github.com/lugondev/go-carbon/internal/instruction"
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+// ParsedInstruction represents a parsed instruction with its decoded data and inner instructions.
+type ParsedInstruction[T any] struct {
+	// ProgramID is the program ID associated with this instruction.
+	ProgramID types.Pubkey `json:"program_id"`
+
+	// Instruction is the decoded instruction data.
+	Instruction *instruction.DecodedInstruction[T] `json:"instruction"`
+
+	// InnerInstructions contains parsed nested instructions.
+	InnerInstructions []*ParsedInstruction[T] `json:"inner_instructions,omitempty"`
+}
+
+// BalanceChange is the change of an account's lamport balance during a transaction.
+type BalanceChange struct {
+	// Account is the public key of the account.
+	Account types.Pubkey `json:"account"`
+
+	// PreBalance is the balance before the transaction.
+	PreBalance uint64 `json:"pre_balance"`
+
+	// PostBalance is the balance after the transaction.
+	PostBalance uint64 `json:"post_balance"`
+
+	// Delta is PostBalance minus PreBalance.
+	Delta int64 `json:"delta"`
+}
+
+// TokenBalanceChange is the change of a token account's balance during a transaction.
+type TokenBalanceChange struct {
+	// Account is the public key of the token account.
+	Account types.Pubkey `json:"account"`
+
+	// Mint is the token mint address.
+	Mint string `json:"mint"`
+
+	// Owner is the owner of the token account.
+	Owner string `json:"owner"`
+
+	// Decimals is the number of decimals for the token.
+	Decimals uint8 `json:"decimals"`
+
+	// PreAmount is the raw token amount before the transaction.
+	PreAmount string `json:"pre_amount"`
+
+	// PostAmount is the raw token amount after the transaction.
+	PostAmount string `json:"post_amount"`
+
+	// Delta is PostAmount minus PreAmount as a signed raw amount.
+	Delta string `json:"delta"`
+}
+
+// ParsedTransaction represents a parsed transaction with its metadata and instructions.
+//
+// It is a self-contained view of a transaction as delivered to transaction
+// processors, and serializes to JSON with MarshalJSON.
+type ParsedTransaction[T any] struct {
+	// Metadata contains transaction metadata.
+	Metadata *TransactionMetadata
+
+	// Instructions contains parsed instructions.
+	Instructions []*ParsedInstruction[T]
+
+	// BalanceChanges contains the lamport balance changes of accounts whose balance changed.
+	BalanceChanges []BalanceChange
+
+	// TokenBalanceChanges contains the token balance changes of token accounts whose balance changed.
+	TokenBalanceChanges []TokenBalanceChange
+
+	// LogMessages is the list of log messages produced during execution.
+	LogMessages []string
+}
+
+// NewParsedTransaction creates a ParsedTransaction, computing balance changes
+// from the transaction status metadata.
+func NewParsedTransaction[T any](metadata *TransactionMetadata, instructions []*ParsedInstruction[T]) *ParsedTransaction[T] {
+	tx := &ParsedTransaction[T]{
+		Metadata:     metadata,
+		Instructions: instructions,
+	}
+
+	if metadata.Meta != nil {
+		accountKeys := metadata.AllAccountKeys()
+		tx.BalanceChanges = computeBalanceChanges(accountKeys, metadata.Meta)
+		tx.TokenBalanceChanges = computeTokenBalanceChanges(accountKeys, metadata.Meta)
+		tx.LogMessages = metadata.Meta.LogMessages
+	}
+
+	return tx
+}
+
+// AllAccountKeys returns the static account keys followed by the addresses
+// loaded from address lookup tables, matching the indexing of balances.
+func (m *TransactionMetadata) AllAccountKeys() []types.Pubkey {
+	if m.Meta == nil {
+		return m.AccountKeys
+	}
+
+	loaded := m.Meta.LoadedAddresses
+	keys := make([]types.Pubkey, 0, len(m.AccountKeys)+len(loaded.Writable)+len(loaded.Readonly))
+	keys = append(keys, m.AccountKeys...)
+	keys = append(keys, loaded.Writable...)
+	keys = append(keys, loaded.Readonly...)
+	return keys
+}
+
+// computeBalanceChanges returns the lamport balance changes of accounts whose balance changed.
+func computeBalanceChanges(accountKeys []types.Pubkey, meta *types.TransactionStatusMeta) []BalanceChange {
+	var changes []BalanceChange
+
+	for i := 0; i < len(meta.PreBalances) && i < len(meta.PostBalances) && i < len(accountKeys); i++ {
+		pre, post := meta.PreBalances[i], meta.PostBalances[i]
+		if pre == post {
+			continue
+		}
+		changes = append(changes, BalanceChange{
+			Account:     accountKeys[i],
+			PreBalance:  pre,
+			PostBalance: post,
+			Delta:       int64(post) - int64(pre),
+		})
+	}
+
+	return changes
+}
+
+// computeTokenBalanceChanges returns the token balance changes of token accounts
+// whose balance changed. Accounts created or closed by the transaction appear
+// with a zero pre or post amount.
+func computeTokenBalanceChanges(accountKeys []types.Pubkey, meta *types.TransactionStatusMeta) []TokenBalanceChange {
+	pre := make(map[uint8]types.TransactionTokenBalance, len(meta.PreTokenBalances))
+	for _, balance := range meta.PreTokenBalances {
+		pre[balance.AccountIndex] = balance
+	}
+
+	var changes []TokenBalanceChange
+	seen := make(map[uint8]bool, len(meta.PostTokenBalances))
+
+	for _, post := range meta.PostTokenBalances {
+		seen[post.AccountIndex] = true
+		before, exists := pre[post.AccountIndex]
+		if !exists {
+			before = types.TransactionTokenBalance{UITokenAmount: types.UITokenAmount{Amount: "0"}}
+		}
+		if change, ok := newTokenBalanceChange(accountKeys, post, before.UITokenAmount.Amount, post.UITokenAmount.Amount); ok {
+			changes = append(changes, change)
+		}
+	}
+
+	for _, before := range meta.PreTokenBalances {
+		if seen[before.AccountIndex] {
+			continue
+		}
+		if change, ok := newTokenBalanceChange(accountKeys, before, before.UITokenAmount.Amount, "0"); ok {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes
+}
+
+// newTokenBalanceChange builds a TokenBalanceChange, returning false if the
+// amounts are equal, unparsable or the account index is out of range.
+func newTokenBalanceChange(
+	accountKeys []types.Pubkey,
+	balance types.TransactionTokenBalance,
+	preAmount, postAmount string,
+) (TokenBalanceChange, bool) {
+	if int(balance.AccountIndex) >= len(accountKeys) {
+		return TokenBalanceChange{}, false
+	}
+
+	before, ok := new(big.Int).SetString(preAmount, 10)
+	if !ok {
+		return TokenBalanceChange{}, false
+	}
+	after, ok := new(big.Int).SetString(postAmount, 10)
+	if !ok {
+		return TokenBalanceChange{}, false
+	}
+
+	delta := new(big.Int).Sub(after, before)
+	if delta.Sign() == 0 {
+		return TokenBalanceChange{}, false
+	}
+
+	return TokenBalanceChange{
+		Account:    accountKeys[balance.AccountIndex],
+		Mint:       balance.Mint,
+		Owner:      balance.Owner,
+		Decimals:   balance.UITokenAmount.Decimals,
+		PreAmount:  preAmount,
+		PostAmount: postAmount,
+		Delta:      delta.String(),
+	}, true
+}
+
+// parsedTransactionJSON is the JSON representation of a ParsedTransaction.
+type parsedTransactionJSON[T any] struct {
+	Slot                 uint64                  `json:"slot"`
+	Signature            types.Signature         `json:"signature"`
+	FeePayer             types.Pubkey            `json:"fee_payer"`
+	Index                *uint64                 `json:"index,omitempty"`
+	BlockTime            *int64                  `json:"block_time,omitempty"`
+	BlockHash            *types.Hash             `json:"block_hash,omitempty"`
+	Success              bool                    `json:"success"`
+	Err                  *types.TransactionError `json:"err,omitempty"`
+	Fee                  uint64                  `json:"fee"`
+	ComputeUnitsConsumed *uint64                 `json:"compute_units_consumed,omitempty"`
+	AccountKeys          []types.Pubkey          `json:"account_keys"`
+	Instructions         []*ParsedInstruction[T] `json:"instructions"`
+	BalanceChanges       []BalanceChange         `json:"balance_changes,omitempty"`
+	TokenBalanceChanges  []TokenBalanceChange    `json:"token_balance_changes,omitempty"`
+	LogMessages          []string                `json:"log_messages,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (t *ParsedTransaction[T]) MarshalJSON() ([]byte, error) {
+	out := parsedTransactionJSON[T]{
+		Instructions:        t.Instructions,
+		BalanceChanges:      t.BalanceChanges,
+		TokenBalanceChanges: t.TokenBalanceChanges,
+		LogMessages:         t.LogMessages,
+	}
+
+	if m := t.Metadata; m != nil {
+		out.Slot = m.Slot
+		out.Signature = m.Signature
+		out.FeePayer = m.FeePayer
+		out.Index = m.Index
+		out.BlockTime = m.BlockTime
+		out.BlockHash = m.BlockHash
+		out.Success = !m.IsFailed()
+		out.Err = m.TransactionError()
+		out.AccountKeys = m.AllAccountKeys()
+		if m.Meta != nil {
+			out.Fee = m.Meta.Fee
+			out.ComputeUnitsConsumed = m.Meta.ComputeUnitsConsumed
+		}
+	}
+
+	if out.Instructions == nil {
+		out.Instructions = []*ParsedInstruction[T]{}
+	}
+
+	return json.Marshal(out)
+}
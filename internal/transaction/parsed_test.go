@@ -0,0 +1,57 @@
+package transaction
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lugondev/go-carbon/pkg/types"
+)
+
+func TestNewParsedTransactionBalanceChanges(t *testing.T) {
+	var payer, tokenAccount, loaded types.Pubkey
+	payer[0], tokenAccount[0], loaded[0] = 1, 2, 3
+
+	metadata := &TransactionMetadata{
+		Slot:        42,
+		FeePayer:    payer,
+		AccountKeys: []types.Pubkey{payer, tokenAccount},
+		Meta: &types.TransactionStatusMeta{
+			Fee:             5000,
+			PreBalances:     []uint64{1_000_000, 2_039_280, 10},
+			PostBalances:    []uint64{995_000, 2_039_280, 20},
+			LoadedAddresses: types.LoadedAddresses{Readonly: []types.Pubkey{loaded}},
+			PreTokenBalances: []types.TransactionTokenBalance{
+				{AccountIndex: 1, Mint: "mint", UITokenAmount: types.UITokenAmount{Amount: "100", Decimals: 6}},
+			},
+			PostTokenBalances: []types.TransactionTokenBalance{
+				{AccountIndex: 1, Mint: "mint", UITokenAmount: types.UITokenAmount{Amount: "40", Decimals: 6}},
+			},
+			LogMessages: []string{"Program log: hello"},
+		},
+	}
+
+	tx := NewParsedTransaction[testIx](metadata, []*ParsedInstruction[testIx]{parsed("swap")})
+
+	if len(tx.BalanceChanges) != 2 || tx.BalanceChanges[0].Delta != -5000 || tx.BalanceChanges[1].Account != loaded {
+		t.Errorf("unexpected balance changes: %+v", tx.BalanceChanges)
+	}
+	if len(tx.TokenBalanceChanges) != 1 || tx.TokenBalanceChanges[0].Delta != "-60" {
+		t.Errorf("unexpected token balance changes: %+v", tx.TokenBalanceChanges)
+	}
+
+	data, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded["slot"] != float64(42) || decoded["success"] != true || decoded["fee_payer"] != payer.String() {
+		t.Errorf("unexpected JSON: %s", data)
+	}
+	if ixs, ok := decoded["instructions"].([]any); !ok || len(ixs) != 1 {
+		t.Errorf("expected one instruction in JSON: %s", data)
+	}
+}
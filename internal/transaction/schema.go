@@ -0,0 +1,432 @@
+package transaction
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/lugondev/go-carbon/internal/instruction"
+)
+
+// TransactionSchema represents the schema for a transaction, defining the structure
+// and expected instructions.
+//
+// TransactionSchema allows you to define the structure of a transaction by specifying
+// a list of SchemaNode elements at the root level. These nodes can represent specific
+// instruction types or allow for flexibility with Any nodes.
+//
+// Matching a schema returns the instructions captured by every named
+// InstructionSchemaNode, which can then be bound into a user struct with
+// BindMatchedData.
+type TransactionSchema[T any] struct {
+	// Root contains the root schema nodes.
+	Root []SchemaNode[T]
+
+	// Matcher is a custom matching function (optional).
+	// When set, it replaces the node based matching and no bindings are captured.
+	Matcher func(instructions []*ParsedInstruction[T]) bool
+}
+
+// NewTransactionSchema creates a new TransactionSchema with the given root nodes.
+func NewTransactionSchema[T any](root ...SchemaNode[T]) *TransactionSchema[T] {
+	return &TransactionSchema[T]{
+		Root: root,
+	}
+}
+
+// Matches checks if the given instructions match this schema.
+func (s *TransactionSchema[T]) Matches(instructions []*ParsedInstruction[T]) bool {
+	_, ok := s.Match(instructions)
+	return ok
+}
+
+// Match matches the given instructions against this schema and returns the
+// instructions captured by each named node.
+//
+// The second return value reports whether the schema matched. Bindings are
+// only meaningful when it is true.
+func (s *TransactionSchema[T]) Match(instructions []*ParsedInstruction[T]) (SchemaBindings[T], bool) {
+	bindings := make(SchemaBindings[T])
+
+	// Use custom matcher if provided
+	if s.Matcher != nil {
+		return bindings, s.Matcher(instructions)
+	}
+
+	if !matchNodes(s.Root, instructions, false, bindings) {
+		return nil, false
+	}
+	return bindings, true
+}
+
+// requiresInstruction reports whether any match of the schema includes at least
+// one decoded instruction. Custom matchers are opaque and never require one.
+func (s *TransactionSchema[T]) requiresInstruction() bool {
+	if s.Matcher != nil {
+		return false
+	}
+
+	for _, node := range s.Root {
+		if ixNode, ok := node.(*InstructionSchemaNode[T]); ok && ixNode.Repeat != RepeatOptional {
+			return true
+		}
+	}
+	return false
+}
+
+// SchemaNode represents a node within a transaction schema, which can be either
+// an Instruction node or an Any node to allow for flexible matching.
+type SchemaNode[T any] interface {
+	isSchemaNode()
+}
+
+// Repetition controls how many consecutive instructions an InstructionSchemaNode matches.
+type Repetition int
+
+const (
+	// RepeatOnce matches exactly one instruction. This is the default.
+	RepeatOnce Repetition = iota
+
+	// RepeatOptional matches zero or one instruction.
+	RepeatOptional
+
+	// RepeatOneOrMore matches one or more consecutive instructions.
+	RepeatOneOrMore
+)
+
+// String returns the string representation of the Repetition.
+func (r Repetition) String() string {
+	switch r {
+	case RepeatOnce:
+		return "Once"
+	case RepeatOptional:
+		return "Optional"
+	case RepeatOneOrMore:
+		return "OneOrMore"
+	default:
+		return "Unknown"
+	}
+}
+
+// bounds returns the minimum and maximum number of instructions matched.
+// A maximum of -1 means unbounded.
+func (r Repetition) bounds() (int, int) {
+	switch r {
+	case RepeatOptional:
+		return 0, 1
+	case RepeatOneOrMore:
+		return 1, -1
+	default:
+		return 1, 1
+	}
+}
+
+// InstructionSchemaNode represents an instruction node within a schema, containing
+// the instruction type, name, and optional nested instructions.
+type InstructionSchemaNode[T any] struct {
+	// IxType is the expected instruction type.
+	IxType T
+
+	// Name is a unique name identifier for the instruction node.
+	// Instructions matched by this node are captured under this name.
+	// Nodes with an empty name are matched but not captured.
+	Name string
+
+	// InnerInstructions contains nested schema nodes.
+	InnerInstructions []SchemaNode[T]
+
+	// Matcher is a custom matching function for this instruction.
+	Matcher func(instruction *instruction.DecodedInstruction[T]) bool
+
+	// Repeat controls how many consecutive instructions this node matches.
+	Repeat Repetition
+}
+
+func (n *InstructionSchemaNode[T]) isSchemaNode() {}
+
+// AnySchemaNode matches any instruction type, providing flexibility within the schema.
+type AnySchemaNode[T any] struct{}
+
+func (n *AnySchemaNode[T]) isSchemaNode() {}
+
+// NewInstructionSchemaNode creates a named InstructionSchemaNode that matches
+// instructions accepted by matcher. A nil matcher accepts every decoded instruction.
+func NewInstructionSchemaNode[T any](
+	name string,
+	matcher func(instruction *instruction.DecodedInstruction[T]) bool,
+	inner ...SchemaNode[T],
+) *InstructionSchemaNode[T] {
+	return &InstructionSchemaNode[T]{
+		Name:              name,
+		Matcher:           matcher,
+		InnerInstructions: inner,
+	}
+}
+
+// NewAnySchemaNode creates an AnySchemaNode.
+func NewAnySchemaNode[T any]() *AnySchemaNode[T] {
+	return &AnySchemaNode[T]{}
+}
+
+// Optional marks the node as matching zero or one instruction.
+func Optional[T any](node *InstructionSchemaNode[T]) *InstructionSchemaNode[T] {
+	node.Repeat = RepeatOptional
+	return node
+}
+
+// OneOrMore marks the node as matching one or more consecutive instructions.
+func OneOrMore[T any](node *InstructionSchemaNode[T]) *InstructionSchemaNode[T] {
+	node.Repeat = RepeatOneOrMore
+	return node
+}
+
+// SchemaBindings maps the names of InstructionSchemaNodes to the instructions they matched.
+type SchemaBindings[T any] map[string][]*ParsedInstruction[T]
+
+// Get returns the first instruction captured under name, or nil.
+func (b SchemaBindings[T]) Get(name string) *ParsedInstruction[T] {
+	if ixs := b[name]; len(ixs) > 0 {
+		return ixs[0]
+	}
+	return nil
+}
+
+// All returns every instruction captured under name.
+func (b SchemaBindings[T]) All(name string) []*ParsedInstruction[T] {
+	return b[name]
+}
+
+// Has reports whether any instruction was captured under name.
+func (b SchemaBindings[T]) Has(name string) bool {
+	return len(b[name]) > 0
+}
+
+// add appends instructions under name without sharing backing arrays,
+// so bindings cloned for backtracking never observe each other's writes.
+func (b SchemaBindings[T]) add(name string, ixs ...*ParsedInstruction[T]) {
+	existing := b[name]
+	b[name] = append(existing[:len(existing):len(existing)], ixs...)
+}
+
+// clone returns a shallow copy of the bindings.
+func (b SchemaBindings[T]) clone() SchemaBindings[T] {
+	c := make(SchemaBindings[T], len(b))
+	for name, ixs := range b {
+		c[name] = ixs
+	}
+	return c
+}
+
+// merge appends every binding in other to b.
+func (b SchemaBindings[T]) merge(other SchemaBindings[T]) {
+	for name, ixs := range other {
+		b.add(name, ixs...)
+	}
+}
+
+// matchNodes matches instructions against schema nodes, capturing named nodes into bindings.
+//
+// Matching is anchored at the first instruction unless preceded by an Any node,
+// and trailing instructions after the last node are ignored. Optional and repeated
+// nodes are matched greedily, backtracking when the remaining nodes fail to match.
+func matchNodes[T any](
+	nodes []SchemaNode[T],
+	instructions []*ParsedInstruction[T],
+	anyMode bool,
+	bindings SchemaBindings[T],
+) bool {
+	if len(nodes) == 0 {
+		return true
+	}
+
+	// Handle Any node
+	if _, isAny := nodes[0].(*AnySchemaNode[T]); isAny {
+		return matchNodes(nodes[1:], instructions, true, bindings)
+	}
+
+	node, isInstruction := nodes[0].(*InstructionSchemaNode[T])
+	if !isInstruction {
+		return false
+	}
+
+	minCount, maxCount := node.Repeat.bounds()
+
+	maxSkip := 0
+	if anyMode {
+		maxSkip = len(instructions)
+	}
+
+	for skip := 0; skip <= maxSkip; skip++ {
+		candidates := instructions[skip:]
+
+		// Collect the longest run of consecutive matches starting at skip
+		var run []SchemaBindings[T]
+		for _, ix := range candidates {
+			if maxCount >= 0 && len(run) >= maxCount {
+				break
+			}
+			inner, ok := matchInstruction(node, ix)
+			if !ok {
+				break
+			}
+			run = append(run, inner)
+		}
+
+		// Try the longest run first, backing off until the rest of the schema matches
+		for count := len(run); count >= minCount; count-- {
+			attempt := bindings.clone()
+			for i := 0; i < count; i++ {
+				if node.Name != "" {
+					attempt.add(node.Name, candidates[i])
+				}
+				attempt.merge(run[i])
+			}
+
+			if matchNodes(nodes[1:], candidates[count:], false, attempt) {
+				for name, ixs := range attempt {
+					bindings[name] = ixs
+				}
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// matchInstruction checks a single instruction against a node and returns
+// the bindings captured by the node's inner schema.
+func matchInstruction[T any](node *InstructionSchemaNode[T], ix *ParsedInstruction[T]) (SchemaBindings[T], bool) {
+	if node.Matcher != nil && !node.Matcher(ix.Instruction) {
+		return nil, false
+	}
+
+	inner := make(SchemaBindings[T])
+	if len(node.InnerInstructions) > 0 {
+		if !matchNodes(node.InnerInstructions, ix.InnerInstructions, false, inner) {
+			return nil, false
+		}
+	}
+
+	return inner, true
+}
+
+// SchemaBinder can be implemented by the matched data type U of a TransactionPipe
+// to populate itself from schema bindings instead of the default field binding.
+type SchemaBinder[T any] interface {
+	BindSchema(bindings SchemaBindings[T]) error
+}
+
+// BindMatchedData deserializes schema bindings into a value of type U.
+//
+// If *U implements SchemaBinder[T], its BindSchema method is used. If U is an
+// interface type that SchemaBindings[T] implements, the bindings themselves
+// are stored. Otherwise U must be a
+// struct, and each exported field is populated from the binding named by its
+// `schema` tag, or by the field name when no tag is present. Supported field
+// types are:
+//
+//   - *ParsedInstruction[T] or []*ParsedInstruction[T]
+//   - *instruction.DecodedInstruction[T] or []*instruction.DecodedInstruction[T]
+//   - any type the decoded instruction data is assignable to, a pointer to it,
+//     or a slice of either
+//
+// Fields without a matching binding, such as unmatched optional nodes, are left
+// at their zero value. A field tagged `schema:"-"` is skipped.
+func BindMatchedData[T any, U any](bindings SchemaBindings[T]) (*U, error) {
+	out := new(U)
+
+	if binder, ok := any(out).(SchemaBinder[T]); ok {
+		if err := binder.BindSchema(bindings); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+
+	target := reflect.ValueOf(out).Elem()
+	switch target.Kind() {
+	case reflect.Interface:
+		value := reflect.ValueOf(bindings)
+		if !value.Type().AssignableTo(target.Type()) {
+			return nil, fmt.Errorf("cannot bind schema matches into %s: %s does not implement it", target.Type(), value.Type())
+		}
+		target.Set(value)
+		return out, nil
+	case reflect.Struct:
+	default:
+		return nil, fmt.Errorf("cannot bind schema matches into %s", target.Type())
+	}
+
+	targetType := target.Type()
+	for i := 0; i < targetType.NumField(); i++ {
+		field := targetType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("schema"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = strings.Split(tag, ",")[0]
+		}
+
+		matched := bindings[name]
+		if len(matched) == 0 {
+			continue
+		}
+
+		if err := bindField(target.Field(i), matched); err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+	}
+
+	return out, nil
+}
+
+// bindField assigns matched instructions to a single struct field.
+func bindField[T any](field reflect.Value, matched []*ParsedInstruction[T]) error {
+	if field.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(field.Type(), 0, len(matched))
+		for _, ix := range matched {
+			elem := reflect.New(field.Type().Elem()).Elem()
+			if err := bindValue(elem, ix); err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, elem)
+		}
+		field.Set(slice)
+		return nil
+	}
+
+	return bindValue(field, matched[0])
+}
+
+// bindValue assigns a single matched instruction to v.
+func bindValue[T any](v reflect.Value, ix *ParsedInstruction[T]) error {
+	candidates := []reflect.Value{reflect.ValueOf(ix)}
+	if ix.Instruction != nil {
+		candidates = append(candidates,
+			reflect.ValueOf(ix.Instruction),
+			reflect.ValueOf(ix.Instruction).Elem(),
+			reflect.ValueOf(&ix.Instruction.Data),
+			reflect.ValueOf(&ix.Instruction.Data).Elem(),
+		)
+
+		// Unwrap interface-typed instruction data to its dynamic value
+		data := reflect.ValueOf(any(ix.Instruction.Data))
+		if data.IsValid() {
+			candidates = append(candidates, data)
+		}
+	}
+
+	for _, candidate := range candidates {
+		if candidate.IsValid() && candidate.Type().AssignableTo(v.Type()) {
+			v.Set(candidate)
+			return nil
+		}
+	}
+
+	return fmt.Errorf("cannot assign matched instruction to %s", v.Type())
+}
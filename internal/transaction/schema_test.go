@@ -0,0 +1,111 @@
+package transaction
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lugondev/go-carbon/internal/instruction"
+)
+
+type testIx struct {
+	Kind   string
+	Amount uint64
+}
+
+func parsed(kind string, inner ...*ParsedInstruction[testIx]) *ParsedInstruction[testIx] {
+	return &ParsedInstruction[testIx]{
+		Instruction:       &instruction.DecodedInstruction[testIx]{Data: testIx{Kind: kind}},
+		InnerInstructions: inner,
+	}
+}
+
+func kind(k string) func(*instruction.DecodedInstruction[testIx]) bool {
+	return func(ix *instruction.DecodedInstruction[testIx]) bool {
+		return ix.Data.Kind == k
+	}
+}
+
+func TestSchemaMatchBindings(t *testing.T) {
+	schema := NewTransactionSchema[testIx](
+		NewInstructionSchemaNode("swap", kind("swap"),
+			OneOrMore(NewInstructionSchemaNode("transfers", kind("transfer"))),
+		),
+		NewAnySchemaNode[testIx](),
+		NewInstructionSchemaNode("close", kind("close")),
+	)
+
+	ixs := []*ParsedInstruction[testIx]{
+		parsed("swap", parsed("transfer"), parsed("transfer")),
+		parsed("memo"),
+		parsed("close"),
+	}
+
+	bindings, ok := schema.Match(ixs)
+	if !ok {
+		t.Fatal("expected schema to match")
+	}
+	if got := len(bindings.All("transfers")); got != 2 {
+		t.Errorf("expected 2 transfers, got %d", got)
+	}
+	if bindings.Get("swap") != ixs[0] || bindings.Get("close") != ixs[2] {
+		t.Error("unexpected swap or close binding")
+	}
+}
+
+func TestSchemaOptionalBacktracking(t *testing.T) {
+	// The optional node must give up its match so the required node can match.
+	schema := NewTransactionSchema[testIx](
+		Optional(NewInstructionSchemaNode("maybe", kind("transfer"))),
+		NewInstructionSchemaNode("required", kind("transfer")),
+	)
+
+	bindings, ok := schema.Match([]*ParsedInstruction[testIx]{parsed("transfer")})
+	if !ok {
+		t.Fatal("expected schema to match")
+	}
+	if bindings.Has("maybe") || !bindings.Has("required") {
+		t.Errorf("unexpected bindings: %v", bindings)
+	}
+
+	if schema.Matches([]*ParsedInstruction[testIx]{parsed("memo"), parsed("transfer")}) {
+		t.Error("expected anchored schema not to match")
+	}
+}
+
+func TestBindMatchedData(t *testing.T) {
+	type swapData struct {
+		Swap      *instruction.DecodedInstruction[testIx] `schema:"swap"`
+		Transfers []testIx                                `schema:"transfers"`
+		Close     *ParsedInstruction[testIx]              `schema:"close"`
+		Ignored   string                                  `schema:"-"`
+	}
+
+	bindings := SchemaBindings[testIx]{
+		"swap":      {parsed("swap")},
+		"transfers": {parsed("transfer"), parsed("transfer")},
+	}
+
+	data, err := BindMatchedData[testIx, swapData](bindings)
+	if err != nil {
+		t.Fatalf("BindMatchedData: %v", err)
+	}
+	if data.Swap == nil || data.Swap.Data.Kind != "swap" {
+		t.Errorf("unexpected swap binding: %+v", data.Swap)
+	}
+	if len(data.Transfers) != 2 || data.Transfers[1].Kind != "transfer" {
+		t.Errorf("unexpected transfers binding: %+v", data.Transfers)
+	}
+	if data.Close != nil {
+		t.Error("expected unmatched binding to stay nil")
+	}
+
+	if _, err := BindMatchedData[testIx, int](bindings); err == nil {
+		t.Error("expected error binding into non-struct type")
+	}
+	if _, err := BindMatchedData[testIx, fmt.Stringer](bindings); err == nil {
+		t.Error("expected error binding into an interface the bindings do not implement")
+	}
+	if data, err := BindMatchedData[testIx, any](bindings); err != nil || len((*data).(SchemaBindings[testIx])) != 2 {
+		t.Errorf("BindMatchedData into any = %v, %v", data, err)
+	}
+}
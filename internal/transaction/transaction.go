@@ -9,7 +9,8 @@
 //     specified processor.
 //   - TransactionMetadata: Metadata associated with a transaction, including slot,
 //     signature, and fee payer information.
-//   - ParsedTransaction: Represents a transaction with its metadata and parsed instructions.
+//   - ParsedTransaction: Represents a transaction with its metadata, parsed instructions,
+//     balance changes and logs, delivered to processors and serializable to JSON.
 //
 // # Usage
 //
@@ -22,6 +23,7 @@ import (
 	"context"
 	"log/slog"
 	"sync"
+	"time"
 
 	"github.com/lugondev/go-carbon/internal/datasource"
 	cerrors "github.com/lugondev/go-carbon/internal/errors"
@@ -29,7 +31,10 @@ import (
 	"github.com/lugondev/go-carbon/internal/instruction"
 	"github.com/lugondev/go-carbon/internal/metrics"
 	"github.com/lugondev/go-carbon/internal/processor"
+	"github.com/lugondev/go-carbon/internal/tracing"
+	"github.com/lugondev/go-carbon/pkg/log"
 	"github.com/lugondev/go-carbon/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // TransactionMetadata contains metadata about a transaction, including its slot, signature,
@@ -58,6 +63,15 @@ type TransactionMetadata struct {
 
 	// AccountKeys is the list of all account keys used in the transaction.
 	AccountKeys []types.Pubkey
+
+	// LogsTruncated reports whether the runtime truncated the log messages, in
+	// which case events logged after the truncation point are missing.
+	LogsTruncated bool
+
+	// CPIEvents are the emit_cpi! event payloads of the transaction. The
+	// pipeline extracts them when the logs are truncated and its
+	// TruncatedLogsPolicy enables the CPI fallback.
+	CPIEvents []log.ProgramData
 }
 
 // GetSlot returns the transaction slot.
@@ -75,6 +89,20 @@ func (m *TransactionMetadata) GetFeePayer() types.Pubkey {
 	return m.FeePayer
 }
 
+// GetFee returns the fee charged for the transaction, or zero if unknown.
+func (m *TransactionMetadata) GetFee() uint64 {
+	if m.Meta == nil {
+		return 0
+	}
+	return m.Meta.Fee
+}
+
+// GetAccountKeys returns all account keys of the transaction, including
+// addresses loaded from lookup tables.
+func (m *TransactionMetadata) GetAccountKeys() []types.Pubkey {
+	return m.AllAccountKeys()
+}
+
 // ToInstructionMetadataRef converts TransactionMetadata to a reference for instruction metadata.
 func (m *TransactionMetadata) ToInstructionMetadataRef() *instruction.TransactionMetadataRef {
 	var logMessages []string
@@ -83,11 +111,12 @@ func (m *TransactionMetadata) ToInstructionMetadataRef() *instruction.Transactio
 	}
 
 	return &instruction.TransactionMetadataRef{
-		Slot:        m.Slot,
-		Signature:   m.Signature,
-		FeePayer:    m.FeePayer,
-		LogMessages: logMessages,
-		Meta:        m.Meta,
+		Slot:          m.Slot,
+		Signature:     m.Signature,
+		FeePayer:      m.FeePayer,
+		LogMessages:   logMessages,
+		LogsTruncated: m.LogsTruncated,
+		Meta:          m.Meta,
 	}
 }
 
@@ -114,14 +143,15 @@ func NewTransactionMetadataFromUpdate(update *datasource.TransactionUpdate) (*Tr
 	}
 
 	return &TransactionMetadata{
-		Slot:        update.Slot,
-		Signature:   update.Signature,
-		FeePayer:    feePayer,
-		Meta:        &update.Meta,
-		Index:       update.Index,
-		BlockTime:   update.BlockTime,
-		BlockHash:   update.BlockHash,
-		AccountKeys: accountKeys,
+		Slot:          update.Slot,
+		Signature:     update.Signature,
+		FeePayer:      feePayer,
+		Meta:          &update.Meta,
+		Index:         update.Index,
+		BlockTime:     update.BlockTime,
+		BlockHash:     update.BlockHash,
+		AccountKeys:   accountKeys,
+		LogsTruncated: log.IsTruncated(update.Meta.LogMessages),
 	}, nil
 }
 
@@ -142,7 +172,12 @@ type TransactionProcessorInput[T any, U any] struct {
 	// Instructions contains all decoded instructions with their metadata.
 	Instructions []DecodedInstructionWithMetadata[T]
 
-	// MatchedData contains the matched schema data, if schema matching was enabled.
+	// Transaction is the fully parsed transaction, including the decoded
+	// instruction tree, balance changes and log messages.
+	Transaction *ParsedTransaction[T]
+
+	// MatchedData contains the schema bindings deserialized into U, if schema
+	// matching was enabled. See BindMatchedData for how bindings map onto U.
 	MatchedData *U
 }
 
@@ -167,6 +202,9 @@ type TransactionPipe[T any, U any] struct {
 	// InstructionDecoder decodes instructions into the type T.
 	InstructionDecoder instruction.InstructionDecoder[T]
 
+	// FailedTransactionPolicy determines whether failed transactions reach the processor.
+	FailedTransactionPolicy FailedTransactionPolicy
+
 	// Logger is used for logging (optional).
 	Logger *slog.Logger
 }
@@ -208,6 +246,22 @@ func (p *TransactionPipe[T, U]) WithLogger(logger *slog.Logger) *TransactionPipe
 	return p
 }
 
+// WithFailedTransactionPolicy sets how the TransactionPipe handles failed transactions.
+func (p *TransactionPipe[T, U]) WithFailedTransactionPolicy(policy FailedTransactionPolicy) *TransactionPipe[T, U] {
+	p.FailedTransactionPolicy = policy
+	return p
+}
+
+// ProgramIDs returns the programs a transaction must invoke for this pipe to
+// process it. A pipe is only scoped when its schema requires at least one
+// decoded instruction; without a schema the processor runs for every transaction.
+func (p *TransactionPipe[T, U]) ProgramIDs() ([]types.Pubkey, bool) {
+	if p.Schema == nil || !p.Schema.requiresInstruction() {
+		return nil, false
+	}
+	return instruction.ProgramIDsOf(p.InstructionDecoder)
+}
+
 // GetFilters returns the filters associated with this pipe.
 func (p *TransactionPipe[T, U]) GetFilters() []filter.Filter {
 	return p.Filters
@@ -225,8 +279,16 @@ func (p *TransactionPipe[T, U]) Run(
 		"signature", metadata.Signature.String(),
 	)
 
+	if !p.FailedTransactionPolicy.Allows(metadata.Meta) {
+		return nil
+	}
+
 	// Parse instructions
+	_, parseSpan := tracing.Start(ctx, tracing.SpanParseTransaction, func() []attribute.KeyValue {
+		return []attribute.KeyValue{tracing.Signature(metadata.Signature), tracing.Slot(metadata.Slot)}
+	})
 	parsedInstructions := p.parseInstructions(metadata, nestedInstructions)
+	parseSpan.End()
 
 	// Unnest instructions for the processor
 	unnestedInstructions := p.unnestInstructions(metadata, nestedInstructions, 0)
@@ -235,16 +297,33 @@ func (p *TransactionPipe[T, U]) Run(
 	input := TransactionProcessorInput[T, U]{
 		Metadata:     metadata,
 		Instructions: unnestedInstructions,
-		MatchedData:  nil, // Schema matching is handled separately if needed
 	}
 
-	// Check schema match if provided
-	if p.Schema != nil && !p.Schema.Matches(parsedInstructions) {
-		// Schema doesn't match, skip processing
-		return nil
+	// Check schema match if provided and bind the captured instructions
+	if p.Schema != nil {
+		bindings, ok := p.Schema.Match(parsedInstructions)
+		if !ok {
+			// Schema doesn't match, skip processing
+			metrics.RecordPipeDecode(ctx, metricsCollection, false)
+			return nil
+		}
+
+		matchedData, err := BindMatchedData[T, U](bindings)
+		if err != nil {
+			return cerrors.DecodeFailed("matched schema data", err)
+		}
+		input.MatchedData = matchedData
 	}
+	metrics.RecordPipeDecode(ctx, metricsCollection, true)
 
-	return p.Processor.Process(ctx, input, metricsCollection)
+	input.Transaction = NewParsedTransaction(metadata, parsedInstructions)
+
+	processCtx, span := tracing.Start(ctx, tracing.SpanProcess, nil)
+	start := time.Now()
+	err := p.Processor.Process(processCtx, input, metricsCollection)
+	metrics.RecordPipeProcess(ctx, metricsCollection, time.Since(start), err)
+	tracing.End(span, err)
+	return err
 }
 
 // parseInstructions parses nested instructions into ParsedInstructions.
@@ -383,148 +462,3 @@ func (m *MultiTransactionPipe) Len() int {
 	defer m.mu.RUnlock()
 	return len(m.pipes)
 }
-
-// ParsedInstruction represents a parsed instruction with its decoded data and inner instructions.
-type ParsedInstruction[T any] struct {
-	// ProgramID is the program ID associated with this instruction.
-	ProgramID types.Pubkey
-
-	// Instruction is the decoded instruction data.
-	Instruction *instruction.DecodedInstruction[T]
-
-	// InnerInstructions contains parsed nested instructions.
-	InnerInstructions []*ParsedInstruction[T]
-}
-
-// TransactionSchema represents the schema for a transaction, defining the structure
-// and expected instructions.
-//
-// TransactionSchema allows you to define the structure of a transaction by specifying
-// a list of SchemaNode elements at the root level. These nodes can represent specific
-// instruction types or allow for flexibility with Any nodes.
-type TransactionSchema[T any] struct {
-	// Root contains the root schema nodes.
-	Root []SchemaNode[T]
-
-	// Matcher is a custom matching function (optional).
-	Matcher func(instructions []*ParsedInstruction[T]) bool
-}
-
-// Matches checks if the given instructions match this schema.
-func (s *TransactionSchema[T]) Matches(instructions []*ParsedInstruction[T]) bool {
-	// Use custom matcher if provided
-	if s.Matcher != nil {
-		return s.Matcher(instructions)
-	}
-
-	// Default matching logic
-	return s.matchNodes(s.Root, instructions)
-}
-
-// SchemaNode represents a node within a transaction schema, which can be either
-// an Instruction node or an Any node to allow for flexible matching.
-type SchemaNode[T any] interface {
-	isSchemaNode()
-}
-
-// InstructionSchemaNode represents an instruction node within a schema, containing
-// the instruction type, name, and optional nested instructions.
-type InstructionSchemaNode[T any] struct {
-	// IxType is the expected instruction type.
-	IxType T
-
-	// Name is a unique name identifier for the instruction node.
-	Name string
-
-	// InnerInstructions contains nested schema nodes.
-	InnerInstructions []SchemaNode[T]
-
-	// Matcher is a custom matching function for this instruction.
-	Matcher func(instruction *instruction.DecodedInstruction[T]) bool
-}
-
-func (n *InstructionSchemaNode[T]) isSchemaNode() {}
-
-// AnySchemaNode matches any instruction type, providing flexibility within the schema.
-type AnySchemaNode[T any] struct{}
-
-func (n *AnySchemaNode[T]) isSchemaNode() {}
-
-// NewTransactionSchema creates a new TransactionSchema with the given root nodes.
-func NewTransactionSchema[T any](root ...SchemaNode[T]) *TransactionSchema[T] {
-	return &TransactionSchema[T]{
-		Root: root,
-	}
-}
-
-// matchNodes matches instructions against schema nodes.
-func (s *TransactionSchema[T]) matchNodes(nodes []SchemaNode[T], instructions []*ParsedInstruction[T]) bool {
-	nodeIndex := 0
-	instructionIndex := 0
-	anyMode := false
-
-	for nodeIndex < len(nodes) {
-		node := nodes[nodeIndex]
-
-		// Handle Any node
-		if _, isAny := node.(*AnySchemaNode[T]); isAny {
-			anyMode = true
-			nodeIndex++
-			continue
-		}
-
-		matched := false
-
-		for instructionIndex < len(instructions) {
-			currentInstruction := instructions[instructionIndex]
-
-			instructionNode, isInstruction := node.(*InstructionSchemaNode[T])
-			if !isInstruction {
-				return false
-			}
-
-			// Check if instruction matches
-			if instructionNode.Matcher != nil {
-				if !instructionNode.Matcher(currentInstruction.Instruction) {
-					if !anyMode {
-						return false
-					}
-					instructionIndex++
-					continue
-				}
-			}
-
-			// Match inner instructions if specified
-			if len(instructionNode.InnerInstructions) > 0 {
-				if !s.matchNodes(instructionNode.InnerInstructions, currentInstruction.InnerInstructions) {
-					if !anyMode {
-						return false
-					}
-					instructionIndex++
-					continue
-				}
-			}
-
-			instructionIndex++
-			nodeIndex++
-			anyMode = false
-			matched = true
-			break
-		}
-
-		if !matched {
-			return false
-		}
-	}
-
-	return true
-}
-
-// ParsedTransaction represents a parsed transaction with its metadata and instructions.
-type ParsedTransaction[T any] struct {
-	// Metadata contains transaction metadata.
-	Metadata *TransactionMetadata
-
-	// Instructions contains parsed instructions.
-	Instructions []*ParsedInstruction[T]
-}
@@ -0,0 +1,90 @@
+package decoder
+
+import (
+	"bytes"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/pkg/log"
+)
+
+// EventIxTag is the tag Anchor's emit_cpi! prefixes to the data of the
+// self-invoked instruction carrying an event: the first 8 bytes of
+// sha256("anchor:event"), the little-endian encoding of 0x1d9acb512ea545e4.
+//
+// The tag is followed by the event discriminator and the Borsh-encoded event,
+// the same payload emit! writes to a "Program data:" log.
+var EventIxTag = [8]byte{0xe4, 0x45, 0xa5, 0x2e, 0x51, 0xcb, 0x9a, 0x1d}
+
+// IsEventCPI reports whether instruction data carries an emit_cpi! event.
+func IsEventCPI(data []byte) bool {
+	return len(data) >= 16 && bytes.Equal(data[:8], EventIxTag[:])
+}
+
+// StripEventIxTag returns the event payload of emit_cpi! instruction data, or
+// data unchanged if it does not start with EventIxTag. It lets event decoders
+// accept payloads of both emit! and emit_cpi!.
+func StripEventIxTag(data []byte) []byte {
+	if IsEventCPI(data) {
+		return data[8:]
+	}
+	return data
+}
+
+// InvokedInstruction is an inner instruction with the program that invoked it
+// and its position in the transaction, as needed to extract emit_cpi! events.
+type InvokedInstruction struct {
+	// ProgramID is the program executing the instruction.
+	ProgramID solana.PublicKey
+
+	// InvokerProgramID is the program whose instruction invoked this one.
+	InvokerProgramID solana.PublicKey
+
+	// StackHeight is the call stack depth of the instruction (2 for
+	// instructions invoked by top-level instructions).
+	StackHeight int
+
+	// Path is the instruction path of the instruction.
+	Path log.InstructionPath
+
+	// Data is the instruction data.
+	Data []byte
+}
+
+// ExtractCPIEvents extracts the events emitted with Anchor's emit_cpi! from
+// inner instructions, attributed to the emitting program.
+//
+// An instruction carries an event when a program invokes itself with data
+// prefixed by EventIxTag. The tag is stripped, so the payloads decode with the
+// same decoders as "Program data:" logs, e.g. with Registry.DecodeAllProgramData.
+// The stack height and path of an event are those of the emitting instruction,
+// its LogIndex is -1 and its InnerIndex is the position of the self-invoked
+// instruction under the emitting one.
+func ExtractCPIEvents(instructions []InvokedInstruction) []log.ProgramData {
+	var events []log.ProgramData
+	for _, ix := range instructions {
+		if ix.ProgramID != ix.InvokerProgramID || !IsEventCPI(ix.Data) {
+			continue
+		}
+
+		path, innerIndex := ix.Path, 0
+		if len(path) > 0 {
+			path, innerIndex = path[:len(path)-1], int(path[len(path)-1])
+		}
+
+		events = append(events, log.ProgramData{
+			ProgramID:   ix.ProgramID.String(),
+			StackHeight: ix.StackHeight - 1,
+			Path:        path,
+			LogIndex:    -1,
+			InnerIndex:  innerIndex,
+			Data:        ix.Data[8:],
+		})
+	}
+	return events
+}
+
+// DecodeCPIEvents decodes the emit_cpi! events of inner instructions with
+// DecodeAllProgramData, skipping events no decoder handles.
+func (r *Registry) DecodeCPIEvents(instructions []InvokedInstruction) ([]*Event, error) {
+	return r.DecodeAllProgramData(ExtractCPIEvents(instructions))
+}
@@ -18,11 +18,14 @@
 package decoder
 
 import (
+	"bytes"
 	"encoding/binary"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/pkg/log"
 	"github.com/lugondev/go-carbon/pkg/view"
 )
 
@@ -42,6 +45,74 @@ type Event struct {
 
 	// Discriminator is the event discriminator (for Anchor events).
 	Discriminator []byte
+
+	// Signature is the signature of the transaction that emitted the event.
+	Signature solana.Signature
+
+	// Slot is the slot of the transaction.
+	Slot uint64
+
+	// BlockTime is the Unix timestamp of the block, or nil if unknown.
+	BlockTime *int64
+
+	// Source is where the event was found in its transaction, or
+	// SourceUnknown if it was not decoded from attributed program data.
+	Source EventSource
+
+	// InstructionPath is the path of the instruction that emitted the event.
+	InstructionPath log.InstructionPath
+
+	// LogIndex is, for SourceLog events, the position of the event's
+	// "Program data:" message in the transaction logs.
+	LogIndex int
+
+	// InnerIndex is, for SourceCPI events, the position of the
+	// self-invoked instruction carrying the event among the instructions
+	// invoked by InstructionPath.
+	InnerIndex int
+}
+
+// EventSource is where an event was found in its transaction.
+type EventSource uint8
+
+// Sources of events.
+const (
+	// SourceUnknown marks events whose position in their transaction is not
+	// known, such as events decoded with Registry.Decode.
+	SourceUnknown EventSource = iota
+
+	// SourceLog marks events decoded from a "Program data:" log message.
+	SourceLog
+
+	// SourceCPI marks events emitted with Anchor's emit_cpi!.
+	SourceCPI
+)
+
+// ID returns an identifier of the event that is unique within the indexed
+// chain and stable across reprocessing of its transaction: the signature
+// followed by the log index of logged events, or by the path of the
+// instruction carrying emit_cpi! events. It returns "" for events without a
+// signature or a known source; decode events with
+// Registry.DecodeTransactionProgramData to set both.
+func (e *Event) ID() string {
+	if e.Signature.IsZero() {
+		return ""
+	}
+
+	switch e.Source {
+	case SourceLog:
+		return fmt.Sprintf("%s_log_%d", e.Signature, e.LogIndex)
+	case SourceCPI:
+		var b strings.Builder
+		fmt.Fprintf(&b, "%s_ix_", e.Signature)
+		for _, index := range e.InstructionPath {
+			fmt.Fprintf(&b, "%d.", index)
+		}
+		fmt.Fprintf(&b, "%d", e.InnerIndex)
+		return b.String()
+	default:
+		return ""
+	}
 }
 
 // Decoder is the interface for decoding event data.
@@ -106,10 +177,18 @@ func (d *DecoderFunc) GetProgramID() solana.PublicKey {
 }
 
 // Registry manages multiple decoders and routes events to the appropriate decoder.
+//
+// Decoders implementing DiscriminatedDecoder are indexed by discriminator, per
+// program and across all decoders, so decoding dispatches to them directly.
 type Registry struct {
 	mu               sync.RWMutex
-	decoders         map[string]Decoder // key: program ID or decoder name
+	decoders         map[string]Decoder          // key: program ID or decoder name
+	keys             []string                    // keys of the decoders map in registration order
+	programIDs       map[string]solana.PublicKey // program ID each key is indexed by
 	decodersByPubkey map[solana.PublicKey][]Decoder
+	programs         map[solana.PublicKey]*decoderSet
+	programsByBase58 map[string]*decoderSet // same as programs, keyed by base58 program ID
+	all              *decoderSet            // the decoders of the decoders map
 	fallbackDecoder  Decoder
 }
 
@@ -117,7 +196,11 @@ type Registry struct {
 func NewRegistry() *Registry {
 	return &Registry{
 		decoders:         make(map[string]Decoder),
+		programIDs:       make(map[string]solana.PublicKey),
 		decodersByPubkey: make(map[solana.PublicKey][]Decoder),
+		programs:         make(map[solana.PublicKey]*decoderSet),
+		programsByBase58: make(map[string]*decoderSet),
+		all:              &decoderSet{},
 	}
 }
 
@@ -126,13 +209,8 @@ func (r *Registry) Register(key string, decoder Decoder) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	r.decoders[key] = decoder
-
-	// Also index by program ID if available
-	programID := decoder.GetProgramID()
-	if !programID.IsZero() {
-		r.decodersByPubkey[programID] = append(r.decodersByPubkey[programID], decoder)
-	}
+	// Index by the decoder's program ID, if it has one
+	r.setDecoder(key, decoder.GetProgramID(), decoder)
 }
 
 // RegisterForProgram registers a decoder for a specific program ID.
@@ -140,9 +218,50 @@ func (r *Registry) RegisterForProgram(programID solana.PublicKey, decoder Decode
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	key := programID.String()
+	r.setDecoder(programID.String(), programID, decoder)
+}
+
+// setDecoder registers a decoder by key, indexed by programID unless it is
+// zero. The caller must hold r.mu.
+func (r *Registry) setDecoder(key string, programID solana.PublicKey, decoder Decoder) {
+	_, replaced := r.decoders[key]
 	r.decoders[key] = decoder
+	r.programIDs[key] = programID
+
+	if !replaced {
+		r.keys = append(r.keys, key)
+		r.index(programID, decoder)
+		return
+	}
+
+	// Rebuild the indexes so the replaced decoder is no longer dispatched to,
+	// keeping the registration order of the keys
+	r.all = &decoderSet{}
+	r.decodersByPubkey = make(map[solana.PublicKey][]Decoder)
+	r.programs = make(map[solana.PublicKey]*decoderSet)
+	r.programsByBase58 = make(map[string]*decoderSet)
+	for _, k := range r.keys {
+		r.index(r.programIDs[k], r.decoders[k])
+	}
+}
+
+// index adds a decoder to the indexes, by programID unless it is zero. The
+// caller must hold r.mu.
+func (r *Registry) index(programID solana.PublicKey, decoder Decoder) {
+	r.all.add(decoder)
+	if programID.IsZero() {
+		return
+	}
+
 	r.decodersByPubkey[programID] = append(r.decodersByPubkey[programID], decoder)
+
+	set, exists := r.programs[programID]
+	if !exists {
+		set = &decoderSet{}
+		r.programs[programID] = set
+		r.programsByBase58[programID.String()] = set
+	}
+	set.add(decoder)
 }
 
 // SetFallbackDecoder sets a decoder to use when no specific decoder is found.
@@ -174,26 +293,23 @@ func (r *Registry) GetForProgram(programID solana.PublicKey) []Decoder {
 // 1. Decoders registered for the specific program ID (if provided)
 // 2. All registered decoders (if they can handle the data)
 // 3. Fallback decoder (if set)
+//
+// Within each step, the decoder indexed by the discriminator of the data is
+// found with a map lookup before decoders without a discriminator are tried.
 func (r *Registry) Decode(data []byte, programID *solana.PublicKey) (*Event, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	// Try program-specific decoders first
 	if programID != nil && !programID.IsZero() {
-		if decoders, exists := r.decodersByPubkey[*programID]; exists {
-			for _, decoder := range decoders {
-				if decoder.CanDecode(data) {
-					return decoder.Decode(data)
-				}
-			}
+		if decoder := r.programs[*programID].find(data); decoder != nil {
+			return decoder.Decode(data)
 		}
 	}
 
 	// Try all registered decoders
-	for _, decoder := range r.decoders {
-		if decoder.CanDecode(data) {
-			return decoder.Decode(data)
-		}
+	if decoder := r.all.find(data); decoder != nil {
+		return decoder.Decode(data)
 	}
 
 	// Try fallback decoder
@@ -236,16 +352,12 @@ func (r *Registry) DecodeAllParallel(dataList [][]byte, programID *solana.Public
 	return batchDecoder.DecodeAllParallel(dataList, programID, workers)
 }
 
-// ListDecoders returns all registered decoder names.
+// ListDecoders returns all registered decoder names in registration order.
 func (r *Registry) ListDecoders() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	names := make([]string, 0, len(r.decoders))
-	for key := range r.decoders {
-		names = append(names, key)
-	}
-	return names
+	return append([]string(nil), r.keys...)
 }
 
 // CompositeDecoder tries multiple decoders in sequence.
@@ -342,8 +454,10 @@ func NewAnchorDecoder(
 	}
 }
 
-// Decode implements Decoder interface.
+// Decode implements Decoder interface. It accepts payloads of both emit! and
+// emit_cpi! events.
 func (d *AnchorDecoderBase) Decode(data []byte) (*Event, error) {
+	data = StripEventIxTag(data)
 	if !d.CanDecode(data) {
 		return nil, fmt.Errorf("discriminator mismatch")
 	}
@@ -365,6 +479,7 @@ func (d *AnchorDecoderBase) Decode(data []byte) (*Event, error) {
 
 // CanDecode implements Decoder interface.
 func (d *AnchorDecoderBase) CanDecode(data []byte) bool {
+	data = StripEventIxTag(data)
 	if len(data) < 8 {
 		return false
 	}
@@ -383,6 +498,76 @@ func (d *AnchorDecoderBase) GetProgramID() solana.PublicKey {
 	return d.programID
 }
 
+// Discriminator implements DiscriminatedDecoder interface.
+func (d *AnchorDecoderBase) Discriminator() []byte {
+	return d.discriminator[:]
+}
+
+// PrefixDecoder decodes data starting with a discriminator of any length, such
+// as the 1-byte instruction tags of SPL programs or 4-byte tags of native programs.
+type PrefixDecoder struct {
+	name          string
+	programID     solana.PublicKey
+	discriminator []byte
+	decodeFunc    func([]byte) (interface{}, error)
+}
+
+// NewPrefixDecoder creates a new PrefixDecoder. decodeFunc receives the data
+// following the discriminator.
+func NewPrefixDecoder(
+	name string,
+	programID solana.PublicKey,
+	discriminator []byte,
+	decodeFunc func([]byte) (interface{}, error),
+) *PrefixDecoder {
+	return &PrefixDecoder{
+		name:          name,
+		programID:     programID,
+		discriminator: discriminator,
+		decodeFunc:    decodeFunc,
+	}
+}
+
+// Decode implements Decoder interface.
+func (d *PrefixDecoder) Decode(data []byte) (*Event, error) {
+	if !d.CanDecode(data) {
+		return nil, fmt.Errorf("discriminator mismatch")
+	}
+
+	decoded, err := d.decodeFunc(data[len(d.discriminator):])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode event data: %w", err)
+	}
+
+	return &Event{
+		Name:          d.name,
+		Data:          decoded,
+		RawData:       data,
+		ProgramID:     d.programID,
+		Discriminator: data[:len(d.discriminator)],
+	}, nil
+}
+
+// CanDecode implements Decoder interface.
+func (d *PrefixDecoder) CanDecode(data []byte) bool {
+	return bytes.HasPrefix(data, d.discriminator)
+}
+
+// GetName implements Decoder interface.
+func (d *PrefixDecoder) GetName() string {
+	return d.name
+}
+
+// GetProgramID implements Decoder interface.
+func (d *PrefixDecoder) GetProgramID() solana.PublicKey {
+	return d.programID
+}
+
+// Discriminator implements DiscriminatedDecoder interface.
+func (d *PrefixDecoder) Discriminator() []byte {
+	return d.discriminator
+}
+
 // BorshDecodable is the interface for types that can decode themselves from Borsh.
 type BorshDecodable interface {
 	UnmarshalBorsh([]byte) error
@@ -0,0 +1,561 @@
+package decoder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/pkg/idl"
+	"github.com/lugondev/go-carbon/pkg/utils"
+)
+
+// maxIDLTypeDepth bounds the nesting of decoded types, guarding against
+// recursive type definitions.
+const maxIDLTypeDepth = 64
+
+// IDLValue is an instruction, account or event decoded by an IDLDecoder.
+type IDLValue struct {
+	// Name is the name of the instruction, account or event in the IDL.
+	Name string `json:"name"`
+
+	// Data holds the decoded fields by name.
+	Data map[string]any `json:"data"`
+}
+
+// IDLDecoder decodes the instructions, accounts and events of an Anchor
+// program at runtime from its IDL, without generated code.
+//
+// Values are decoded with Borsh rules into:
+//   - bool, uint8-uint64, int8-int64, float32, float64, string and []byte
+//     for the primitive types, and *big.Int for 128- and 256-bit integers
+//   - solana.PublicKey for pubkey
+//   - nil or the value for option and coption
+//   - []any for vec, array and tuple types
+//   - map[string]any for structs, or []any for tuple structs
+//   - the variant name for unit enum variants, and a map from the variant
+//     name to its fields for the others
+//
+// Generic type definitions are decoded with the type and const arguments of
+// each use.
+type IDLDecoder struct {
+	programID    solana.PublicKey
+	types        map[string]*idl.TypeDef
+	instructions []idlItem
+	accounts     []idlItem
+	events       []idlItem
+}
+
+// idlGenerics binds the generic parameters of a type definition to the
+// arguments of a reference to it.
+type idlGenerics map[string]idlGenericArg
+
+// idlGenericArg is a generic argument with the bindings of the scope it was
+// written in, which it may reference.
+type idlGenericArg struct {
+	typ   *idl.Type
+	scope idlGenerics
+}
+
+// idlItem is an instruction, account or event with its discriminator and the
+// struct its data decodes to.
+type idlItem struct {
+	name          string
+	discriminator []byte
+	fields        []idl.Field
+}
+
+// NewIDLDecoder creates an IDLDecoder for the program described by program.
+//
+// The program ID is the IDL address, if any. Discriminators missing from older
+// IDLs are derived with Anchor's rules.
+func NewIDLDecoder(program *idl.IDL) (*IDLDecoder, error) {
+	d := &IDLDecoder{types: make(map[string]*idl.TypeDef, len(program.Types))}
+
+	if program.Address != "" {
+		programID, err := solana.PublicKeyFromBase58(program.Address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IDL address: %w", err)
+		}
+		d.programID = programID
+	}
+
+	for i := range program.Types {
+		d.types[program.Types[i].Name] = &program.Types[i]
+	}
+
+	for _, ix := range program.Instructions {
+		d.instructions = append(d.instructions, idlItem{
+			name:          ix.Name,
+			discriminator: discriminatorOr(ix.Discriminator, "global:"+utils.ToSnakeCase(ix.Name)),
+			fields:        ix.Args,
+		})
+	}
+
+	for _, account := range program.Accounts {
+		fields, err := d.itemFields(account.Name, account.Type.Struct)
+		if err != nil {
+			return nil, fmt.Errorf("account %s: %w", account.Name, err)
+		}
+		d.accounts = append(d.accounts, idlItem{
+			name:          account.Name,
+			discriminator: discriminatorOr(account.Discriminator, "account:"+account.Name),
+			fields:        fields,
+		})
+	}
+
+	for _, event := range program.Events {
+		var inline *idl.StructType
+		if len(event.Fields) > 0 {
+			inline = &idl.StructType{Fields: event.Fields}
+		}
+		fields, err := d.itemFields(event.Name, inline)
+		if err != nil {
+			return nil, fmt.Errorf("event %s: %w", event.Name, err)
+		}
+		d.events = append(d.events, idlItem{
+			name:          event.Name,
+			discriminator: discriminatorOr(event.Discriminator, "event:"+event.Name),
+			fields:        fields,
+		})
+	}
+
+	return d, nil
+}
+
+// discriminatorOr returns discriminator, or the first 8 bytes of the SHA-256
+// of preimage if it is empty.
+func discriminatorOr(discriminator []byte, preimage string) []byte {
+	if len(discriminator) > 0 {
+		return discriminator
+	}
+	hash := sha256.Sum256([]byte(preimage))
+	return hash[:8]
+}
+
+// itemFields returns the fields of an account or event: the inline struct of
+// older IDLs, or the struct type definition of the same name.
+func (d *IDLDecoder) itemFields(name string, inline *idl.StructType) ([]idl.Field, error) {
+	if inline != nil {
+		return inline.Fields, nil
+	}
+	def, ok := d.types[name]
+	if !ok {
+		return nil, nil
+	}
+	if def.Type.Struct == nil {
+		return nil, fmt.Errorf("type %s is not a struct", name)
+	}
+	return def.Type.Struct.Fields, nil
+}
+
+// ProgramID returns the program ID of the IDL, or the zero key if it has none.
+func (d *IDLDecoder) ProgramID() solana.PublicKey {
+	return d.programID
+}
+
+// DecodeInstructionData decodes instruction data into its arguments. It
+// returns nil if no instruction of the IDL has the data's discriminator.
+func (d *IDLDecoder) DecodeInstructionData(data []byte) (*IDLValue, error) {
+	return d.decodeItem(d.instructions, data)
+}
+
+// DecodeAccountData decodes account data into its fields. It returns nil if no
+// account of the IDL has the data's discriminator.
+func (d *IDLDecoder) DecodeAccountData(data []byte) (*IDLValue, error) {
+	return d.decodeItem(d.accounts, data)
+}
+
+// DecodeEventData decodes event data, with or without the EventIxTag of
+// emit_cpi!, into its fields. It returns nil if no event of the IDL has the
+// data's discriminator.
+func (d *IDLDecoder) DecodeEventData(data []byte) (*IDLValue, error) {
+	return d.decodeItem(d.events, StripEventIxTag(data))
+}
+
+// EventDecoders returns a Decoder for each event of the IDL, with the event
+// name as decoder name and an IDLValue as event data.
+func (d *IDLDecoder) EventDecoders() []Decoder {
+	decoders := make([]Decoder, 0, len(d.events))
+	for _, event := range d.events {
+		decoders = append(decoders, NewPrefixDecoder(event.name, d.programID, event.discriminator,
+			func(data []byte) (interface{}, error) {
+				fields, err := d.decodeFields(event.fields, data)
+				if err != nil {
+					return nil, err
+				}
+				return &IDLValue{Name: event.name, Data: fields}, nil
+			}))
+	}
+	return decoders
+}
+
+// Register registers the event decoders of the IDL into registry, for the
+// program of the IDL if it has an address.
+func (d *IDLDecoder) Register(registry *Registry) {
+	for _, dec := range d.EventDecoders() {
+		if d.programID.IsZero() {
+			registry.Register(dec.GetName(), dec)
+		} else {
+			registry.RegisterForProgram(d.programID, dec)
+		}
+	}
+}
+
+// decodeItem decodes data with the item whose discriminator it starts with.
+func (d *IDLDecoder) decodeItem(items []idlItem, data []byte) (*IDLValue, error) {
+	for _, item := range items {
+		if !bytes.HasPrefix(data, item.discriminator) {
+			continue
+		}
+		fields, err := d.decodeFields(item.fields, data[len(item.discriminator):])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", item.name, err)
+		}
+		return &IDLValue{Name: item.name, Data: fields}, nil
+	}
+	return nil, nil
+}
+
+// decodeFields decodes a struct with fields from data, ignoring trailing bytes
+// such as account padding.
+func (d *IDLDecoder) decodeFields(fields []idl.Field, data []byte) (map[string]any, error) {
+	r := &borshReader{data: data}
+	values := make(map[string]any, len(fields))
+	for _, field := range fields {
+		value, err := d.decodeType(r, &field.Type, nil, 0)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		values[field.Name] = value
+	}
+	return values, nil
+}
+
+// decodeType decodes a value of type t. generics binds the generic parameters
+// of the enclosing type definition.
+func (d *IDLDecoder) decodeType(r *borshReader, t *idl.Type, generics idlGenerics, depth int) (any, error) {
+	if depth > maxIDLTypeDepth {
+		return nil, fmt.Errorf("type nesting exceeds %d levels", maxIDLTypeDepth)
+	}
+	depth++
+
+	switch {
+	case t.Generic != "":
+		arg, ok := generics[t.Generic]
+		if !ok {
+			return nil, fmt.Errorf("unbound generic %s", t.Generic)
+		}
+		return d.decodeType(r, arg.typ, arg.scope, depth)
+
+	case t.Defined != nil:
+		return d.decodeDefined(r, t.Defined, generics, depth)
+
+	case t.Option != nil:
+		tag, err := r.u8()
+		if err != nil || tag == 0 {
+			return nil, err
+		}
+		return d.decodeType(r, t.Option, generics, depth)
+
+	case t.Coption != nil:
+		// COption is laid out with a u32 tag and a value that is present, and
+		// zeroed, even when the tag is 0.
+		tag, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		if tag == 0 {
+			start := r.off
+			if _, err := d.decodeType(r, t.Coption, generics, depth); err != nil {
+				r.off = start
+			}
+			return nil, nil
+		}
+		return d.decodeType(r, t.Coption, generics, depth)
+
+	case t.Vec != nil:
+		n, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		if int(n) > r.remaining() {
+			return nil, fmt.Errorf("vec length %d exceeds remaining %d bytes", n, r.remaining())
+		}
+		return d.decodeSequence(r, t.Vec, int(n), generics, depth)
+
+	case t.Array != nil:
+		n := t.Array.Len
+		if t.Array.LenGeneric != "" {
+			arg, ok := generics[t.Array.LenGeneric]
+			if !ok {
+				return nil, fmt.Errorf("unbound generic %s", t.Array.LenGeneric)
+			}
+			parsed, err := strconv.Atoi(arg.typ.Const)
+			if err != nil {
+				return nil, fmt.Errorf("invalid array length %q for generic %s", arg.typ.Const, t.Array.LenGeneric)
+			}
+			n = parsed
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("invalid array length %d", n)
+		}
+		if n > r.remaining() {
+			return nil, fmt.Errorf("array length %d exceeds remaining %d bytes", n, r.remaining())
+		}
+		return d.decodeSequence(r, &t.Array.Type, n, generics, depth)
+
+	case t.Struct != nil:
+		return d.decodeStruct(r, t.Struct.Fields, generics, depth)
+
+	case t.Enum != nil:
+		return d.decodeEnum(r, t.Enum, generics, depth)
+
+	case len(t.Tuple) > 0:
+		values := make([]any, len(t.Tuple))
+		for i := range t.Tuple {
+			value, err := d.decodeType(r, &t.Tuple[i], generics, depth)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+
+	case t.Kind != "":
+		return r.primitive(t.Kind)
+
+	default:
+		return nil, fmt.Errorf("unsupported IDL type %+v", *t)
+	}
+}
+
+// decodeDefined decodes a value of a defined type, binding its generic
+// parameters to the arguments of the reference.
+func (d *IDLDecoder) decodeDefined(r *borshReader, ref *idl.DefinedType, generics idlGenerics, depth int) (any, error) {
+	def, ok := d.types[ref.Name]
+	if !ok {
+		return nil, fmt.Errorf("undefined type %s", ref.Name)
+	}
+	if len(ref.Generics) != len(def.Generics) {
+		return nil, fmt.Errorf("type %s takes %d generic arguments, got %d", ref.Name, len(def.Generics), len(ref.Generics))
+	}
+
+	var bound idlGenerics
+	if len(def.Generics) > 0 {
+		bound = make(idlGenerics, len(def.Generics))
+		for i, param := range def.Generics {
+			arg := idlGenericArg{typ: &ref.Generics[i], scope: generics}
+			// Forward arguments that are the caller's own parameters, so const
+			// arguments resolve to their values.
+			if arg.typ.Generic != "" {
+				forwarded, ok := generics[arg.typ.Generic]
+				if !ok {
+					return nil, fmt.Errorf("unbound generic %s", arg.typ.Generic)
+				}
+				arg = forwarded
+			}
+			bound[param.Name] = arg
+		}
+	}
+
+	return d.decodeType(r, &def.Type, bound, depth)
+}
+
+// decodeSequence decodes n values of type elem.
+func (d *IDLDecoder) decodeSequence(r *borshReader, elem *idl.Type, n int, generics idlGenerics, depth int) ([]any, error) {
+	values := make([]any, n)
+	for i := range values {
+		value, err := d.decodeType(r, elem, generics, depth)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// decodeStruct decodes struct fields into a map, or into a slice if the
+// fields are unnamed, as in tuple structs and variants.
+func (d *IDLDecoder) decodeStruct(r *borshReader, fields []idl.Field, generics idlGenerics, depth int) (any, error) {
+	if len(fields) > 0 && fields[0].Name == "" {
+		values := make([]any, len(fields))
+		for i := range fields {
+			value, err := d.decodeType(r, &fields[i].Type, generics, depth)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	}
+
+	values := make(map[string]any, len(fields))
+	for i := range fields {
+		value, err := d.decodeType(r, &fields[i].Type, generics, depth)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", fields[i].Name, err)
+		}
+		values[fields[i].Name] = value
+	}
+	return values, nil
+}
+
+// decodeEnum decodes an enum, whose variant is selected by a u8 tag.
+func (d *IDLDecoder) decodeEnum(r *borshReader, enum *idl.EnumType, generics idlGenerics, depth int) (any, error) {
+	tag, err := r.u8()
+	if err != nil {
+		return nil, err
+	}
+	if int(tag) >= len(enum.Variants) {
+		return nil, fmt.Errorf("invalid enum variant %d of %d", tag, len(enum.Variants))
+	}
+
+	variant := enum.Variants[tag]
+	if len(variant.Fields) == 0 {
+		return variant.Name, nil
+	}
+	fields, err := d.decodeStruct(r, variant.Fields, generics, depth)
+	if err != nil {
+		return nil, fmt.Errorf("variant %s: %w", variant.Name, err)
+	}
+	return map[string]any{variant.Name: fields}, nil
+}
+
+// borshReader reads Borsh-encoded values from data.
+type borshReader struct {
+	data []byte
+	off  int
+}
+
+func (r *borshReader) remaining() int {
+	return len(r.data) - r.off
+}
+
+// read returns the next n bytes.
+func (r *borshReader) read(n int) ([]byte, error) {
+	if n < 0 || n > r.remaining() {
+		return nil, fmt.Errorf("unexpected end of data at offset %d: need %d bytes, have %d", r.off, n, r.remaining())
+	}
+	b := r.data[r.off : r.off+n]
+	r.off += n
+	return b, nil
+}
+
+func (r *borshReader) u8() (uint8, error) {
+	b, err := r.read(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
+}
+
+func (r *borshReader) u32() (uint32, error) {
+	b, err := r.read(4)
+	if err != nil {
+		return 0, err
+	}
+	return binary.LittleEndian.Uint32(b), nil
+}
+
+// primitive decodes a value of a primitive type.
+func (r *borshReader) primitive(kind string) (any, error) {
+	switch kind {
+	case "bool":
+		b, err := r.u8()
+		return b != 0, err
+	case "u8":
+		return r.u8()
+	case "i8":
+		b, err := r.u8()
+		return int8(b), err
+	case "u16", "i16":
+		b, err := r.read(2)
+		if err != nil {
+			return nil, err
+		}
+		v := binary.LittleEndian.Uint16(b)
+		if kind == "i16" {
+			return int16(v), nil
+		}
+		return v, nil
+	case "u32", "i32", "f32":
+		b, err := r.read(4)
+		if err != nil {
+			return nil, err
+		}
+		v := binary.LittleEndian.Uint32(b)
+		switch kind {
+		case "i32":
+			return int32(v), nil
+		case "f32":
+			return math.Float32frombits(v), nil
+		}
+		return v, nil
+	case "u64", "i64", "f64":
+		b, err := r.read(8)
+		if err != nil {
+			return nil, err
+		}
+		v := binary.LittleEndian.Uint64(b)
+		switch kind {
+		case "i64":
+			return int64(v), nil
+		case "f64":
+			return math.Float64frombits(v), nil
+		}
+		return v, nil
+	case "u128", "i128":
+		return r.bigInt(16, kind == "i128")
+	case "u256", "i256":
+		return r.bigInt(32, kind == "i256")
+	case "string":
+		b, err := r.bytes()
+		return string(b), err
+	case "bytes":
+		b, err := r.bytes()
+		if err != nil {
+			return nil, err
+		}
+		return bytes.Clone(b), nil
+	case "pubkey", "publicKey":
+		b, err := r.read(32)
+		if err != nil {
+			return nil, err
+		}
+		return solana.PublicKeyFromBytes(b), nil
+	default:
+		return nil, fmt.Errorf("unsupported primitive type %s", kind)
+	}
+}
+
+// bytes reads a u32 length-prefixed byte string.
+func (r *borshReader) bytes() ([]byte, error) {
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	return r.read(int(n))
+}
+
+// bigInt reads a little-endian integer of size bytes.
+func (r *borshReader) bigInt(size int, signed bool) (*big.Int, error) {
+	b, err := r.read(size)
+	if err != nil {
+		return nil, err
+	}
+
+	be := make([]byte, size)
+	for i := range b {
+		be[size-1-i] = b[i]
+	}
+	v := new(big.Int).SetBytes(be)
+	if signed && be[0]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+	}
+	return v, nil
+}
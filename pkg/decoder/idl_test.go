@@ -0,0 +1,171 @@
+package decoder
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/lugondev/go-carbon/pkg/idl"
+)
+
+const testIDL = `{
+	"address": "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA",
+	"metadata": {"name": "demo", "version": "0.1.0", "spec": "0.1.0"},
+	"instructions": [{
+		"name": "swap",
+		"discriminator": [1, 1, 1, 1, 1, 1, 1, 1],
+		"accounts": [],
+		"args": [
+			{"name": "amount", "type": "u64"},
+			{"name": "memo", "type": {"option": "string"}}
+		]
+	}],
+	"accounts": [{"name": "Pool", "discriminator": [2, 2, 2, 2, 2, 2, 2, 2]}],
+	"events": [{"name": "Swapped", "discriminator": [3, 3, 3, 3, 3, 3, 3, 3]}],
+	"types": [
+		{"name": "Pool", "type": {"kind": "struct", "fields": [
+			{"name": "authority", "type": "pubkey"},
+			{"name": "delegate", "type": {"coption": "pubkey"}},
+			{"name": "fees", "type": {"array": ["u16", 2]}},
+			{"name": "pair", "type": {"defined": {"name": "Pair", "generics": [
+				{"kind": "type", "type": "u8"},
+				{"kind": "const", "value": "3"}
+			]}}}
+		]}},
+		{"name": "Pair", "generics": [
+			{"kind": "type", "name": "T"},
+			{"kind": "const", "name": "N", "type": "usize"}
+		], "type": {"kind": "struct", "fields": [
+			{"name": "items", "type": {"array": [{"generic": "T"}, {"generic": "N"}]}},
+			{"name": "tag", "type": {"tuple": ["bool", "i128"]}}
+		]}},
+		{"name": "Side", "type": {"kind": "enum", "variants": [
+			{"name": "Buy"},
+			{"name": "Sell", "fields": ["u64"]},
+			{"name": "Limit", "fields": [{"name": "price", "type": "u32"}]}
+		]}},
+		{"name": "Swapped", "type": {"kind": "struct", "fields": [
+			{"name": "sides", "type": {"vec": {"defined": {"name": "Side"}}}}
+		]}}
+	]
+}`
+
+func TestIDLDecoder(t *testing.T) {
+	program, err := idl.Parse([]byte(testIDL))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	d, err := NewIDLDecoder(program)
+	if err != nil {
+		t.Fatalf("NewIDLDecoder: %v", err)
+	}
+	programID := d.ProgramID()
+
+	t.Run("instruction", func(t *testing.T) {
+		data := bytes.Repeat([]byte{1}, 8)
+		data = binary.LittleEndian.AppendUint64(data, 500)
+		data = append(data, 1, 2, 0, 0, 0, 'h', 'i')
+
+		value, err := d.DecodeInstructionData(data)
+		if err != nil {
+			t.Fatalf("DecodeInstructionData: %v", err)
+		}
+		want := &IDLValue{Name: "swap", Data: map[string]any{"amount": uint64(500), "memo": "hi"}}
+		if !reflect.DeepEqual(value, want) {
+			t.Errorf("got %#v, want %#v", value, want)
+		}
+	})
+
+	t.Run("account", func(t *testing.T) {
+		data := bytes.Repeat([]byte{2}, 8)
+		data = append(data, programID.Bytes()...)
+		data = append(data, make([]byte, 4+32)...) // COption::None
+		data = append(data, 5, 0, 7, 0, 9, 8, 7, 1)
+		data = append(data, bytes.Repeat([]byte{0xff}, 16)...)
+		data[len(data)-16] = 0xfe
+
+		value, err := d.DecodeAccountData(data)
+		if err != nil {
+			t.Fatalf("DecodeAccountData: %v", err)
+		}
+		fields := value.Data
+		if fields["authority"] != programID || fields["delegate"] != nil {
+			t.Errorf("unexpected keys: %v, %v", fields["authority"], fields["delegate"])
+		}
+		if !reflect.DeepEqual(fields["fees"], []any{uint16(5), uint16(7)}) {
+			t.Errorf("fees = %#v", fields["fees"])
+		}
+		pair := fields["pair"].(map[string]any)
+		if !reflect.DeepEqual(pair["items"], []any{uint8(9), uint8(8), uint8(7)}) {
+			t.Errorf("items = %#v", pair["items"])
+		}
+		tag := pair["tag"].([]any)
+		if tag[0] != true || tag[1].(*big.Int).Cmp(big.NewInt(-2)) != 0 {
+			t.Errorf("tag = %v", tag)
+		}
+	})
+
+	t.Run("event", func(t *testing.T) {
+		registry := NewRegistry()
+		d.Register(registry)
+
+		data := bytes.Repeat([]byte{3}, 8)
+		data = append(data, 3, 0, 0, 0, 0, 1)
+		data = binary.LittleEndian.AppendUint64(data, 42)
+		data = append(data, 2, 100, 0, 0, 0)
+
+		event, err := registry.Decode(data, &programID)
+		if err != nil {
+			t.Fatalf("Decode: %v", err)
+		}
+		want := []any{
+			"Buy",
+			map[string]any{"Sell": []any{uint64(42)}},
+			map[string]any{"Limit": map[string]any{"price": uint32(100)}},
+		}
+		value := event.Data.(*IDLValue)
+		if event.Name != "Swapped" || !reflect.DeepEqual(value.Data["sides"], want) {
+			t.Errorf("got %s %#v, want %#v", event.Name, value.Data["sides"], want)
+		}
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		data := append(bytes.Repeat([]byte{1}, 8), 1, 2, 3)
+		if _, err := d.DecodeInstructionData(data); err == nil {
+			t.Error("expected an error for truncated data")
+		}
+		if value, err := d.DecodeInstructionData([]byte{9, 9, 9, 9, 9, 9, 9, 9}); value != nil || err != nil {
+			t.Errorf("unknown discriminator = %v, %v, want nil", value, err)
+		}
+	})
+}
+
+func TestIDLDecoderRejectsBadArrayLengths(t *testing.T) {
+	for _, length := range []string{"-1", "4000000000"} {
+		program, err := idl.Parse([]byte(`{
+			"address": "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA",
+			"accounts": [{"name": "Buffer", "discriminator": [2, 2, 2, 2, 2, 2, 2, 2]}],
+			"types": [
+				{"name": "Buffer", "type": {"kind": "struct", "fields": [
+					{"name": "items", "type": {"defined": {"name": "Items", "generics": [{"kind": "const", "value": "` + length + `"}]}}}
+				]}},
+				{"name": "Items", "generics": [{"kind": "const", "name": "N", "type": "usize"}],
+					"type": {"kind": "struct", "fields": [{"name": "values", "type": {"array": ["u8", {"generic": "N"}]}}]}}
+			]
+		}`))
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		d, err := NewIDLDecoder(program)
+		if err != nil {
+			t.Fatalf("NewIDLDecoder: %v", err)
+		}
+
+		data := append(bytes.Repeat([]byte{2}, 8), 1, 2, 3)
+		if _, err := d.DecodeAccountData(data); err == nil {
+			t.Errorf("length %s: expected an error", length)
+		}
+	}
+}
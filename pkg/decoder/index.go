@@ -0,0 +1,98 @@
+package decoder
+
+import "sort"
+
+// DiscriminatedDecoder is implemented by decoders that only decode data
+// starting with a fixed discriminator, such as Anchor events and instructions
+// (8 bytes), native programs with 4-byte tags or SPL instructions (1 byte).
+//
+// The Registry indexes these decoders by discriminator and dispatches to them
+// with a map lookup instead of calling CanDecode on every decoder. Decoders
+// without a discriminator are still tried in order.
+type DiscriminatedDecoder interface {
+	Decoder
+
+	// Discriminator returns the prefix of the data the decoder handles.
+	Discriminator() []byte
+}
+
+// discriminatorIndex maps data prefixes of varying lengths to decoders.
+type discriminatorIndex struct {
+	// lengths are the distinct discriminator lengths, longest first, so the
+	// most specific discriminator wins.
+	lengths  []int
+	decoders map[string][]Decoder
+}
+
+// add indexes decoder by discriminator.
+func (x *discriminatorIndex) add(discriminator []byte, decoder Decoder) {
+	if x.decoders == nil {
+		x.decoders = make(map[string][]Decoder)
+	}
+
+	key := string(discriminator)
+	if !x.hasLength(len(key)) {
+		x.lengths = append(x.lengths, len(key))
+		sort.Sort(sort.Reverse(sort.IntSlice(x.lengths)))
+	}
+	x.decoders[key] = append(x.decoders[key], decoder)
+}
+
+// hasLength reports whether a discriminator of length n is indexed.
+func (x *discriminatorIndex) hasLength(n int) bool {
+	for _, length := range x.lengths {
+		if length == n {
+			return true
+		}
+	}
+	return false
+}
+
+// lookup returns the decoder indexed by a prefix of data that can decode it.
+// The prefix is taken after the EventIxTag of emit_cpi! instruction data.
+func (x *discriminatorIndex) lookup(data []byte) Decoder {
+	prefix := StripEventIxTag(data)
+	for _, n := range x.lengths {
+		if len(prefix) < n {
+			continue
+		}
+		for _, decoder := range x.decoders[string(prefix[:n])] {
+			if decoder.CanDecode(data) {
+				return decoder
+			}
+		}
+	}
+	return nil
+}
+
+// decoderSet is a set of decoders dispatched by discriminator, with the
+// decoders that have none tried in registration order.
+type decoderSet struct {
+	index  discriminatorIndex
+	others []Decoder
+}
+
+// add adds decoder to the set.
+func (s *decoderSet) add(decoder Decoder) {
+	if discriminated, ok := decoder.(DiscriminatedDecoder); ok && len(discriminated.Discriminator()) > 0 {
+		s.index.add(discriminated.Discriminator(), decoder)
+		return
+	}
+	s.others = append(s.others, decoder)
+}
+
+// find returns the decoder of the set that can decode data, or nil.
+func (s *decoderSet) find(data []byte) Decoder {
+	if s == nil {
+		return nil
+	}
+	if decoder := s.index.lookup(data); decoder != nil {
+		return decoder
+	}
+	for _, decoder := range s.others {
+		if decoder.CanDecode(data) {
+			return decoder
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package decoder
+
+import (
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+)
+
+func TestRegistryDispatchesByDiscriminator(t *testing.T) {
+	program := solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	decodeRest := func(data []byte) (interface{}, error) { return data, nil }
+
+	canDecodeCalls := 0
+	generic := NewDecoderFunc("generic", program,
+		func(data []byte) bool { canDecodeCalls++; return len(data) == 2 },
+		func(data []byte) (*Event, error) { return &Event{Name: "generic"}, nil },
+	)
+
+	registry := NewRegistry()
+	registry.RegisterForProgram(program, generic)
+	registry.Register("transfer", NewPrefixDecoder("transfer", program, []byte{3}, decodeRest))
+	registry.Register("native", NewPrefixDecoder("native", program, []byte{3, 0, 0, 0}, decodeRest))
+	registry.Register("anchor", NewAnchorDecoder("anchor", program,
+		NewAnchorDiscriminator([]byte{3, 0, 0, 0, 9, 9, 9, 9}), decodeRest))
+
+	tests := []struct {
+		data []byte
+		want string
+	}{
+		{[]byte{3, 1, 1, 1, 1}, "transfer"},
+		{[]byte{3, 0, 0, 0, 1}, "native"},
+		{[]byte{3, 0, 0, 0, 9, 9, 9, 9, 1}, "anchor"},
+		{append(append([]byte{}, EventIxTag[:]...), 3, 0, 0, 0, 9, 9, 9, 9, 1), "anchor"},
+	}
+	for _, tt := range tests {
+		event, err := registry.Decode(tt.data, &program)
+		if err != nil {
+			t.Fatalf("Decode(%v): %v", tt.data, err)
+		}
+		if event.Name != tt.want {
+			t.Errorf("Decode(%v) = %s, want %s", tt.data, event.Name, tt.want)
+		}
+	}
+	if canDecodeCalls != 0 {
+		t.Errorf("expected indexed decoders to bypass CanDecode of other decoders, got %d calls", canDecodeCalls)
+	}
+
+	// Decoders without a discriminator are still tried.
+	event, err := registry.Decode([]byte{7, 7}, &program)
+	if err != nil || event.Name != "generic" {
+		t.Errorf("expected generic decoder, got %v, %v", event, err)
+	}
+
+	// Replacing a decoder by key removes the old one from dispatch.
+	registry.Register("transfer", NewPrefixDecoder("transfer_v2", program, []byte{4}, decodeRest))
+	if _, err := registry.Decode([]byte{3, 1, 1, 1, 1}, nil); err == nil {
+		t.Error("expected replaced decoder to be removed")
+	}
+}
+
+func TestRegistryKeepsRegistrationOrderOnReplace(t *testing.T) {
+	accept := func(name string) Decoder {
+		return NewDecoderFunc(name, solana.PublicKey{},
+			func([]byte) bool { return true },
+			func([]byte) (*Event, error) { return &Event{Name: name}, nil },
+		)
+	}
+
+	registry := NewRegistry()
+	for _, key := range []string{"first", "second", "third", "fourth"} {
+		registry.Register(key, accept(key))
+	}
+
+	// Map iteration would pick an arbitrary decoder after a rebuild.
+	for i := 0; i < 20; i++ {
+		registry.Register("third", accept("third"))
+		event, err := registry.Decode([]byte{1}, nil)
+		if err != nil || event.Name != "first" {
+			t.Fatalf("expected first registered decoder, got %v, %v", event, err)
+		}
+	}
+
+	names := registry.ListDecoders()
+	want := []string{"first", "second", "third", "fourth"}
+	if len(names) != len(want) {
+		t.Fatalf("ListDecoders() = %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("ListDecoders() = %v, want %v", names, want)
+			break
+		}
+	}
+}
+
+func TestRegistryReplacesProgramDecoders(t *testing.T) {
+	program := solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	accept := func(name string) Decoder {
+		return NewDecoderFunc(name, program,
+			func([]byte) bool { return true },
+			func([]byte) (*Event, error) { return &Event{Name: name}, nil },
+		)
+	}
+
+	registry := NewRegistry()
+	registry.Register("swap", accept("v1"))
+	registry.Register("swap", accept("v2"))
+	registry.RegisterForProgram(program, accept("program_v1"))
+	registry.RegisterForProgram(program, accept("program_v2"))
+
+	decoders := registry.GetForProgram(program)
+	if len(decoders) != 2 || decoders[0].GetName() != "v2" || decoders[1].GetName() != "program_v2" {
+		names := make([]string, len(decoders))
+		for i, d := range decoders {
+			names[i] = d.GetName()
+		}
+		t.Fatalf("GetForProgram() = %v, want [v2 program_v2]", names)
+	}
+
+	event, err := registry.Decode([]byte{1}, &program)
+	if err != nil || event.Name != "v2" {
+		t.Errorf("expected the replacement decoder for the program, got %v, %v", event, err)
+	}
+}
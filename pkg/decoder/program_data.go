@@ -0,0 +1,106 @@
+package decoder
+
+import (
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/pkg/log"
+)
+
+// DecodeProgramData decodes a "Program data:" payload attributed to the program
+// that emitted it, as returned by log.LogParser.ExtractAttributedProgramData.
+//
+// Only the decoders registered for the emitting program are tried, found with
+// a map lookup by program and discriminator, followed by the fallback decoder. If the decoder does
+// not set the event's program ID, it is set to the emitting program. The
+// event's source, instruction path, log index and inner index are those of
+// data.
+func (r *Registry) DecodeProgramData(data log.ProgramData) (*Event, error) {
+	r.mu.RLock()
+	decoder := r.programsByBase58[data.ProgramID].find(data.Data)
+	if decoder == nil && r.fallbackDecoder != nil && r.fallbackDecoder.CanDecode(data.Data) {
+		decoder = r.fallbackDecoder
+	}
+	r.mu.RUnlock()
+
+	if decoder != nil {
+		event, err := decoder.Decode(data.Data)
+		if err != nil {
+			return nil, err
+		}
+		if event != nil && event.ProgramID.IsZero() {
+			if programID, err := solana.PublicKeyFromBase58(data.ProgramID); err == nil {
+				event.ProgramID = programID
+			}
+		}
+		if event != nil {
+			event.Source = SourceLog
+			if data.LogIndex < 0 {
+				event.Source = SourceCPI
+			}
+			event.InstructionPath = data.Path
+			event.LogIndex = data.LogIndex
+			event.InnerIndex = data.InnerIndex
+		}
+		return event, nil
+	}
+
+	return nil, fmt.Errorf("no decoder found for program %s data (length: %d)", data.ProgramID, len(data.Data))
+}
+
+// DecodeAllProgramData decodes attributed payloads with DecodeProgramData,
+// skipping payloads no decoder handles.
+func (r *Registry) DecodeAllProgramData(dataList []log.ProgramData) ([]*Event, error) {
+	events := make([]*Event, 0, len(dataList))
+
+	for _, data := range dataList {
+		event, err := r.DecodeProgramData(data)
+		if err != nil {
+			continue
+		}
+		if event != nil {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+// TransactionContext identifies the transaction events were emitted in.
+type TransactionContext struct {
+	// Signature is the signature of the transaction.
+	Signature solana.Signature
+
+	// Slot is the slot of the transaction.
+	Slot uint64
+
+	// BlockTime is the Unix timestamp of the block, or nil if unknown.
+	BlockTime *int64
+}
+
+// DecodeTransactionProgramData decodes the attributed payloads of the
+// transaction tx with DecodeAllProgramData and sets the signature, slot and
+// block time of the events, giving them unique IDs.
+//
+// Example:
+//
+//	data := parser.ExtractAttributedProgramData(meta.LogMessages)
+//	data = append(data, nestedInstructions.CPIEvents()...)
+//	events, _ := registry.DecodeTransactionProgramData(decoder.TransactionContext{
+//	    Signature: signature,
+//	    Slot:      slot,
+//	    BlockTime: blockTime,
+//	}, data)
+func (r *Registry) DecodeTransactionProgramData(tx TransactionContext, dataList []log.ProgramData) ([]*Event, error) {
+	events, err := r.DecodeAllProgramData(dataList)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events {
+		event.Signature = tx.Signature
+		event.Slot = tx.Slot
+		event.BlockTime = tx.BlockTime
+	}
+	return events, nil
+}
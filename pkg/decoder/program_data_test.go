@@ -0,0 +1,134 @@
+package decoder
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/pkg/log"
+)
+
+func TestDecodeAllProgramData(t *testing.T) {
+	outer := solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	inner := solana.MustPublicKeyFromBase58("11111111111111111111111111111111")
+	payload := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	data := "Program data: " + base64.StdEncoding.EncodeToString(payload)
+
+	logs := []string{
+		"Program " + outer.String() + " invoke [1]",
+		"Program " + inner.String() + " invoke [2]",
+		"Program " + inner.String() + " success",
+		"Program " + inner.String() + " invoke [2]",
+		data,
+		"Program " + inner.String() + " success",
+		data,
+		"Program " + outer.String() + " success",
+	}
+
+	attributed := log.NewParser().ExtractAttributedProgramData(logs)
+	if len(attributed) != 2 {
+		t.Fatalf("expected 2 payloads, got %d", len(attributed))
+	}
+
+	want := []struct {
+		programID   solana.PublicKey
+		stackHeight int
+		path        string
+		logIndex    int
+	}{
+		{inner, 2, "[0, 1]", 4},
+		{outer, 1, "[0]", 6},
+	}
+	for i, w := range want {
+		got := attributed[i]
+		if got.ProgramID != w.programID.String() || got.StackHeight != w.stackHeight || got.Path.String() != w.path || got.LogIndex != w.logIndex {
+			t.Errorf("payload %d: got %+v", i, got)
+		}
+	}
+
+	registry := NewRegistry()
+	registry.RegisterForProgram(outer, NewDecoderFunc("outer", outer,
+		func([]byte) bool { return true },
+		func(data []byte) (*Event, error) { return &Event{Name: "outer", Data: data}, nil },
+	))
+
+	events, err := registry.DecodeAllProgramData(attributed)
+	if err != nil {
+		t.Fatalf("DecodeAllProgramData: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(events))
+	}
+	if events[0].Name != "outer" || events[0].ProgramID != outer {
+		t.Errorf("unexpected event: %+v", events[0])
+	}
+}
+
+func TestDecodeTransactionProgramData(t *testing.T) {
+	program := solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	payload := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	cpiData := append(EventIxTag[:], payload...)
+	data := "Program data: " + base64.StdEncoding.EncodeToString(payload)
+
+	// The same event, twice in the logs and twice with emit_cpi!.
+	attributed := log.NewParser().ExtractAttributedProgramData([]string{
+		"Program " + program.String() + " invoke [1]",
+		data,
+		data,
+		"Program " + program.String() + " success",
+	})
+	attributed = append(attributed, ExtractCPIEvents([]InvokedInstruction{
+		{ProgramID: program, InvokerProgramID: program, StackHeight: 2, Path: log.InstructionPath{0, 0}, Data: cpiData},
+		{ProgramID: program, InvokerProgramID: program, StackHeight: 2, Path: log.InstructionPath{0, 1}, Data: cpiData},
+	})...)
+
+	registry := NewRegistry()
+	registry.RegisterForProgram(program, NewDecoderFunc("swap", program,
+		func([]byte) bool { return true },
+		func(data []byte) (*Event, error) { return &Event{Name: "Swap", Data: data}, nil },
+	))
+
+	blockTime := int64(1700000000)
+	tx := TransactionContext{Signature: solana.Signature{1}, Slot: 42, BlockTime: &blockTime}
+	events, err := registry.DecodeTransactionProgramData(tx, attributed)
+	if err != nil {
+		t.Fatalf("DecodeTransactionProgramData: %v", err)
+	}
+
+	sig := tx.Signature.String()
+	want := []string{sig + "_log_1", sig + "_log_2", sig + "_ix_0.0", sig + "_ix_0.1"}
+	if len(events) != len(want) {
+		t.Fatalf("expected %d events, got %d", len(want), len(events))
+	}
+	for i, event := range events {
+		if id := event.ID(); id != want[i] {
+			t.Errorf("event %d: ID = %s, want %s", i, id, want[i])
+		}
+		if event.Signature != tx.Signature || event.Slot != 42 || event.BlockTime != &blockTime || !event.InstructionPath.Equals(log.InstructionPath{0}) {
+			t.Errorf("event %d: missing transaction context: %+v", i, event)
+		}
+	}
+}
+
+func TestEventIDRequiresSource(t *testing.T) {
+	program := solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	registry := NewRegistry()
+	registry.RegisterForProgram(program, NewDecoderFunc("swap", program,
+		func([]byte) bool { return true },
+		func(data []byte) (*Event, error) { return &Event{Name: "Swap", Data: data}, nil },
+	))
+
+	event, err := registry.Decode([]byte{1, 2, 3, 4, 5, 6, 7, 8}, &program)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	event.Signature = solana.Signature{1}
+	if id := event.ID(); id != "" {
+		t.Errorf("ID of event without source = %q, want empty", id)
+	}
+
+	built := &Event{Name: "Swap", Signature: solana.Signature{1}}
+	if id := built.ID(); id != "" {
+		t.Errorf("ID of hand-built event = %q, want empty", id)
+	}
+}
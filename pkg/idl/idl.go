@@ -0,0 +1,384 @@
+// Package idl models Anchor IDLs (Interface Definition Languages), the JSON
+// documents describing the instructions, accounts, events and types of
+// Solana programs.
+//
+// Parse accepts the IDL formats of both older and current Anchor versions. The
+// parsed IDL drives the code generator and decoder.IDLDecoder.
+package idl
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// IDL represents an Anchor IDL (Interface Definition Language) structure.
+// This is the JSON schema used by Anchor to describe Solana programs.
+type IDL struct {
+	Address      string        `json:"address"`
+	Metadata     Metadata      `json:"metadata"`
+	Instructions []Instruction `json:"instructions"`
+	Accounts     []AccountDef  `json:"accounts"`
+	Events       []Event       `json:"events"`
+	Errors       []ErrorDef    `json:"errors"`
+	Types        []TypeDef     `json:"types"`
+	Constants    []Constant    `json:"constants,omitempty"`
+}
+
+// Metadata contains program metadata.
+type Metadata struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Spec        string `json:"spec"`
+	Description string `json:"description,omitempty"`
+}
+
+// Instruction represents a program instruction.
+type Instruction struct {
+	Name          string        `json:"name"`
+	Discriminator []byte        `json:"discriminator"`
+	Accounts      []AccountMeta `json:"accounts"`
+	Args          []Field       `json:"args"`
+	Returns       *Type         `json:"returns,omitempty"`
+	Docs          []string      `json:"docs,omitempty"`
+}
+
+// AccountMeta represents an account in an instruction.
+type AccountMeta struct {
+	Name     string   `json:"name"`
+	Writable bool     `json:"writable,omitempty"`
+	Signer   bool     `json:"signer,omitempty"`
+	Optional bool     `json:"optional,omitempty"`
+	Address  string   `json:"address,omitempty"`
+	PDA      *PDA     `json:"pda,omitempty"`
+	Docs     []string `json:"docs,omitempty"`
+}
+
+// PDA represents a Program Derived Address configuration.
+type PDA struct {
+	Seeds   []Seed `json:"seeds"`
+	Program *Seed  `json:"program,omitempty"`
+}
+
+// Seed represents a PDA seed.
+type Seed struct {
+	Kind    string `json:"kind"` // "const", "account", "arg"
+	Value   []byte `json:"value,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Account string `json:"account,omitempty"`
+	Type    *Type  `json:"type,omitempty"`
+}
+
+// AccountDef represents an account type definition.
+type AccountDef struct {
+	Name          string   `json:"name"`
+	Discriminator []byte   `json:"discriminator"`
+	Type          Type     `json:"type,omitempty"`
+	Docs          []string `json:"docs,omitempty"`
+}
+
+// Event represents a program event.
+type Event struct {
+	Name          string   `json:"name"`
+	Discriminator []byte   `json:"discriminator"`
+	Fields        []Field  `json:"fields,omitempty"`
+	Docs          []string `json:"docs,omitempty"`
+}
+
+// ErrorDef represents a program error.
+type ErrorDef struct {
+	Code int    `json:"code"`
+	Name string `json:"name"`
+	Msg  string `json:"msg,omitempty"`
+}
+
+// TypeDef represents a custom type definition.
+type TypeDef struct {
+	Name     string    `json:"name"`
+	Docs     []string  `json:"docs,omitempty"`
+	Type     Type      `json:"type"`
+	Generics []Generic `json:"generics,omitempty"`
+}
+
+// Generic represents a generic type parameter.
+type Generic struct {
+	Kind string `json:"kind"` // "type", "const"
+	Name string `json:"name"`
+	Type string `json:"type,omitempty"`
+}
+
+// Type represents a type in the IDL.
+// It can be a primitive type, a defined type, or a complex type (struct, enum, array, etc.)
+type Type struct {
+	// For primitive types, this is the type name directly
+	Kind string `json:"kind,omitempty"`
+
+	// For complex types
+	Defined *DefinedType `json:"defined,omitempty"`
+	Option  *Type        `json:"option,omitempty"`
+	Coption *Type        `json:"coption,omitempty"`
+	Vec     *Type        `json:"vec,omitempty"`
+	Array   *ArrayType   `json:"array,omitempty"`
+	Struct  *StructType  `json:"struct,omitempty"`
+	Enum    *EnumType    `json:"enum,omitempty"`
+	Tuple   []Type       `json:"tuple,omitempty"`
+
+	// Generic references a generic parameter of the enclosing type definition.
+	Generic string `json:"generic,omitempty"`
+
+	// Const is the value of a const generic argument, e.g. an array length.
+	Const string `json:"const,omitempty"`
+}
+
+// DefinedType references a defined type.
+type DefinedType struct {
+	Name     string `json:"name"`
+	Generics []Type `json:"generics,omitempty"`
+}
+
+// ArrayType represents a fixed-size array.
+type ArrayType struct {
+	Type Type `json:"type"`
+	Len  int  `json:"len"`
+
+	// LenGeneric is the const generic parameter giving the length, if any.
+	LenGeneric string `json:"len_generic,omitempty"`
+}
+
+// StructType represents a struct type.
+type StructType struct {
+	Fields []Field `json:"fields"`
+}
+
+// EnumType represents an enum type.
+type EnumType struct {
+	Variants []EnumVariant `json:"variants"`
+}
+
+// EnumVariant represents an enum variant.
+type EnumVariant struct {
+	Name   string  `json:"name"`
+	Fields []Field `json:"fields,omitempty"`
+}
+
+// Field represents a field in a struct, event, or instruction.
+type Field struct {
+	Name string   `json:"name"`
+	Type Type     `json:"type"`
+	Docs []string `json:"docs,omitempty"`
+}
+
+// Constant represents a constant definition.
+type Constant struct {
+	Name  string `json:"name"`
+	Type  Type   `json:"type"`
+	Value string `json:"value"`
+}
+
+// UnmarshalJSON implements custom unmarshaling for Type to support both old and new Anchor IDL formats.
+// Old format (Anchor v0.1.0): {"kind": "struct", "fields": [...]}
+// New format (Anchor v0.29+): {"struct": {"fields": [...]}}
+// Primitive types are plain strings ("u64"), arrays are [type, len] pairs and
+// generic parameters are referenced as {"generic": "T"}.
+func (t *Type) UnmarshalJSON(data []byte) error {
+	var primitive string
+	if err := json.Unmarshal(data, &primitive); err == nil {
+		t.Kind = primitive
+		return nil
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if kind, ok := raw["kind"].(string); ok {
+		t.Kind = kind
+
+		if kind == "struct" {
+			if fieldsRaw, ok := raw["fields"].([]interface{}); ok {
+				fieldsJSON, _ := json.Marshal(fieldsRaw)
+				var fields []Field
+				if err := json.Unmarshal(fieldsJSON, &fields); err != nil {
+					return err
+				}
+				t.Struct = &StructType{Fields: fields}
+			}
+		} else if kind == "enum" {
+			if variantsRaw, ok := raw["variants"].([]interface{}); ok {
+				variantsJSON, _ := json.Marshal(variantsRaw)
+				var variants []EnumVariant
+				if err := json.Unmarshal(variantsJSON, &variants); err != nil {
+					return err
+				}
+				t.Enum = &EnumType{Variants: variants}
+			}
+		} else if kind == "defined" {
+			// Handle defined type: {"kind": "defined", "name": "TypeName"}
+			if name, ok := raw["name"].(string); ok {
+				t.Defined = &DefinedType{Name: name}
+			}
+		}
+	}
+
+	if definedRaw, ok := raw["defined"]; ok {
+		if name, ok := definedRaw.(string); ok {
+			t.Defined = &DefinedType{Name: name}
+		} else {
+			definedJSON, _ := json.Marshal(definedRaw)
+			var defined DefinedType
+			if err := json.Unmarshal(definedJSON, &defined); err == nil {
+				t.Defined = &defined
+			}
+		}
+	}
+
+	if generic, ok := raw["generic"].(string); ok {
+		t.Generic = generic
+	}
+
+	if optionRaw, ok := raw["option"]; ok {
+		optionJSON, _ := json.Marshal(optionRaw)
+		var option Type
+		if err := json.Unmarshal(optionJSON, &option); err == nil {
+			t.Option = &option
+		}
+	}
+
+	if coptionRaw, ok := raw["coption"]; ok {
+		coptionJSON, _ := json.Marshal(coptionRaw)
+		var coption Type
+		if err := json.Unmarshal(coptionJSON, &coption); err == nil {
+			t.Coption = &coption
+		}
+	}
+
+	if vecRaw, ok := raw["vec"]; ok {
+		vecJSON, _ := json.Marshal(vecRaw)
+		var vec Type
+		if err := json.Unmarshal(vecJSON, &vec); err == nil {
+			t.Vec = &vec
+		}
+	}
+
+	if arrayRaw, ok := raw["array"]; ok {
+		arrayJSON, _ := json.Marshal(arrayRaw)
+		var array ArrayType
+		if err := array.UnmarshalJSON(arrayJSON); err == nil {
+			t.Array = &array
+		}
+	}
+
+	if structRaw, ok := raw["struct"]; ok {
+		structJSON, _ := json.Marshal(structRaw)
+		var structType StructType
+		if err := json.Unmarshal(structJSON, &structType); err == nil {
+			t.Struct = &structType
+		}
+	}
+
+	if enumRaw, ok := raw["enum"]; ok {
+		enumJSON, _ := json.Marshal(enumRaw)
+		var enumType EnumType
+		if err := json.Unmarshal(enumJSON, &enumType); err == nil {
+			t.Enum = &enumType
+		}
+	}
+
+	if tupleRaw, ok := raw["tuple"]; ok {
+		tupleJSON, _ := json.Marshal(tupleRaw)
+		var tuple []Type
+		if err := json.Unmarshal(tupleJSON, &tuple); err == nil {
+			t.Tuple = tuple
+		}
+	}
+
+	return nil
+}
+
+// UnmarshalJSON implements custom unmarshaling for ArrayType to support both
+// the [type, len] pair of Anchor IDLs, where len is a number or a const generic
+// {"generic": "N"}, and the {"type": ..., "len": ...} object form.
+func (a *ArrayType) UnmarshalJSON(data []byte) error {
+	var pair []json.RawMessage
+	if err := json.Unmarshal(data, &pair); err != nil {
+		type plain ArrayType
+		return json.Unmarshal(data, (*plain)(a))
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("array type must be a [type, len] pair, got %d elements", len(pair))
+	}
+
+	if err := json.Unmarshal(pair[0], &a.Type); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(pair[1], &a.Len); err == nil {
+		return nil
+	}
+	var generic struct {
+		Generic string `json:"generic"`
+	}
+	if err := json.Unmarshal(pair[1], &generic); err != nil || generic.Generic == "" {
+		return fmt.Errorf("invalid array length %s", pair[1])
+	}
+	a.LenGeneric = generic.Generic
+	return nil
+}
+
+// UnmarshalJSON implements custom unmarshaling for DefinedType, whose
+// generic arguments are {"kind": "type", "type": ...} or
+// {"kind": "const", "value": "..."} objects in Anchor IDLs.
+func (d *DefinedType) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Name     string            `json:"name"`
+		Generics []json.RawMessage `json:"generics"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	d.Name = raw.Name
+	d.Generics = nil
+	for _, genericRaw := range raw.Generics {
+		var arg struct {
+			Kind  string          `json:"kind"`
+			Type  json.RawMessage `json:"type"`
+			Value string          `json:"value"`
+		}
+		if err := json.Unmarshal(genericRaw, &arg); err != nil {
+			return err
+		}
+
+		var typ Type
+		switch {
+		case arg.Kind == "const":
+			typ.Const = arg.Value
+		case arg.Kind == "type" && len(arg.Type) > 0:
+			if err := json.Unmarshal(arg.Type, &typ); err != nil {
+				return err
+			}
+		default:
+			if err := json.Unmarshal(genericRaw, &typ); err != nil {
+				return err
+			}
+		}
+		d.Generics = append(d.Generics, typ)
+	}
+	return nil
+}
+
+// UnmarshalJSON implements custom unmarshaling for Field to support the
+// unnamed fields of tuple structs and enum variants, given as bare types.
+func (f *Field) UnmarshalJSON(data []byte) error {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err == nil {
+		_, hasName := keys["name"]
+		_, hasType := keys["type"]
+		if hasName && hasType {
+			type plain Field
+			return json.Unmarshal(data, (*plain)(f))
+		}
+	}
+
+	*f = Field{}
+	return json.Unmarshal(data, &f.Type)
+}
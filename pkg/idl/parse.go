@@ -0,0 +1,27 @@
+package idl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// ParseFile reads and parses the IDL JSON file at filePath.
+func ParseFile(filePath string) (*IDL, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IDL file: %w", err)
+	}
+
+	return Parse(data)
+}
+
+// Parse parses IDL JSON.
+func Parse(data []byte) (*IDL, error) {
+	var idl IDL
+	if err := json.Unmarshal(data, &idl); err != nil {
+		return nil, fmt.Errorf("failed to parse IDL JSON: %w", err)
+	}
+
+	return &idl, nil
+}
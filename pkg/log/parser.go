@@ -4,6 +4,7 @@
 //   - Extract "Program data:" messages from transaction logs
 //   - Filter logs by instruction path (nested instruction support)
 //   - Parse structured events from log messages
+//   - Detect logs truncated by the runtime
 //   - Register custom log processors
 //
 // Example usage:
@@ -40,8 +41,15 @@ const (
 	LogTypeLog
 	// LogTypeComputeUnits represents a compute units consumed message.
 	LogTypeComputeUnits
+	// LogTypeTruncated represents the "Log truncated" message the runtime
+	// writes when a transaction exceeds the log size limit.
+	LogTypeTruncated
 )
 
+// TruncatedLogMessage is the message the runtime writes in place of the log
+// messages exceeding the log size limit of a transaction (10KB by default).
+const TruncatedLogMessage = "Log truncated"
+
 // String returns the string representation of LogType.
 func (lt LogType) String() string {
 	switch lt {
@@ -57,6 +65,8 @@ func (lt LogType) String() string {
 		return "Log"
 	case LogTypeComputeUnits:
 		return "ComputeUnits"
+	case LogTypeTruncated:
+		return "Truncated"
 	default:
 		return "Unknown"
 	}
@@ -126,6 +136,11 @@ func (p *LogParser) Parse(logMessage string) *ParsedLog {
 		RawLog: logMessage,
 	}
 
+	if logMessage == TruncatedLogMessage {
+		result.Type = LogTypeTruncated
+		return result
+	}
+
 	// Try to match invoke pattern
 	if matches := p.patterns.invoke.FindStringSubmatch(logMessage); matches != nil {
 		result.Type = LogTypeInvoke
@@ -186,6 +201,41 @@ func (p *LogParser) ParseAll(logMessages []string) []*ParsedLog {
 	return results
 }
 
+// ParsedLogs is the result of parsing the log messages of a transaction.
+type ParsedLogs struct {
+	// Logs are the parsed log messages.
+	Logs []*ParsedLog
+
+	// Truncated reports whether the runtime truncated the log messages. Events
+	// logged after the truncation point are missing from Logs.
+	Truncated bool
+}
+
+// ParseLogs parses the log messages of a transaction and detects truncation.
+func (p *LogParser) ParseLogs(logMessages []string) *ParsedLogs {
+	result := &ParsedLogs{Logs: p.ParseAll(logMessages)}
+	for _, parsed := range result.Logs {
+		if parsed.Type == LogTypeTruncated {
+			result.Truncated = true
+			break
+		}
+	}
+	return result
+}
+
+// IsTruncated reports whether the runtime truncated logMessages, in which case
+// "Program data:" events after the truncation point are missing.
+func IsTruncated(logMessages []string) bool {
+	// The runtime stops logging once it writes the truncation message, so it
+	// is almost always the last message.
+	for i := len(logMessages) - 1; i >= 0; i-- {
+		if logMessages[i] == TruncatedLogMessage {
+			return true
+		}
+	}
+	return false
+}
+
 // ExtractProgramData extracts all "Program data:" messages and returns decoded data.
 func (p *LogParser) ExtractProgramData(logMessages []string) [][]byte {
 	var data [][]byte
@@ -0,0 +1,46 @@
+package log
+
+import "testing"
+
+func TestParseLogsDetectsTruncation(t *testing.T) {
+	logs := []string{
+		"Program " + programA + " invoke [1]",
+		"Program data: AQID",
+		TruncatedLogMessage,
+	}
+
+	parsed := NewParser().ParseLogs(logs)
+	if !parsed.Truncated {
+		t.Error("expected logs to be reported truncated")
+	}
+	if len(parsed.Logs) != 3 || parsed.Logs[2].Type != LogTypeTruncated {
+		t.Errorf("unexpected parsed logs: %+v", parsed.Logs)
+	}
+	if data := parsed.Logs[1].Data; len(data) != 3 || data[2] != 3 {
+		t.Errorf("unexpected program data: %v", data)
+	}
+
+	if NewParser().ParseLogs(logs[:2]).Truncated {
+		t.Error("expected complete logs not to be reported truncated")
+	}
+}
+
+func TestIsTruncated(t *testing.T) {
+	tests := []struct {
+		name string
+		logs []string
+		want bool
+	}{
+		{"empty", nil, false},
+		{"complete", []string{"Program " + programA + " invoke [1]", "Program " + programA + " success"}, false},
+		{"truncated last", []string{"Program " + programA + " invoke [1]", TruncatedLogMessage}, true},
+		{"truncated earlier", []string{TruncatedLogMessage, "Program " + programA + " success"}, true},
+		{"similar message", []string{"Program log: Log truncated"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := IsTruncated(tt.logs); got != tt.want {
+			t.Errorf("%s: IsTruncated() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package log
+
+// LogEntry is a log message visited by Walk, attributed to the program
+// invocation that emitted it.
+type LogEntry struct {
+	// Log is the parsed log message.
+	Log *ParsedLog
+
+	// ProgramID is the program executing when the message was logged. For
+	// invoke, success and failed messages it is the program they name.
+	ProgramID string
+
+	// StackHeight is the call stack depth of ProgramID (1 for top-level
+	// instructions), or 0 outside of any invocation.
+	StackHeight int
+
+	// Path is the instruction path of the invocation that emitted the message.
+	Path InstructionPath
+
+	// Index is the position of the message in the transaction logs.
+	Index int
+}
+
+// frame is an invocation on the call stack tracked by Walk.
+type frame struct {
+	programID string
+	path      InstructionPath
+	children  uint8
+}
+
+// Walk parses logMessages and calls visit for each message with the program
+// invocation that emitted it.
+//
+// Walk tracks the call stack from "Program X invoke [N]" and "Program X
+// success/failed" messages. The stack height of invoke messages resynchronizes
+// the stack, so a missing success or failed message, as in failed or truncated
+// transactions, does not misattribute later messages.
+//
+// Top-level instructions are numbered by counting their invoke messages.
+// Instructions that log no invoke message, such as those of the Ed25519 and
+// Secp256k1 precompiles, therefore shift the paths of the instructions after
+// them. Use WalkAligned when the instructions of the message are known.
+func (p *LogParser) Walk(logMessages []string, visit func(entry LogEntry)) {
+	p.WalkAligned(logMessages, nil, visit)
+}
+
+// WalkAligned is like Walk, but numbers top-level instructions by their
+// position in topLevelPrograms, the program IDs of the transaction message's
+// instructions: each top-level invoke is matched to the next instruction of
+// its program. Instructions without invoke messages then do not shift paths.
+func (p *LogParser) WalkAligned(logMessages []string, topLevelPrograms []string, visit func(entry LogEntry)) {
+	var stack []frame
+	var topLevel int
+
+	for i, message := range logMessages {
+		parsed := p.Parse(message)
+
+		switch parsed.Type {
+		case LogTypeInvoke:
+			height := parsed.StackHeight
+			if height < 1 {
+				height = 1
+			}
+			if height-1 < len(stack) {
+				stack = stack[:height-1]
+			}
+
+			var path InstructionPath
+			if len(stack) == 0 {
+				for j := topLevel; j < len(topLevelPrograms); j++ {
+					if topLevelPrograms[j] == parsed.ProgramID {
+						topLevel = j
+						break
+					}
+				}
+				path = InstructionPath{uint8(topLevel)}
+				topLevel++
+			} else {
+				parent := &stack[len(stack)-1]
+				path = make(InstructionPath, len(parent.path), len(parent.path)+1)
+				copy(path, parent.path)
+				path = append(path, parent.children)
+				parent.children++
+			}
+			stack = append(stack, frame{programID: parsed.ProgramID, path: path})
+
+			visit(LogEntry{Log: parsed, ProgramID: parsed.ProgramID, StackHeight: len(stack), Path: path, Index: i})
+
+		case LogTypeSuccess, LogTypeFailed:
+			entry := LogEntry{Log: parsed, ProgramID: parsed.ProgramID, Index: i}
+			if len(stack) > 0 {
+				top := stack[len(stack)-1]
+				entry.StackHeight, entry.Path = len(stack), top.path
+				stack = stack[:len(stack)-1]
+			}
+			visit(entry)
+
+		default:
+			entry := LogEntry{Log: parsed, Index: i}
+			if len(stack) > 0 {
+				top := stack[len(stack)-1]
+				entry.ProgramID, entry.StackHeight, entry.Path = top.programID, len(stack), top.path
+			}
+			visit(entry)
+		}
+	}
+}
+
+// ProgramData is a "Program data:" payload attributed to the program that
+// emitted it. decoder.ExtractCPIEvents returns the payloads of Anchor's
+// emit_cpi! events in the same form.
+type ProgramData struct {
+	// ProgramID is the program that emitted the payload.
+	ProgramID string
+
+	// StackHeight is the call stack depth of ProgramID (1 for top-level instructions).
+	StackHeight int
+
+	// Path is the instruction path of the invocation that emitted the payload.
+	Path InstructionPath
+
+	// LogIndex is the position of the "Program data:" message in the transaction
+	// logs, or -1 if the payload was not logged.
+	LogIndex int
+
+	// InnerIndex is, for emit_cpi! payloads, the position of the self-invoked
+	// instruction carrying the payload among the instructions invoked by Path.
+	InnerIndex int
+
+	// Data is the decoded payload.
+	Data []byte
+}
+
+// ExtractAttributedProgramData extracts all "Program data:" payloads with the
+// program, stack height and instruction path that emitted them.
+//
+// Unlike ExtractProgramData, the result lets decoders be selected by program
+// instead of trying every decoder on every payload. Pass the program IDs of
+// the message's top-level instructions, if known, to align paths as
+// WalkAligned does.
+func (p *LogParser) ExtractAttributedProgramData(logMessages []string, topLevelPrograms ...string) []ProgramData {
+	var data []ProgramData
+	p.WalkAligned(logMessages, topLevelPrograms, func(entry LogEntry) {
+		if entry.Log.Type != LogTypeData || len(entry.Log.Data) == 0 || entry.ProgramID == "" {
+			return
+		}
+		data = append(data, ProgramData{
+			ProgramID:   entry.ProgramID,
+			StackHeight: entry.StackHeight,
+			Path:        entry.Path,
+			LogIndex:    entry.Index,
+			Data:        entry.Log.Data,
+		})
+	})
+	return data
+}
@@ -0,0 +1,132 @@
+package log
+
+import "testing"
+
+const (
+	programA   = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"
+	programB   = "BBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB"
+	precompile = "Ed25519SigVerify111111111111111111111111111"
+)
+
+// walkedEntry is the attribution of a visited message.
+type walkedEntry struct {
+	programID   string
+	stackHeight int
+	path        string
+}
+
+func walk(logs []string, topLevelPrograms []string) []walkedEntry {
+	var entries []walkedEntry
+	NewParser().WalkAligned(logs, topLevelPrograms, func(entry LogEntry) {
+		entries = append(entries, walkedEntry{entry.ProgramID, entry.StackHeight, entry.Path.String()})
+	})
+	return entries
+}
+
+func checkWalk(t *testing.T, got, want []walkedEntry) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("visited %d messages, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("message %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWalkNestedInvokes(t *testing.T) {
+	logs := []string{
+		"Program " + programA + " invoke [1]",
+		"Program " + programB + " invoke [2]",
+		"Program log: inner",
+		"Program " + programB + " success",
+		"Program " + programB + " invoke [2]",
+		"Program " + programB + " success",
+		"Program log: outer",
+		"Program " + programA + " success",
+		"Program " + programB + " invoke [1]",
+		"Program " + programB + " success",
+	}
+
+	checkWalk(t, walk(logs, nil), []walkedEntry{
+		{programA, 1, "[0]"},
+		{programB, 2, "[0, 0]"},
+		{programB, 2, "[0, 0]"},
+		{programB, 2, "[0, 0]"},
+		{programB, 2, "[0, 1]"},
+		{programB, 2, "[0, 1]"},
+		{programA, 1, "[0]"},
+		{programA, 1, "[0]"},
+		{programB, 1, "[1]"},
+		{programB, 1, "[1]"},
+	})
+}
+
+func TestWalkResynchronizesOnMissingSuccess(t *testing.T) {
+	// The inner invocation of B never reports success or failure.
+	logs := []string{
+		"Program " + programA + " invoke [1]",
+		"Program " + programB + " invoke [2]",
+		"Program " + programB + " invoke [2]",
+		"Program log: second",
+		"Program " + programB + " success",
+		"Program " + programA + " success",
+		"Program " + programA + " invoke [1]",
+		"Program log: next",
+	}
+
+	checkWalk(t, walk(logs, nil), []walkedEntry{
+		{programA, 1, "[0]"},
+		{programB, 2, "[0, 0]"},
+		{programB, 2, "[0, 1]"},
+		{programB, 2, "[0, 1]"},
+		{programB, 2, "[0, 1]"},
+		{programA, 1, "[0]"},
+		{programA, 1, "[1]"},
+		{programA, 1, "[1]"},
+	})
+}
+
+func TestWalkDataOutsideFrames(t *testing.T) {
+	logs := []string{
+		"Program data: AQID",
+		"Program " + programA + " invoke [1]",
+		"Program " + programA + " success",
+		"Program log: after",
+	}
+
+	checkWalk(t, walk(logs, nil), []walkedEntry{
+		{"", 0, "[]"},
+		{programA, 1, "[0]"},
+		{programA, 1, "[0]"},
+		{"", 0, "[]"},
+	})
+
+	if data := NewParser().ExtractAttributedProgramData(logs); len(data) != 0 {
+		t.Errorf("expected payloads outside frames to be skipped, got %+v", data)
+	}
+}
+
+func TestWalkAlignedSkipsSilentInstructions(t *testing.T) {
+	// The precompile at index 0 logs no invoke message.
+	logs := []string{
+		"Program " + programA + " invoke [1]",
+		"Program data: AQID",
+		"Program " + programA + " success",
+		"Program " + programB + " invoke [1]",
+		"Program " + programB + " success",
+	}
+
+	if data := NewParser().ExtractAttributedProgramData(logs); data[0].Path.String() != "[0]" {
+		t.Errorf("unaligned path = %s, want [0]", data[0].Path)
+	}
+
+	checkWalk(t, walk(logs, []string{precompile, programA, precompile, programB}), []walkedEntry{
+		{programA, 1, "[1]"},
+		{programA, 1, "[1]"},
+		{programA, 1, "[1]"},
+		{programB, 1, "[3]"},
+		{programB, 1, "[3]"},
+	})
+}
@@ -0,0 +1,94 @@
+package external
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lugondev/go-carbon/pkg/plugin/external/pluginv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// maxMessageSize is the maximum size of a protocol message.
+const maxMessageSize = 16 << 20
+
+// ErrNotRunning is returned by calls to a plugin whose process is not running.
+var ErrNotRunning = errors.New("plugin process is not running")
+
+// conn is the gRPC connection to a plugin process.
+type conn struct {
+	cc     *grpc.ClientConn
+	plugin pluginv1.PluginClient
+	health healthpb.HealthClient
+	exited <-chan struct{}
+}
+
+// dial connects to the plugin server announced by h. exited is closed when the
+// plugin process exits.
+func dial(h handshake, exited <-chan struct{}) (*conn, error) {
+	cc, err := grpc.NewClient("passthrough:///"+h.address,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(maxMessageSize),
+			grpc.MaxCallSendMsgSize(maxMessageSize),
+		),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to plugin at %s: %w", h.address, err)
+	}
+
+	return &conn{
+		cc:     cc,
+		plugin: pluginv1.NewPluginClient(cc),
+		health: healthpb.NewHealthClient(cc),
+		exited: exited,
+	}, nil
+}
+
+// call runs fn, the call of method, and converts its error. Calls failing
+// because the process exited or is unreachable fail with ErrNotRunning.
+func (c *conn) call(ctx context.Context, method string, fn func(client pluginv1.PluginClient) error) error {
+	err := fn(c.plugin)
+	if err == nil {
+		return nil
+	}
+
+	select {
+	case <-c.exited:
+		return ErrNotRunning
+	default:
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return fmt.Errorf("%s: %w", method, ctxErr)
+	}
+
+	s, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	if s.Code() == codes.Unavailable {
+		return fmt.Errorf("%s: %w", method, ErrNotRunning)
+	}
+	return fmt.Errorf("%s: %s", method, s.Message())
+}
+
+// checkHealth reports an error unless the plugin reports it is serving.
+func (c *conn) checkHealth(ctx context.Context) error {
+	resp, err := c.health.Check(ctx, &healthpb.HealthCheckRequest{Service: HealthServiceName})
+	if err != nil {
+		return c.call(ctx, "health", func(pluginv1.PluginClient) error { return err })
+	}
+	if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("health: plugin is %s", resp.GetStatus())
+	}
+	return nil
+}
+
+// close closes the connection.
+func (c *conn) close() {
+	_ = c.cc.Close()
+}
@@ -0,0 +1,133 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/pkg/decoder"
+	"github.com/lugondev/go-carbon/pkg/plugin/external/pluginv1"
+)
+
+// remoteDecoder is a decoder provided by an external plugin.
+type remoteDecoder struct {
+	plugin    *Plugin
+	spec      DecoderSpec
+	programID solana.PublicKey
+}
+
+var _ decoder.DiscriminatedDecoder = (*remoteDecoder)(nil)
+
+// newRemoteDecoder returns the decoder of p described by spec.
+func newRemoteDecoder(p *Plugin, spec DecoderSpec) (*remoteDecoder, error) {
+	d := &remoteDecoder{plugin: p, spec: spec}
+	if spec.ProgramID != "" {
+		programID, err := solana.PublicKeyFromBase58(spec.ProgramID)
+		if err != nil {
+			return nil, fmt.Errorf("decoder %s has invalid program ID: %w", spec.Name, err)
+		}
+		d.programID = programID
+	}
+	return d, nil
+}
+
+// Decode implements decoder.Decoder. The event data is the decoded JSON object
+// as a map[string]any. Data the plugin declines to decode is an error, since
+// CanDecode accepted it. The plugin receives the data without the Anchor event
+// instruction tag, like CanDecode matches it.
+func (d *remoteDecoder) Decode(data []byte) (*decoder.Event, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), d.plugin.callTimeout)
+	defer cancel()
+
+	var resp *pluginv1.DecodeResponse
+	req := &pluginv1.DecodeRequest{Decoder: d.spec.Name, Data: decoder.StripEventIxTag(data)}
+	err := d.plugin.call(ctx, "decode", func(client pluginv1.PluginClient) (err error) {
+		resp, err = client.Decode(ctx, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := resp.GetEvent()
+	if result == nil {
+		return nil, fmt.Errorf("decoder %s declined data", d.spec.Name)
+	}
+
+	event := &decoder.Event{
+		Name:          result.GetName(),
+		RawData:       data,
+		ProgramID:     d.programID,
+		Discriminator: d.spec.Discriminator,
+	}
+	if result.GetProgramId() != "" {
+		programID, err := solana.PublicKeyFromBase58(result.GetProgramId())
+		if err != nil {
+			return nil, fmt.Errorf("event %s has invalid program ID: %w", result.GetName(), err)
+		}
+		event.ProgramID = programID
+	}
+	if len(result.GetData()) > 0 {
+		var fields map[string]any
+		if err := json.Unmarshal(result.GetData(), &fields); err != nil {
+			return nil, fmt.Errorf("event %s has invalid data: %w", result.GetName(), err)
+		}
+		event.Data = fields
+	}
+	return event, nil
+}
+
+// CanDecode implements decoder.Decoder. Decoders with a discriminator are
+// matched locally, like other Anchor decoders; the others ask the plugin.
+func (d *remoteDecoder) CanDecode(data []byte) bool {
+	data = decoder.StripEventIxTag(data)
+	if len(d.spec.Discriminator) > 0 {
+		return bytes.HasPrefix(data, d.spec.Discriminator)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.plugin.callTimeout)
+	defer cancel()
+
+	var resp *pluginv1.CanDecodeResponse
+	req := &pluginv1.DecodeRequest{Decoder: d.spec.Name, Data: data}
+	err := d.plugin.call(ctx, "can_decode", func(client pluginv1.PluginClient) (err error) {
+		resp, err = client.CanDecode(ctx, req)
+		return err
+	})
+	return err == nil && resp.GetCanDecode()
+}
+
+// GetName implements decoder.Decoder.
+func (d *remoteDecoder) GetName() string {
+	return d.spec.Name
+}
+
+// GetProgramID implements decoder.Decoder.
+func (d *remoteDecoder) GetProgramID() solana.PublicKey {
+	return d.programID
+}
+
+// Discriminator implements decoder.DiscriminatedDecoder.
+func (d *remoteDecoder) Discriminator() []byte {
+	return d.spec.Discriminator
+}
+
+// newEventMessage encodes event for the ProcessEvent RPC.
+func newEventMessage(event *decoder.Event) (*pluginv1.Event, error) {
+	msg := &pluginv1.Event{
+		Name:    event.Name,
+		RawData: event.RawData,
+	}
+	if !event.ProgramID.IsZero() {
+		msg.ProgramId = event.ProgramID.String()
+	}
+	if event.Data != nil {
+		raw, err := json.Marshal(event.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode event %s: %w", event.Name, err)
+		}
+		msg.Data = raw
+	}
+	return msg, nil
+}
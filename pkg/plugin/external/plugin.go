@@ -0,0 +1,559 @@
+package external
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lugondev/go-carbon/pkg/decoder"
+	"github.com/lugondev/go-carbon/pkg/log"
+	"github.com/lugondev/go-carbon/pkg/plugin"
+	"github.com/lugondev/go-carbon/pkg/plugin/external/pluginv1"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Defaults of a Plugin.
+const (
+	DefaultCallTimeout         = 5 * time.Second
+	DefaultHealthCheckInterval = 10 * time.Second
+	DefaultHealthCheckTimeout  = 2 * time.Second
+	DefaultMaxRestarts         = 3
+	DefaultRestartBackoff      = time.Second
+	DefaultStableInterval      = time.Minute
+	DefaultShutdownTimeout     = 5 * time.Second
+)
+
+var _ plugin.FullPlugin = (*Plugin)(nil)
+
+// Plugin runs an external plugin executable and exposes it as a plugin.Plugin,
+// plugin.DecoderPlugin and plugin.EventProcessorPlugin.
+//
+// Start launches the executable and reads its Manifest, so it must be called
+// before registering the plugin:
+//
+//	p := external.NewPlugin("./plugins/my-plugin").WithLogger(logger)
+//	if err := p.Start(ctx); err != nil {
+//	    return err
+//	}
+//	registry.MustRegister(p)
+//	registry.Initialize(ctx)
+//
+// While running, the plugin is health-checked periodically. If the process
+// exits or fails a health check, it is restarted, up to a maximum number of
+// consecutive restarts; a process that stays up for the stable interval resets
+// the count. Calls made while the process is down fail with ErrNotRunning.
+type Plugin struct {
+	command string
+	args    []string
+	env     []string
+	logger  *slog.Logger
+
+	callTimeout         time.Duration
+	healthCheckInterval time.Duration
+	healthCheckTimeout  time.Duration
+	maxRestarts         int
+	restartBackoff      time.Duration
+	stableInterval      time.Duration
+	shutdownTimeout     time.Duration
+
+	manifest Manifest
+	decoders []decoder.Decoder
+
+	mu          sync.RWMutex
+	proc        *process
+	startedAt   time.Time
+	initialized bool
+	restarts    int
+	crashes     int
+	stopping    bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewPlugin creates a Plugin running command with args.
+func NewPlugin(command string, args ...string) *Plugin {
+	return &Plugin{
+		command:             command,
+		args:                args,
+		logger:              slog.Default(),
+		callTimeout:         DefaultCallTimeout,
+		healthCheckInterval: DefaultHealthCheckInterval,
+		healthCheckTimeout:  DefaultHealthCheckTimeout,
+		maxRestarts:         DefaultMaxRestarts,
+		restartBackoff:      DefaultRestartBackoff,
+		stableInterval:      DefaultStableInterval,
+		shutdownTimeout:     DefaultShutdownTimeout,
+	}
+}
+
+// WithLogger sets the logger, which also receives the plugin's stderr.
+func (p *Plugin) WithLogger(logger *slog.Logger) *Plugin {
+	p.logger = logger
+	return p
+}
+
+// WithEnv adds environment variables, in "KEY=value" form, to the plugin's
+// environment.
+func (p *Plugin) WithEnv(env ...string) *Plugin {
+	p.env = append(p.env, env...)
+	return p
+}
+
+// WithCallTimeout sets the timeout of decode and process_event calls.
+func (p *Plugin) WithCallTimeout(timeout time.Duration) *Plugin {
+	p.callTimeout = timeout
+	return p
+}
+
+// WithHealthCheck sets how often the plugin is health-checked and how long it
+// has to answer. A zero interval disables health checks.
+func (p *Plugin) WithHealthCheck(interval, timeout time.Duration) *Plugin {
+	p.healthCheckInterval = interval
+	p.healthCheckTimeout = timeout
+	return p
+}
+
+// WithRestartPolicy sets the maximum number of consecutive restarts after
+// crashes and the delay before each restart. Zero maxRestarts disables
+// restarts.
+func (p *Plugin) WithRestartPolicy(maxRestarts int, backoff time.Duration) *Plugin {
+	p.maxRestarts = maxRestarts
+	p.restartBackoff = backoff
+	return p
+}
+
+// WithStableInterval sets how long the process must run after a restart for
+// its crash count to be reset.
+func (p *Plugin) WithStableInterval(interval time.Duration) *Plugin {
+	p.stableInterval = interval
+	return p
+}
+
+// Start launches the plugin executable, reads its Manifest and starts
+// supervising the process.
+func (p *Plugin) Start(ctx context.Context) error {
+	if p.stop != nil {
+		return fmt.Errorf("plugin %s already started", p.command)
+	}
+
+	proc, manifest, err := p.launch(ctx)
+	if err != nil {
+		return err
+	}
+
+	p.manifest = manifest
+	p.decoders = make([]decoder.Decoder, 0, len(manifest.Decoders))
+	for _, spec := range manifest.Decoders {
+		d, err := newRemoteDecoder(p, spec)
+		if err != nil {
+			proc.kill()
+			return err
+		}
+		p.decoders = append(p.decoders, d)
+	}
+
+	p.proc = proc
+	p.startedAt = time.Now()
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go p.supervise()
+
+	return nil
+}
+
+// Manifest returns the Manifest reported by the plugin.
+func (p *Plugin) Manifest() Manifest {
+	return p.manifest
+}
+
+// Restarts returns the number of times the plugin process was restarted.
+func (p *Plugin) Restarts() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.restarts
+}
+
+// Name implements plugin.Plugin. Before Start, it returns the executable name.
+func (p *Plugin) Name() string {
+	if p.manifest.Name == "" {
+		return filepath.Base(p.command)
+	}
+	return p.manifest.Name
+}
+
+// Version implements plugin.Plugin.
+func (p *Plugin) Version() string {
+	return p.manifest.Version
+}
+
+// Description implements plugin.Plugin.
+func (p *Plugin) Description() string {
+	return p.manifest.Description
+}
+
+// Initialize implements plugin.Plugin.
+func (p *Plugin) Initialize(ctx context.Context) error {
+	if err := p.call(ctx, "initialize", initialize(ctx)); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	p.initialized = true
+	p.mu.Unlock()
+	return nil
+}
+
+// Shutdown implements plugin.Plugin. It asks the plugin to shut down and waits
+// for the process to exit, killing it if it does not exit in time.
+func (p *Plugin) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if p.stop == nil || p.stopping {
+		p.mu.Unlock()
+		return nil
+	}
+	p.stopping = true
+	p.mu.Unlock()
+
+	close(p.stop)
+	<-p.done
+
+	// The supervisor has exited, so p.proc is no longer replaced.
+	p.mu.RLock()
+	proc := p.proc
+	p.mu.RUnlock()
+	if proc == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.shutdownTimeout)
+	defer cancel()
+
+	err := proc.conn.call(ctx, "shutdown", func(client pluginv1.PluginClient) error {
+		_, err := client.Shutdown(ctx, &emptypb.Empty{})
+		return err
+	})
+	if errors.Is(err, ErrNotRunning) {
+		err = nil
+	}
+	proc.stdin.Close()
+
+	select {
+	case <-proc.exited:
+	case <-ctx.Done():
+		proc.kill()
+		<-proc.exited
+	}
+
+	if err != nil {
+		return fmt.Errorf("plugin %s shutdown failed: %w", p.Name(), err)
+	}
+	return nil
+}
+
+// GetDecoders implements plugin.DecoderPlugin.
+func (p *Plugin) GetDecoders() []decoder.Decoder {
+	return p.decoders
+}
+
+// GetLogProcessors implements plugin.DecoderPlugin.
+func (p *Plugin) GetLogProcessors() []log.LogProcessor {
+	return nil
+}
+
+// ProcessEvent implements plugin.EventProcessorPlugin. Event data is sent to
+// the plugin encoded as JSON.
+func (p *Plugin) ProcessEvent(ctx context.Context, event *decoder.Event) (bool, error) {
+	if !p.manifest.ProcessesEvents {
+		return false, nil
+	}
+
+	msg, err := newEventMessage(event)
+	if err != nil {
+		return false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.callTimeout)
+	defer cancel()
+
+	var resp *pluginv1.ProcessEventResponse
+	err = p.call(ctx, "process_event", func(client pluginv1.PluginClient) (err error) {
+		resp, err = client.ProcessEvent(ctx, msg)
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return resp.GetHandled(), nil
+}
+
+// GetEventTypes implements plugin.EventProcessorPlugin.
+func (p *Plugin) GetEventTypes() []string {
+	return p.manifest.EventTypes
+}
+
+// call calls method, run by fn, on the running plugin process.
+func (p *Plugin) call(ctx context.Context, method string, fn func(client pluginv1.PluginClient) error) error {
+	p.mu.RLock()
+	proc := p.proc
+	p.mu.RUnlock()
+
+	if proc == nil {
+		return fmt.Errorf("plugin %s: %w", p.Name(), ErrNotRunning)
+	}
+	if err := proc.conn.call(ctx, method, fn); err != nil {
+		return fmt.Errorf("plugin %s: %w", p.Name(), err)
+	}
+	return nil
+}
+
+// initialize returns the call of the Initialize RPC.
+func initialize(ctx context.Context) func(client pluginv1.PluginClient) error {
+	return func(client pluginv1.PluginClient) error {
+		_, err := client.Initialize(ctx, &emptypb.Empty{})
+		return err
+	}
+}
+
+// launch starts the plugin executable, performs the handshake and reads the
+// plugin's Manifest.
+func (p *Plugin) launch(ctx context.Context) (*process, Manifest, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.callTimeout)
+	defer cancel()
+
+	proc, err := startProcess(ctx, p.command, p.args, p.env, p.logger)
+	if err != nil {
+		return nil, Manifest{}, err
+	}
+
+	var resp *pluginv1.Manifest
+	err = proc.conn.call(ctx, "get_manifest", func(client pluginv1.PluginClient) (err error) {
+		resp, err = client.GetManifest(ctx, &emptypb.Empty{})
+		return err
+	})
+	if err != nil {
+		proc.kill()
+		return nil, Manifest{}, fmt.Errorf("plugin %s handshake failed: %w", p.command, err)
+	}
+	manifest := manifestFromProto(resp)
+	if manifest.Name == "" {
+		proc.kill()
+		return nil, Manifest{}, fmt.Errorf("plugin %s reported no name", p.command)
+	}
+
+	return proc, manifest, nil
+}
+
+// supervise health-checks the plugin process and restarts it when it exits,
+// until Shutdown is called.
+func (p *Plugin) supervise() {
+	defer close(p.done)
+
+	var healthChecks <-chan time.Time
+	if p.healthCheckInterval > 0 {
+		ticker := time.NewTicker(p.healthCheckInterval)
+		defer ticker.Stop()
+		healthChecks = ticker.C
+	}
+
+	for {
+		p.mu.RLock()
+		proc := p.proc
+		p.mu.RUnlock()
+
+		select {
+		case <-p.stop:
+			return
+
+		case <-healthChecks:
+			ctx, cancel := context.WithTimeout(context.Background(), p.healthCheckTimeout)
+			err := proc.conn.checkHealth(ctx)
+			cancel()
+			if err != nil {
+				p.logger.Warn("plugin failed health check, killing it",
+					"plugin", p.Name(),
+					"error", err,
+				)
+				proc.kill()
+			}
+
+		case <-proc.exited:
+			p.logger.Warn("plugin process exited", "plugin", p.Name(), "error", proc.err)
+			if !p.restart() {
+				return
+			}
+		}
+	}
+}
+
+// restart relaunches the plugin process after a crash, reinitializing it if it
+// was initialized. It reports false if the plugin is stopping or exceeded its
+// restarts.
+func (p *Plugin) restart() bool {
+	p.mu.Lock()
+	if time.Since(p.startedAt) >= p.stableInterval {
+		p.crashes = 0
+	}
+	p.mu.Unlock()
+
+	for {
+		p.mu.Lock()
+		if p.crashes >= p.maxRestarts {
+			p.proc = nil
+			p.mu.Unlock()
+			p.logger.Error("plugin exceeded its restarts, giving up",
+				"plugin", p.Name(),
+				"restarts", p.crashes,
+			)
+			return false
+		}
+		p.crashes++
+		initialized := p.initialized
+		p.mu.Unlock()
+
+		select {
+		case <-p.stop:
+			return false
+		case <-time.After(p.restartBackoff):
+		}
+
+		proc, _, err := p.launch(context.Background())
+		if err == nil && initialized {
+			ctx, cancel := context.WithTimeout(context.Background(), p.callTimeout)
+			err = proc.conn.call(ctx, "initialize", initialize(ctx))
+			cancel()
+			if err != nil {
+				proc.kill()
+			}
+		}
+		if err != nil {
+			p.logger.Warn("failed to restart plugin", "plugin", p.Name(), "error", err)
+			continue
+		}
+
+		p.mu.Lock()
+		if p.stopping {
+			// Shutdown started during the launch and will not see proc.
+			p.mu.Unlock()
+			proc.kill()
+			return false
+		}
+		p.proc = proc
+		p.startedAt = time.Now()
+		p.restarts++
+		p.mu.Unlock()
+
+		p.logger.Info("plugin restarted", "plugin", p.Name(), "restarts", p.Restarts())
+		return true
+	}
+}
+
+// process is a running plugin executable.
+type process struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	conn   *conn
+	exited chan struct{}
+	err    error // exit error, set before exited is closed
+}
+
+// startProcess starts command with the magic cookie in its environment, reads
+// its handshake line and connects to it.
+func startProcess(ctx context.Context, command string, args, env []string, logger *slog.Logger) (*process, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = append(os.Environ(), MagicCookieKey+"="+MagicCookieValue)
+	cmd.Env = append(cmd.Env, env...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plugin stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plugin stdout: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create plugin stderr: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start plugin %s: %w", command, err)
+	}
+
+	proc := &process{
+		cmd:    cmd,
+		stdin:  stdin,
+		exited: make(chan struct{}),
+	}
+
+	name := filepath.Base(command)
+	handshakes := make(chan string, 1)
+
+	var output sync.WaitGroup
+	output.Add(2)
+	go func() {
+		defer output.Done()
+		scanner := bufio.NewScanner(stdout)
+		if scanner.Scan() {
+			handshakes <- scanner.Text()
+		}
+		for scanner.Scan() {
+			logger.Info(scanner.Text(), "plugin", name)
+		}
+	}()
+	go func() {
+		defer output.Done()
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			logger.Info(scanner.Text(), "plugin", name)
+		}
+	}()
+
+	go func() {
+		// Wait closes the pipes, so read them to the end first.
+		output.Wait()
+		proc.err = cmd.Wait()
+		close(proc.exited)
+	}()
+
+	var line string
+	select {
+	case line = <-handshakes:
+	case <-proc.exited:
+		return nil, fmt.Errorf("plugin %s exited before its handshake: %v", command, proc.err)
+	case <-ctx.Done():
+		proc.kill()
+		return nil, fmt.Errorf("plugin %s handshake failed: %w", command, ctx.Err())
+	}
+
+	h, err := parseHandshake(line)
+	if err != nil {
+		proc.kill()
+		return nil, fmt.Errorf("plugin %s handshake failed: %w", command, err)
+	}
+	proc.conn, err = dial(h, proc.exited)
+	if err != nil {
+		proc.kill()
+		return nil, err
+	}
+	go func() {
+		<-proc.exited
+		proc.conn.close()
+	}()
+
+	return proc, nil
+}
+
+// kill kills the process.
+func (p *process) kill() {
+	_ = p.cmd.Process.Kill()
+}
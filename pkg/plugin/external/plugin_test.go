@@ -0,0 +1,208 @@
+package external
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lugondev/go-carbon/pkg/decoder"
+	"github.com/lugondev/go-carbon/pkg/plugin"
+)
+
+// helperEnv makes the test binary serve testServer instead of running the
+// tests, so the tests can launch it as an external plugin.
+const helperEnv = "CARBON_EXTERNAL_TEST_PLUGIN"
+
+var counterDiscriminator = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+// Values making the test plugin exit, or decline to decode, while decoding.
+const (
+	crashValue   = 0xdead
+	declineValue = 0xbeef
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv(helperEnv) != "" {
+		if err := Serve(testServer()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// testServer decodes "Counter" events, a u64 after counterDiscriminator.
+func testServer() *Server {
+	return &Server{
+		Manifest: Manifest{
+			Name:    "counter",
+			Version: "1.0.0",
+			Decoders: []DecoderSpec{{
+				Name:          "Counter",
+				ProgramID:     "11111111111111111111111111111111",
+				Discriminator: counterDiscriminator,
+			}},
+			EventTypes: []string{"Counter"},
+		},
+		Decode: func(_ string, data []byte) (*DecodedEvent, error) {
+			if len(data) < 16 {
+				return nil, errors.New("data too short")
+			}
+			value := binary.LittleEndian.Uint64(data[8:])
+			switch value {
+			case crashValue:
+				os.Exit(2)
+			case declineValue:
+				return nil, nil
+			}
+			return NewDecodedEvent("Counter", map[string]uint64{"value": value})
+		},
+		ProcessEvent: func(_ context.Context, event *EventMessage) (bool, error) {
+			return event.Name == "Counter", nil
+		},
+	}
+}
+
+func counterData(value uint64) []byte {
+	return binary.LittleEndian.AppendUint64(append([]byte{}, counterDiscriminator...), value)
+}
+
+func startTestPlugin(t *testing.T) *Plugin {
+	t.Helper()
+
+	p := NewPlugin(os.Args[0]).
+		WithEnv(helperEnv+"=1").
+		WithHealthCheck(50*time.Millisecond, time.Second).
+		WithRestartPolicy(1, 10*time.Millisecond)
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { _ = p.Shutdown(context.Background()) })
+	return p
+}
+
+func TestPluginDecodesAndProcessesEvents(t *testing.T) {
+	ctx := context.Background()
+	p := startTestPlugin(t)
+
+	registry := plugin.NewRegistry()
+	registry.MustRegister(p)
+	if err := registry.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	event, err := registry.GetDecoderRegistry().Decode(counterData(42), nil)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if event.Name != "Counter" || event.ProgramID.String() != "11111111111111111111111111111111" {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if fields, ok := event.Data.(map[string]any); !ok || fields["value"] != float64(42) {
+		t.Errorf("event data = %#v, want value 42", event.Data)
+	}
+	cpiData := append(decoder.EventIxTag[:], counterData(43)...)
+	if event, err := p.GetDecoders()[0].Decode(cpiData); err != nil {
+		t.Errorf("Decode of emit_cpi! data: %v", err)
+	} else if fields, _ := event.Data.(map[string]any); fields["value"] != float64(43) {
+		t.Errorf("emit_cpi! event data = %#v, want value 43", event.Data)
+	}
+	if event, err := p.GetDecoders()[0].Decode(counterData(declineValue)); err == nil {
+		t.Errorf("expected declined data to fail, got %+v", event)
+	}
+
+	handled, err := p.ProcessEvent(ctx, event)
+	if err != nil || !handled {
+		t.Errorf("ProcessEvent = %v, %v, want handled", handled, err)
+	}
+
+	if err := registry.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if _, err := p.GetDecoders()[0].Decode(counterData(1)); err == nil {
+		t.Error("expected Decode to fail after Shutdown")
+	}
+}
+
+func TestParseHandshake(t *testing.T) {
+	h := handshake{network: "tcp", address: "127.0.0.1:1234"}
+	got, err := parseHandshake(h.String() + "\n")
+	if err != nil || got != h {
+		t.Fatalf("parseHandshake(%q) = %+v, %v, want %+v", h.String(), got, err, h)
+	}
+
+	for _, line := range []string{
+		"",
+		"1|1|tcp|127.0.0.1:1234",
+		"2|1|tcp|127.0.0.1:1234|grpc",
+		fmt.Sprintf("1|%d|tcp|127.0.0.1:1234|grpc", ProtocolVersion+1),
+		"1|1|unix|/tmp/plugin.sock|grpc",
+		"1|1|tcp|127.0.0.1:1234|netrpc",
+	} {
+		if _, err := parseHandshake(line); err == nil {
+			t.Errorf("parseHandshake(%q) succeeded, want error", line)
+		}
+	}
+}
+
+func TestPluginRestartsAfterCrash(t *testing.T) {
+	p := startTestPlugin(t)
+	if err := p.Initialize(context.Background()); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	counter := p.GetDecoders()[0]
+
+	crashAndRecover(t, counter)
+
+	if restarts := p.Restarts(); restarts != 1 {
+		t.Errorf("Restarts() = %d, want 1", restarts)
+	}
+}
+
+func TestPluginRestartsResetAfterStableInterval(t *testing.T) {
+	p := NewPlugin(os.Args[0]).
+		WithEnv(helperEnv+"=1").
+		WithRestartPolicy(1, 10*time.Millisecond).
+		WithStableInterval(100 * time.Millisecond)
+	if err := p.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer p.Shutdown(context.Background())
+	counter := p.GetDecoders()[0]
+
+	// The policy allows a single restart, but each crash follows a stable run.
+	for i := 0; i < 3; i++ {
+		time.Sleep(150 * time.Millisecond)
+		crashAndRecover(t, counter)
+	}
+
+	if restarts := p.Restarts(); restarts != 3 {
+		t.Errorf("Restarts() = %d, want 3", restarts)
+	}
+}
+
+// crashAndRecover crashes the test plugin and waits for it to decode again.
+func crashAndRecover(t *testing.T, counter decoder.Decoder) {
+	t.Helper()
+
+	if _, err := counter.Decode(counterData(crashValue)); err == nil {
+		t.Fatal("expected Decode to fail when the plugin crashes")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		event, err := counter.Decode(counterData(7))
+		if err == nil && event != nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("plugin did not restart: %v", err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
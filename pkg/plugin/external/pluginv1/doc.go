@@ -0,0 +1,6 @@
+// Package pluginv1 holds the gRPC service and messages of the external plugin
+// protocol, generated from plugin.proto. Plugins in other languages generate
+// their stubs from the same file.
+package pluginv1
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative plugin.proto
@@ -0,0 +1,190 @@
+// Package external runs go-carbon plugins as separate processes.
+//
+// An external plugin is an executable, written in any language, that serves
+// the gRPC service defined in pluginv1/plugin.proto, so its stubs can be
+// generated with the standard gRPC tooling of that language. The process
+// model follows hashicorp/go-plugin: the host launches the executable with
+// MagicCookieKey set in its environment, and the plugin starts a gRPC server
+// on a loopback address and announces it with a handshake line on its stdout:
+//
+//	1|1|tcp|127.0.0.1:51234|grpc
+//
+// The fields are CoreProtocolVersion, ProtocolVersion, the network and the
+// address of the server, and the RPC protocol. The host refuses plugins
+// reporting versions it does not speak, dials the address and reads the
+// plugin's Manifest with GetManifest. The rest of the plugin's stdout, and its
+// stderr, are forwarded to the host's logger.
+//
+// The host then calls Initialize, health-checks the plugin with the standard
+// grpc.health.v1.Health service for HealthServiceName, and finally calls
+// Shutdown. A plugin also exits when its stdin is closed, so it does not
+// outlive a crashed host.
+//
+// Decoded events cross the process boundary as JSON objects. Data sent to
+// decoders never starts with the Anchor event instruction tag
+// (decoder.EventIxTag): the host strips it, as it does before matching
+// discriminators.
+//
+// On the host, Plugin launches the executable and implements plugin.Plugin,
+// plugin.DecoderPlugin and plugin.EventProcessorPlugin, health-checking the
+// process and restarting it if it crashes. Go plugins can use Serve to
+// implement the protocol.
+package external
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/lugondev/go-carbon/pkg/plugin/external/pluginv1"
+)
+
+// CoreProtocolVersion is the version of the handshake and process model.
+const CoreProtocolVersion = 1
+
+// ProtocolVersion is the version of the pluginv1 service implemented by this
+// package.
+const ProtocolVersion = 1
+
+// MagicCookieKey and MagicCookieValue form the environment variable the host
+// sets when launching a plugin, so plugins can tell they were not started
+// directly by a user.
+const (
+	MagicCookieKey   = "CARBON_PLUGIN_MAGIC_COOKIE"
+	MagicCookieValue = "b7f3c1d2-carbon-plugin"
+)
+
+// HealthServiceName is the service name plugins report as serving through the
+// grpc.health.v1.Health service.
+const HealthServiceName = "plugin"
+
+// handshakeProtocol is the RPC protocol announced in the handshake line.
+const handshakeProtocol = "grpc"
+
+// handshake is the handshake line a plugin writes to its stdout.
+type handshake struct {
+	network string
+	address string
+}
+
+// String formats the handshake line, without a trailing newline.
+func (h handshake) String() string {
+	return fmt.Sprintf("%d|%d|%s|%s|%s", CoreProtocolVersion, ProtocolVersion, h.network, h.address, handshakeProtocol)
+}
+
+// parseHandshake parses a handshake line, checking its versions.
+func parseHandshake(line string) (handshake, error) {
+	parts := strings.Split(strings.TrimSpace(line), "|")
+	if len(parts) != 5 {
+		return handshake{}, fmt.Errorf("invalid handshake %q", line)
+	}
+
+	core, err := strconv.Atoi(parts[0])
+	if err != nil || core != CoreProtocolVersion {
+		return handshake{}, fmt.Errorf("plugin speaks core protocol version %s, want %d", parts[0], CoreProtocolVersion)
+	}
+	version, err := strconv.Atoi(parts[1])
+	if err != nil || version != ProtocolVersion {
+		return handshake{}, fmt.Errorf("plugin speaks protocol version %s, want %d", parts[1], ProtocolVersion)
+	}
+	if parts[2] != "tcp" {
+		return handshake{}, fmt.Errorf("plugin listens on unsupported network %q", parts[2])
+	}
+	if parts[4] != handshakeProtocol {
+		return handshake{}, fmt.Errorf("plugin speaks unsupported RPC protocol %q", parts[4])
+	}
+
+	return handshake{network: parts[2], address: parts[3]}, nil
+}
+
+// Manifest describes a plugin and what it provides.
+type Manifest struct {
+	Name        string
+	Version     string
+	Description string
+
+	// Decoders are the decoders the plugin provides.
+	Decoders []DecoderSpec
+
+	// ProcessesEvents reports whether the plugin processes events.
+	ProcessesEvents bool
+
+	// EventTypes are the names of the events the plugin processes. Empty means
+	// all events.
+	EventTypes []string
+}
+
+// DecoderSpec describes a decoder provided by a plugin.
+type DecoderSpec struct {
+	// Name is the decoder name, passed back to the plugin's decode handlers.
+	Name string
+
+	// ProgramID is the base58 program ID the decoder handles, or empty if it
+	// handles several programs.
+	ProgramID string
+
+	// Discriminator is the prefix of the data the decoder handles, e.g. an
+	// Anchor event discriminator. With a discriminator, the host dispatches
+	// data to the decoder without asking the plugin whether it can decode it.
+	Discriminator []byte
+}
+
+// DecodedEvent is an event decoded by a plugin.
+type DecodedEvent struct {
+	// Name is the event name.
+	Name string
+
+	// ProgramID is the base58 program ID of the event. Defaults to the
+	// decoder's program ID.
+	ProgramID string
+
+	// Data is the decoded event as a JSON object.
+	Data json.RawMessage
+}
+
+// EventMessage is a decoded event sent to a plugin to process.
+type EventMessage struct {
+	Name      string
+	ProgramID string
+	Data      json.RawMessage
+	RawData   []byte
+}
+
+// manifestToProto converts m to its protocol message.
+func manifestToProto(m Manifest) *pluginv1.Manifest {
+	msg := &pluginv1.Manifest{
+		Name:            m.Name,
+		Version:         m.Version,
+		Description:     m.Description,
+		ProcessesEvents: m.ProcessesEvents,
+		EventTypes:      m.EventTypes,
+	}
+	for _, spec := range m.Decoders {
+		msg.Decoders = append(msg.Decoders, &pluginv1.DecoderSpec{
+			Name:          spec.Name,
+			ProgramId:     spec.ProgramID,
+			Discriminator: spec.Discriminator,
+		})
+	}
+	return msg
+}
+
+// manifestFromProto converts a protocol message to a Manifest.
+func manifestFromProto(msg *pluginv1.Manifest) Manifest {
+	m := Manifest{
+		Name:            msg.GetName(),
+		Version:         msg.GetVersion(),
+		Description:     msg.GetDescription(),
+		ProcessesEvents: msg.GetProcessesEvents(),
+		EventTypes:      msg.GetEventTypes(),
+	}
+	for _, spec := range msg.GetDecoders() {
+		m.Decoders = append(m.Decoders, DecoderSpec{
+			Name:          spec.GetName(),
+			ProgramID:     spec.GetProgramId(),
+			Discriminator: spec.GetDiscriminator(),
+		})
+	}
+	return m
+}
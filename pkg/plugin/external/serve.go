@@ -0,0 +1,219 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+
+	"github.com/lugondev/go-carbon/pkg/plugin/external/pluginv1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Server implements the plugin side of the protocol for plugins written in Go.
+//
+// Handlers are called concurrently and are optional: a nil Decode decodes
+// nothing and a nil ProcessEvent handles no event.
+//
+// Example:
+//
+//	func main() {
+//	    err := external.Serve(&external.Server{
+//	        Manifest: external.Manifest{
+//	            Name:     "my-plugin",
+//	            Version:  "1.0.0",
+//	            Decoders: []external.DecoderSpec{{Name: "Swap", Discriminator: swapDiscriminator}},
+//	        },
+//	        Decode: func(decoder string, data []byte) (*external.DecodedEvent, error) {
+//	            return external.NewDecodedEvent("Swap", decodeSwap(data))
+//	        },
+//	    })
+//	    if err != nil {
+//	        log.Fatal(err)
+//	    }
+//	}
+type Server struct {
+	// Manifest describes the plugin. ProcessesEvents is set by the server.
+	Manifest Manifest
+
+	// Initialize is called by the Initialize RPC.
+	Initialize func(ctx context.Context) error
+
+	// CanDecode is called by the CanDecode RPC. Defaults to matching the data
+	// against the decoder's discriminator.
+	CanDecode func(decoder string, data []byte) bool
+
+	// Decode is called by the Decode RPC. It returns nil if the decoder cannot
+	// decode the data.
+	Decode func(decoder string, data []byte) (*DecodedEvent, error)
+
+	// ProcessEvent is called by the ProcessEvent RPC.
+	ProcessEvent func(ctx context.Context, event *EventMessage) (bool, error)
+
+	// Shutdown is called by the Shutdown RPC.
+	Shutdown func(ctx context.Context) error
+}
+
+// NewDecodedEvent returns a DecodedEvent named name, with data encoded as JSON.
+func NewDecodedEvent(name string, data any) (*DecodedEvent, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode event %s: %w", name, err)
+	}
+	return &DecodedEvent{Name: name, Data: raw}, nil
+}
+
+// Serve serves s until the host calls Shutdown or closes stdin. It fails if
+// the process was not launched by a host.
+func Serve(s *Server) error {
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		return errors.New("this binary is a go-carbon plugin and is launched by the plugin host, not directly")
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	return s.ServeListener(listener, os.Stdin, os.Stdout)
+}
+
+// ServeListener serves s on listener, writing the handshake line to stdout,
+// until the host calls Shutdown or stdin is closed.
+func (s *Server) ServeListener(listener net.Listener, stdin io.Reader, stdout io.Writer) error {
+	server := grpc.NewServer(
+		grpc.MaxRecvMsgSize(maxMessageSize),
+		grpc.MaxSendMsgSize(maxMessageSize),
+	)
+
+	var stopOnce sync.Once
+	stop := func(graceful bool) {
+		stopOnce.Do(func() {
+			if graceful {
+				server.GracefulStop()
+			} else {
+				server.Stop()
+			}
+		})
+	}
+
+	pluginv1.RegisterPluginServer(server, &pluginServer{
+		server: s,
+		// Stop once the Shutdown RPC has been answered.
+		stop: func() { go stop(true) },
+	})
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(HealthServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	go func() {
+		// The host closes stdin when it shuts down or exits.
+		_, _ = io.Copy(io.Discard, stdin)
+		stop(false)
+	}()
+
+	h := handshake{network: listener.Addr().Network(), address: listener.Addr().String()}
+	if _, err := fmt.Fprintln(stdout, h.String()); err != nil {
+		return fmt.Errorf("failed to write handshake: %w", err)
+	}
+	return server.Serve(listener)
+}
+
+// pluginServer implements the pluginv1.PluginServer with the handlers of a
+// Server.
+type pluginServer struct {
+	pluginv1.UnimplementedPluginServer
+
+	server *Server
+	stop   func()
+}
+
+// GetManifest implements pluginv1.PluginServer.
+func (p *pluginServer) GetManifest(context.Context, *emptypb.Empty) (*pluginv1.Manifest, error) {
+	manifest := p.server.Manifest
+	manifest.ProcessesEvents = p.server.ProcessEvent != nil
+	return manifestToProto(manifest), nil
+}
+
+// Initialize implements pluginv1.PluginServer.
+func (p *pluginServer) Initialize(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	if p.server.Initialize != nil {
+		if err := p.server.Initialize(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// CanDecode implements pluginv1.PluginServer.
+func (p *pluginServer) CanDecode(_ context.Context, req *pluginv1.DecodeRequest) (*pluginv1.CanDecodeResponse, error) {
+	if p.server.CanDecode != nil {
+		return &pluginv1.CanDecodeResponse{CanDecode: p.server.CanDecode(req.GetDecoder(), req.GetData())}, nil
+	}
+	return &pluginv1.CanDecodeResponse{CanDecode: p.matchesDiscriminator(req.GetDecoder(), req.GetData())}, nil
+}
+
+// Decode implements pluginv1.PluginServer.
+func (p *pluginServer) Decode(_ context.Context, req *pluginv1.DecodeRequest) (*pluginv1.DecodeResponse, error) {
+	if p.server.Decode == nil {
+		return &pluginv1.DecodeResponse{}, nil
+	}
+	event, err := p.server.Decode(req.GetDecoder(), req.GetData())
+	if err != nil {
+		return nil, err
+	}
+	if event == nil {
+		return &pluginv1.DecodeResponse{}, nil
+	}
+	return &pluginv1.DecodeResponse{Event: &pluginv1.DecodedEvent{
+		Name:      event.Name,
+		ProgramId: event.ProgramID,
+		Data:      event.Data,
+	}}, nil
+}
+
+// ProcessEvent implements pluginv1.PluginServer.
+func (p *pluginServer) ProcessEvent(ctx context.Context, req *pluginv1.Event) (*pluginv1.ProcessEventResponse, error) {
+	if p.server.ProcessEvent == nil {
+		return &pluginv1.ProcessEventResponse{}, nil
+	}
+	handled, err := p.server.ProcessEvent(ctx, &EventMessage{
+		Name:      req.GetName(),
+		ProgramID: req.GetProgramId(),
+		Data:      req.GetData(),
+		RawData:   req.GetRawData(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pluginv1.ProcessEventResponse{Handled: handled}, nil
+}
+
+// Shutdown implements pluginv1.PluginServer.
+func (p *pluginServer) Shutdown(ctx context.Context, _ *emptypb.Empty) (*emptypb.Empty, error) {
+	defer p.stop()
+	if p.server.Shutdown != nil {
+		if err := p.server.Shutdown(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// matchesDiscriminator reports whether data starts with the discriminator of
+// the named decoder.
+func (p *pluginServer) matchesDiscriminator(name string, data []byte) bool {
+	for _, spec := range p.server.Manifest.Decoders {
+		if spec.Name == name {
+			return len(spec.Discriminator) > 0 && bytes.HasPrefix(data, spec.Discriminator)
+		}
+	}
+	return false
+}
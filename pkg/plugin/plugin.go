@@ -4,7 +4,9 @@
 //   - Create custom event processors as plugins
 //   - Register decoders dynamically
 //   - Extend pipeline functionality without modifying core code
-//   - Load plugins at runtime
+//   - Load plugins at runtime as separate processes (see package external)
+//   - Load sandboxed WebAssembly decoders at runtime (see package wasm)
+//   - Subscribe typed handlers to decoded events with On and OnProgram
 //
 // Example plugin implementation:
 //
@@ -22,7 +24,11 @@ package plugin
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/lugondev/go-carbon/pkg/decoder"
@@ -80,24 +86,66 @@ type FullPlugin interface {
 	DecoderPlugin
 }
 
+// DependentPlugin is implemented by plugins that depend on other plugins, e.g.
+// on the decoders they provide. A plugin is initialized after and shut down
+// before the plugins it depends on.
+type DependentPlugin interface {
+	Plugin
+
+	// Dependencies returns the names of the plugins this plugin depends on.
+	Dependencies() []string
+}
+
+// State is the lifecycle state of a plugin in a Registry.
+type State string
+
+const (
+	// StateRegistered means the plugin is registered but not initialized.
+	StateRegistered State = "registered"
+
+	// StateInitialized means the plugin initialized successfully.
+	StateInitialized State = "initialized"
+
+	// StateFailed means the plugin failed to initialize or shut down.
+	StateFailed State = "failed"
+
+	// StateStopped means the plugin was shut down, either by Shutdown or by
+	// the rollback of a failed Initialize.
+	StateStopped State = "stopped"
+)
+
+// Capabilities of a plugin, derived from the interfaces it implements.
+const (
+	CapabilityDecoder        = "decoder"
+	CapabilityEventProcessor = "event_processor"
+)
+
 // PluginInfo contains metadata about a plugin.
 type PluginInfo struct {
-	Name        string
-	Version     string
-	Description string
-	Author      string
-	License     string
-	Tags        []string
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	Description  string   `json:"description"`
+	Author       string   `json:"author,omitempty"`
+	License      string   `json:"license,omitempty"`
+	Tags         []string `json:"tags,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"`
+	Dependencies []string `json:"dependencies,omitempty"`
+	State        State    `json:"state"`
+	Error        string   `json:"error,omitempty"`
 }
 
 // Registry manages plugin registration and lifecycle.
 type Registry struct {
 	mu                  sync.RWMutex
 	plugins             map[string]Plugin
+	states              map[string]State
+	failures            map[string]error
+	initOrder           []string // names of the initialized plugins, in initialization order
 	eventProcessors     []EventProcessorPlugin
 	decoderPlugins      []DecoderPlugin
 	decoderRegistry     *decoder.Registry
 	logParserProcessors []log.LogProcessor
+	subscriptions       map[reflect.Type][]subscription
 	initialized         bool
 }
 
@@ -105,10 +153,13 @@ type Registry struct {
 func NewRegistry() *Registry {
 	return &Registry{
 		plugins:             make(map[string]Plugin),
+		states:              make(map[string]State),
+		failures:            make(map[string]error),
 		eventProcessors:     make([]EventProcessorPlugin, 0),
 		decoderPlugins:      make([]DecoderPlugin, 0),
 		decoderRegistry:     decoder.NewRegistry(),
 		logParserProcessors: make([]log.LogProcessor, 0),
+		subscriptions:       make(map[reflect.Type][]subscription),
 	}
 }
 
@@ -123,6 +174,7 @@ func (r *Registry) Register(plugin Plugin) error {
 	}
 
 	r.plugins[name] = plugin
+	r.states[name] = StateRegistered
 
 	// Register event processor if applicable
 	if ep, ok := plugin.(EventProcessorPlugin); ok {
@@ -154,7 +206,12 @@ func (r *Registry) MustRegister(plugin Plugin) {
 	}
 }
 
-// Initialize initializes all registered plugins.
+// Initialize initializes all registered plugins, each after the plugins it
+// depends on.
+//
+// If a plugin fails to initialize, the plugins initialized before it are shut
+// down in reverse order, so the registry is left uninitialized and Initialize
+// may be called again.
 func (r *Registry) Initialize(ctx context.Context) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
@@ -163,27 +220,52 @@ func (r *Registry) Initialize(ctx context.Context) error {
 		return fmt.Errorf("registry already initialized")
 	}
 
-	for name, plugin := range r.plugins {
-		if err := plugin.Initialize(ctx); err != nil {
-			return fmt.Errorf("failed to initialize plugin %s: %w", name, err)
+	order, err := r.resolveOrder()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range order {
+		if err := r.plugins[name].Initialize(ctx); err != nil {
+			r.setState(name, StateFailed, err)
+			initErr := fmt.Errorf("failed to initialize plugin %s: %w", name, err)
+			if rollbackErr := r.shutdownInitialized(ctx); rollbackErr != nil {
+				return errors.Join(initErr, fmt.Errorf("rollback failed: %w", rollbackErr))
+			}
+			return initErr
 		}
+		r.setState(name, StateInitialized, nil)
+		r.initOrder = append(r.initOrder, name)
 	}
 
 	r.initialized = true
 	return nil
 }
 
-// Shutdown shuts down all registered plugins.
+// Shutdown shuts down all initialized plugins in reverse initialization order,
+// so each plugin is shut down before the plugins it depends on.
 func (r *Registry) Shutdown(ctx context.Context) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	r.initialized = false
+	return r.shutdownInitialized(ctx)
+}
+
+// shutdownInitialized shuts down the initialized plugins in reverse
+// initialization order. The caller must hold r.mu.
+func (r *Registry) shutdownInitialized(ctx context.Context) error {
 	var errs []error
-	for name, plugin := range r.plugins {
-		if err := plugin.Shutdown(ctx); err != nil {
+	for i := len(r.initOrder) - 1; i >= 0; i-- {
+		name := r.initOrder[i]
+		if err := r.plugins[name].Shutdown(ctx); err != nil {
+			r.setState(name, StateFailed, err)
 			errs = append(errs, fmt.Errorf("plugin %s shutdown error: %w", name, err))
+			continue
 		}
+		r.setState(name, StateStopped, nil)
 	}
+	r.initOrder = nil
 
 	if len(errs) > 0 {
 		return fmt.Errorf("shutdown errors: %v", errs)
@@ -192,6 +274,93 @@ func (r *Registry) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// resolveOrder returns the names of the registered plugins ordered so that
+// every plugin follows its dependencies. Plugins without dependencies between
+// them are ordered by name. The caller must hold r.mu.
+func (r *Registry) resolveOrder() ([]string, error) {
+	names := make([]string, 0, len(r.plugins))
+	for name := range r.plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	marks := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch marks[name] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("plugin dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		}
+
+		marks[name] = visiting
+		for _, dep := range dependencies(r.plugins[name]) {
+			if _, exists := r.plugins[dep]; !exists {
+				return fmt.Errorf("plugin %s depends on unregistered plugin %s", name, dep)
+			}
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		marks[name] = visited
+		order = append(order, name)
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// setState records the lifecycle state of a plugin. The caller must hold r.mu.
+func (r *Registry) setState(name string, state State, err error) {
+	r.states[name] = state
+	if err != nil {
+		r.failures[name] = err
+	} else {
+		delete(r.failures, name)
+	}
+}
+
+// State returns the lifecycle state of a plugin.
+func (r *Registry) State(name string) (State, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	state, exists := r.states[name]
+	return state, exists
+}
+
+// dependencies returns the dependencies declared by a plugin.
+func dependencies(plugin Plugin) []string {
+	if dp, ok := plugin.(DependentPlugin); ok {
+		return dp.Dependencies()
+	}
+	return nil
+}
+
+// capabilities returns the capabilities of a plugin.
+func capabilities(plugin Plugin) []string {
+	var caps []string
+	if _, ok := plugin.(DecoderPlugin); ok {
+		caps = append(caps, CapabilityDecoder)
+	}
+	if _, ok := plugin.(EventProcessorPlugin); ok {
+		caps = append(caps, CapabilityEventProcessor)
+	}
+	return caps
+}
+
 // Get retrieves a plugin by name.
 func (r *Registry) Get(name string) (Plugin, bool) {
 	r.mu.RLock()
@@ -227,9 +396,40 @@ func (r *Registry) GetLogProcessors() []log.LogProcessor {
 
 // ProcessEvent processes an event through all registered event processor plugins.
 func (r *Registry) ProcessEvent(ctx context.Context, event *decoder.Event) error {
+	return r.ProcessEventWithContext(ctx, event, EventContext{})
+}
+
+// ProcessEventWithContext processes an event emitted in the transaction
+// described by ec. The event is dispatched to the handlers subscribed with On
+// and OnProgram to the Go type of its data, then to the event processor
+// plugins handling its name. Unset fields of ec default to those of the
+// event.
+func (r *Registry) ProcessEventWithContext(ctx context.Context, event *decoder.Event, ec EventContext) error {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	if ec.Name == "" {
+		ec.Name = event.Name
+	}
+	if ec.ProgramID.IsZero() {
+		ec.ProgramID = event.ProgramID
+	}
+	if ec.Signature.IsZero() {
+		ec.Signature = event.Signature
+	}
+	if ec.Slot == 0 {
+		ec.Slot = event.Slot
+	}
+	if ec.BlockTime == nil {
+		ec.BlockTime = event.BlockTime
+	}
+	if ec.InstructionPath == nil {
+		ec.InstructionPath = event.InstructionPath
+	}
+	if err := r.dispatch(ctx, event, ec); err != nil {
+		return err
+	}
+
 	for _, processor := range r.eventProcessors {
 		// Check if processor handles this event type
 		eventTypes := processor.GetEventTypes()
@@ -255,19 +455,27 @@ func (r *Registry) ProcessEvent(ctx context.Context, event *decoder.Event) error
 	return nil
 }
 
-// ListPlugins returns information about all registered plugins.
+// ListPlugins returns information about all registered plugins, sorted by name.
 func (r *Registry) ListPlugins() []PluginInfo {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	infos := make([]PluginInfo, 0, len(r.plugins))
-	for _, plugin := range r.plugins {
-		infos = append(infos, PluginInfo{
-			Name:        plugin.Name(),
-			Version:     plugin.Version(),
-			Description: plugin.Description(),
-		})
+	for name, plugin := range r.plugins {
+		info := PluginInfo{
+			Name:         plugin.Name(),
+			Version:      plugin.Version(),
+			Description:  plugin.Description(),
+			Capabilities: capabilities(plugin),
+			Dependencies: dependencies(plugin),
+			State:        r.states[name],
+		}
+		if err := r.failures[name]; err != nil {
+			info.Error = err.Error()
+		}
+		infos = append(infos, info)
 	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
 
 	return infos
 }
@@ -275,9 +483,10 @@ func (r *Registry) ListPlugins() []PluginInfo {
 // BasePlugin provides a basic implementation of the Plugin interface.
 // Plugins can embed this to get default implementations.
 type BasePlugin struct {
-	name        string
-	version     string
-	description string
+	name         string
+	version      string
+	description  string
+	dependencies []string
 }
 
 // NewBasePlugin creates a new BasePlugin.
@@ -289,6 +498,17 @@ func NewBasePlugin(name, version, description string) *BasePlugin {
 	}
 }
 
+// WithDependencies declares the plugins this plugin depends on.
+func (p *BasePlugin) WithDependencies(names ...string) *BasePlugin {
+	p.dependencies = names
+	return p
+}
+
+// Dependencies implements DependentPlugin interface.
+func (p *BasePlugin) Dependencies() []string {
+	return p.dependencies
+}
+
 // Name implements Plugin interface.
 func (p *BasePlugin) Name() string {
 	return p.name
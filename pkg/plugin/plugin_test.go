@@ -0,0 +1,112 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// lifecyclePlugin records its Initialize and Shutdown calls into a shared log.
+type lifecyclePlugin struct {
+	*BasePlugin
+	calls   *[]string
+	initErr error
+}
+
+func newLifecyclePlugin(name string, calls *[]string, deps ...string) *lifecyclePlugin {
+	return &lifecyclePlugin{
+		BasePlugin: NewBasePlugin(name, "1.0.0", name).WithDependencies(deps...),
+		calls:      calls,
+	}
+}
+
+func (p *lifecyclePlugin) Initialize(context.Context) error {
+	*p.calls = append(*p.calls, "init "+p.Name())
+	return p.initErr
+}
+
+func (p *lifecyclePlugin) Shutdown(context.Context) error {
+	*p.calls = append(*p.calls, "shutdown "+p.Name())
+	return nil
+}
+
+func TestRegistryLifecycleFollowsDependencies(t *testing.T) {
+	var calls []string
+	registry := NewRegistry()
+	registry.MustRegister(newLifecyclePlugin("alerts", &calls, "amm", "tokens"))
+	registry.MustRegister(newLifecyclePlugin("amm", &calls, "tokens"))
+	registry.MustRegister(newLifecyclePlugin("tokens", &calls))
+
+	ctx := context.Background()
+	if err := registry.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	if state, _ := registry.State("amm"); state != StateInitialized {
+		t.Errorf("amm state = %s, want %s", state, StateInitialized)
+	}
+	if err := registry.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	want := []string{
+		"init tokens", "init amm", "init alerts",
+		"shutdown alerts", "shutdown amm", "shutdown tokens",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	for _, info := range registry.ListPlugins() {
+		if info.State != StateStopped {
+			t.Errorf("%s state = %s, want %s", info.Name, info.State, StateStopped)
+		}
+	}
+}
+
+func TestRegistryInitializeRollsBack(t *testing.T) {
+	var calls []string
+	failing := newLifecyclePlugin("amm", &calls, "tokens")
+	failing.initErr = errors.New("boom")
+
+	registry := NewRegistry()
+	registry.MustRegister(newLifecyclePlugin("tokens", &calls))
+	registry.MustRegister(failing)
+
+	if err := registry.Initialize(context.Background()); err == nil {
+		t.Fatal("expected Initialize to fail")
+	}
+
+	want := []string{"init tokens", "init amm", "shutdown tokens"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+	if state, _ := registry.State("tokens"); state != StateStopped {
+		t.Errorf("tokens state = %s, want %s", state, StateStopped)
+	}
+	infos := registry.ListPlugins()
+	if infos[0].Name != "amm" || infos[0].State != StateFailed || infos[0].Error != "boom" {
+		t.Errorf("unexpected amm info: %+v", infos[0])
+	}
+}
+
+func TestRegistryInitializeRejectsInvalidDependencies(t *testing.T) {
+	var calls []string
+
+	cyclic := NewRegistry()
+	cyclic.MustRegister(newLifecyclePlugin("a", &calls, "b"))
+	cyclic.MustRegister(newLifecyclePlugin("b", &calls, "a"))
+	if err := cyclic.Initialize(context.Background()); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected dependency cycle error, got %v", err)
+	}
+
+	missing := NewRegistry()
+	missing.MustRegister(newLifecyclePlugin("a", &calls, "missing"))
+	if err := missing.Initialize(context.Background()); err == nil || !strings.Contains(err.Error(), "unregistered") {
+		t.Errorf("expected missing dependency error, got %v", err)
+	}
+
+	if len(calls) != 0 {
+		t.Errorf("plugins initialized despite invalid dependencies: %v", calls)
+	}
+}
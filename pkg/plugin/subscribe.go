@@ -0,0 +1,118 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/pkg/decoder"
+	"github.com/lugondev/go-carbon/pkg/log"
+)
+
+// EventContext describes where a decoded event was emitted.
+type EventContext struct {
+	// Name is the event name set by the decoder.
+	Name string
+
+	// ProgramID is the program that emitted the event.
+	ProgramID solana.PublicKey
+
+	// Signature is the signature of the transaction that emitted the event.
+	Signature solana.Signature
+
+	// Slot is the slot of the transaction.
+	Slot uint64
+
+	// BlockTime is the Unix timestamp of the block, or nil if unknown.
+	BlockTime *int64
+
+	// InstructionPath is the path of the instruction that emitted the event.
+	InstructionPath log.InstructionPath
+}
+
+// Handler handles decoded events of type T.
+type Handler[T any] func(ctx context.Context, event *T, ec EventContext) error
+
+// subscription is a type-erased Handler, optionally scoped to a program.
+type subscription struct {
+	programID solana.PublicKey
+	handle    func(ctx context.Context, data any, ec EventContext) error
+}
+
+// On subscribes handler to events whose decoded data is a T or *T, emitted by
+// any program. If T is a pointer type, events whose data is the type it points
+// to are handled as well.
+//
+// Example:
+//
+//	plugin.On(registry, func(ctx context.Context, swap *SwapEvent, ec plugin.EventContext) error {
+//	    fmt.Println(ec.Signature, swap.AmountIn)
+//	    return nil
+//	})
+func On[T any](registry *Registry, handler Handler[T]) {
+	subscribe(registry, solana.PublicKey{}, handler)
+}
+
+// OnProgram subscribes handler to events whose decoded data is a T or *T,
+// emitted by programID.
+func OnProgram[T any](registry *Registry, programID solana.PublicKey, handler Handler[T]) {
+	subscribe(registry, programID, handler)
+}
+
+// subscribe registers handler for the events of type T of programID, or of any
+// program if programID is zero.
+func subscribe[T any](registry *Registry, programID solana.PublicKey, handler Handler[T]) {
+	sub := subscription{
+		programID: programID,
+		handle: func(ctx context.Context, data any, ec EventContext) error {
+			switch event := data.(type) {
+			case *T:
+				return handler(ctx, event, ec)
+			case T:
+				return handler(ctx, &event, ec)
+			default:
+				// T is a pointer type and data the value it points to
+				ptr := reflect.New(reflect.TypeOf(data))
+				ptr.Elem().Set(reflect.ValueOf(data))
+				if event, ok := ptr.Interface().(T); ok {
+					return handler(ctx, &event, ec)
+				}
+				return nil
+			}
+		},
+	}
+
+	// Key pointer types by their element type, as dispatch does
+	eventType := reflect.TypeFor[T]()
+	if eventType.Kind() == reflect.Pointer {
+		eventType = eventType.Elem()
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.subscriptions[eventType] = append(registry.subscriptions[eventType], sub)
+}
+
+// dispatch calls the handlers subscribed to the type and program of event.
+// The caller must hold r.mu.
+func (r *Registry) dispatch(ctx context.Context, event *decoder.Event, ec EventContext) error {
+	if event.Data == nil || len(r.subscriptions) == 0 {
+		return nil
+	}
+
+	eventType := reflect.TypeOf(event.Data)
+	if eventType.Kind() == reflect.Pointer {
+		eventType = eventType.Elem()
+	}
+
+	for _, sub := range r.subscriptions[eventType] {
+		if !sub.programID.IsZero() && sub.programID != ec.ProgramID {
+			continue
+		}
+		if err := sub.handle(ctx, event.Data, ec); err != nil {
+			return fmt.Errorf("handler for %s failed to process event: %w", eventType, err)
+		}
+	}
+	return nil
+}
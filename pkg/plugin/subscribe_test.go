@@ -0,0 +1,84 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/pkg/decoder"
+	"github.com/lugondev/go-carbon/pkg/log"
+)
+
+type swapEvent struct{ Amount uint64 }
+
+type depositEvent struct{ Amount uint64 }
+
+func TestOnDispatchesByTypeAndProgram(t *testing.T) {
+	amm := solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+	other := solana.MustPublicKeyFromBase58("11111111111111111111111111111111")
+
+	registry := NewRegistry()
+
+	var swaps []uint64
+	var contexts []EventContext
+	On(registry, func(_ context.Context, swap *swapEvent, ec EventContext) error {
+		swaps = append(swaps, swap.Amount)
+		contexts = append(contexts, ec)
+		return nil
+	})
+
+	var ammDeposits int
+	OnProgram(registry, amm, func(context.Context, *depositEvent, EventContext) error {
+		ammDeposits++
+		return nil
+	})
+
+	ctx := context.Background()
+	ec := EventContext{Slot: 42, InstructionPath: log.InstructionPath{0, 1}}
+
+	events := []*decoder.Event{
+		{Name: "Swap", ProgramID: amm, Data: &swapEvent{Amount: 1}},
+		{Name: "Swap", ProgramID: other, Data: swapEvent{Amount: 2}},
+		{Name: "Deposit", ProgramID: amm, Data: &depositEvent{}},
+		{Name: "Deposit", ProgramID: other, Data: &depositEvent{}},
+	}
+	for _, event := range events {
+		if err := registry.ProcessEventWithContext(ctx, event, ec); err != nil {
+			t.Fatalf("ProcessEventWithContext: %v", err)
+		}
+	}
+
+	if len(swaps) != 2 || swaps[0] != 1 || swaps[1] != 2 {
+		t.Errorf("swaps = %v, want [1 2]", swaps)
+	}
+	if contexts[0].Slot != 42 || contexts[0].ProgramID != amm || contexts[0].Name != "Swap" {
+		t.Errorf("unexpected event context: %+v", contexts[0])
+	}
+	if ammDeposits != 1 {
+		t.Errorf("ammDeposits = %d, want 1", ammDeposits)
+	}
+}
+
+func TestOnPointerType(t *testing.T) {
+	registry := NewRegistry()
+
+	var swaps []uint64
+	On(registry, func(_ context.Context, swap **swapEvent, _ EventContext) error {
+		swaps = append(swaps, (*swap).Amount)
+		return nil
+	})
+
+	ctx := context.Background()
+	for _, event := range []*decoder.Event{
+		{Name: "Swap", Data: &swapEvent{Amount: 1}},
+		{Name: "Swap", Data: swapEvent{Amount: 2}},
+	} {
+		if err := registry.ProcessEventWithContext(ctx, event, EventContext{}); err != nil {
+			t.Fatalf("ProcessEventWithContext: %v", err)
+		}
+	}
+
+	if len(swaps) != 2 || swaps[0] != 1 || swaps[1] != 2 {
+		t.Errorf("swaps = %v, want [1 2]", swaps)
+	}
+}
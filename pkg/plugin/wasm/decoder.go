@@ -0,0 +1,374 @@
+// Package wasm loads event decoders compiled to WebAssembly.
+//
+// WASM decoders run sandboxed in the pure-Go wazero runtime, with limits on
+// their memory and on the time of each call, so decoders for new programs can
+// be shipped, and third-party decoders run, without rebuilding the indexer.
+//
+// A decoder module exports its linear memory as "memory" and the functions:
+//
+//	alloc(size i32) -> i32                  allocates size bytes for the input
+//	can_decode(ptr i32, len i32) -> i32     1 if the input can be decoded
+//	decode(ptr i32, len i32) -> i64         the decoded JSON, packed as ptr<<32 | len
+//
+// and optionally dealloc(ptr i32, len i32), called to free the input and the
+// output once they are consumed. decode returns 0 if it cannot decode the
+// input. Its output is a JSON object such as:
+//
+//	{"name": "SwapEvent", "program_id": "<base58>", "data": {"amount_in": 100}}
+//
+// or {"error": "..."} if decoding failed. program_id is optional. Modules
+// built for WASI (e.g. with TinyGo) can run too, without filesystem, network,
+// arguments or environment; a reactor's _initialize function is called on
+// instantiation.
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/pkg/decoder"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+)
+
+// Defaults of a Decoder.
+const (
+	DefaultMemoryLimit = 16 << 20
+	DefaultTimeout     = 100 * time.Millisecond
+)
+
+// pageSize is the size of a WebAssembly memory page.
+const pageSize = 64 << 10
+
+// Functions exported by decoder modules.
+const (
+	exportAlloc     = "alloc"
+	exportDealloc   = "dealloc"
+	exportCanDecode = "can_decode"
+	exportDecode    = "decode"
+)
+
+var _ decoder.DiscriminatedDecoder = (*Decoder)(nil)
+
+// Decoder is a decoder.Decoder implemented by a WebAssembly module.
+//
+// Calls into the module are serialized. A module instance that traps, exceeds
+// its memory limit or times out is discarded and the next call runs on a new
+// instance.
+//
+// Example:
+//
+//	module, err := os.ReadFile("./decoders/amm.wasm")
+//	if err != nil {
+//	    return err
+//	}
+//	d := wasm.NewDecoder("SwapEvent", module).
+//	    WithProgramID(ammProgramID).
+//	    WithDiscriminator(swapDiscriminator)
+//	if err := d.Compile(ctx); err != nil {
+//	    return err
+//	}
+//	defer d.Close(ctx)
+//	registry.RegisterForProgram(ammProgramID, d)
+type Decoder struct {
+	name          string
+	module        []byte
+	programID     solana.PublicKey
+	discriminator []byte
+	memoryLimit   uint32
+	timeout       time.Duration
+
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+
+	mu       sync.Mutex
+	instance api.Module
+}
+
+// NewDecoder creates a Decoder named name running the WebAssembly module.
+// Compile it before use.
+func NewDecoder(name string, module []byte) *Decoder {
+	return &Decoder{
+		name:        name,
+		module:      module,
+		memoryLimit: DefaultMemoryLimit,
+		timeout:     DefaultTimeout,
+	}
+}
+
+// WithProgramID sets the program ID of the events the decoder handles.
+func (d *Decoder) WithProgramID(programID solana.PublicKey) *Decoder {
+	d.programID = programID
+	return d
+}
+
+// WithDiscriminator sets the prefix of the data the decoder handles. With a
+// discriminator, CanDecode matches it without calling into the module and the
+// decoder.Registry indexes the decoder by it.
+func (d *Decoder) WithDiscriminator(discriminator []byte) *Decoder {
+	d.discriminator = discriminator
+	return d
+}
+
+// WithMemoryLimit limits the memory of the module to limit bytes, rounded up
+// to whole 64 KiB pages.
+func (d *Decoder) WithMemoryLimit(limit uint32) *Decoder {
+	d.memoryLimit = limit
+	return d
+}
+
+// WithTimeout limits the time of each call into the module.
+func (d *Decoder) WithTimeout(timeout time.Duration) *Decoder {
+	d.timeout = timeout
+	return d
+}
+
+// Compile compiles and instantiates the module, checking it exports the
+// decoder functions.
+func (d *Decoder) Compile(ctx context.Context) error {
+	if d.runtime != nil {
+		return fmt.Errorf("decoder %s already compiled", d.name)
+	}
+
+	pages := (d.memoryLimit + pageSize - 1) / pageSize
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(pages).
+		WithCloseOnContextDone(true))
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return fmt.Errorf("failed to instantiate WASI for decoder %s: %w", d.name, err)
+	}
+
+	compiled, err := runtime.CompileModule(ctx, d.module)
+	if err != nil {
+		runtime.Close(ctx)
+		return fmt.Errorf("failed to compile decoder %s: %w", d.name, err)
+	}
+	for _, export := range []string{exportAlloc, exportCanDecode, exportDecode} {
+		if _, ok := compiled.ExportedFunctions()[export]; !ok {
+			runtime.Close(ctx)
+			return fmt.Errorf("decoder %s does not export %s", d.name, export)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.runtime = runtime
+	d.compiled = compiled
+	if _, err := d.instantiate(ctx); err != nil {
+		runtime.Close(ctx)
+		d.runtime = nil
+		return err
+	}
+	return nil
+}
+
+// Close releases the module and its runtime.
+func (d *Decoder) Close(ctx context.Context) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.runtime == nil {
+		return nil
+	}
+	err := d.runtime.Close(ctx)
+	d.runtime = nil
+	d.instance = nil
+	return err
+}
+
+// Decode implements decoder.Decoder. The event data is the decoded JSON object
+// as a map[string]any. Data the module declines to decode is an error.
+func (d *Decoder) Decode(data []byte) (*decoder.Event, error) {
+	var output struct {
+		Name      string          `json:"name"`
+		ProgramID string          `json:"program_id"`
+		Data      json.RawMessage `json:"data"`
+		Error     string          `json:"error"`
+	}
+
+	raw, err := d.callDecode(data)
+	if err != nil {
+		return nil, err
+	}
+	if raw == nil {
+		return nil, fmt.Errorf("decoder %s declined data", d.name)
+	}
+	if err := json.Unmarshal(raw, &output); err != nil {
+		return nil, fmt.Errorf("decoder %s returned invalid JSON: %w", d.name, err)
+	}
+	if output.Error != "" {
+		return nil, fmt.Errorf("decoder %s: %s", d.name, output.Error)
+	}
+
+	event := &decoder.Event{
+		Name:          output.Name,
+		RawData:       data,
+		ProgramID:     d.programID,
+		Discriminator: d.discriminator,
+	}
+	if event.Name == "" {
+		event.Name = d.name
+	}
+	if output.ProgramID != "" {
+		programID, err := solana.PublicKeyFromBase58(output.ProgramID)
+		if err != nil {
+			return nil, fmt.Errorf("decoder %s returned invalid program ID: %w", d.name, err)
+		}
+		event.ProgramID = programID
+	}
+	if len(output.Data) > 0 {
+		var fields map[string]any
+		if err := json.Unmarshal(output.Data, &fields); err != nil {
+			return nil, fmt.Errorf("decoder %s returned invalid data: %w", d.name, err)
+		}
+		event.Data = fields
+	}
+	return event, nil
+}
+
+// CanDecode implements decoder.Decoder.
+func (d *Decoder) CanDecode(data []byte) bool {
+	if len(d.discriminator) > 0 {
+		return bytes.HasPrefix(decoder.StripEventIxTag(data), d.discriminator)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	var ok bool
+	err := d.withInput(ctx, data, func(mod api.Module, ptr, size uint64) error {
+		results, err := mod.ExportedFunction(exportCanDecode).Call(ctx, ptr, size)
+		if err != nil {
+			return err
+		}
+		ok = api.DecodeI32(results[0]) != 0
+		return nil
+	})
+	return err == nil && ok
+}
+
+// GetName implements decoder.Decoder.
+func (d *Decoder) GetName() string {
+	return d.name
+}
+
+// GetProgramID implements decoder.Decoder.
+func (d *Decoder) GetProgramID() solana.PublicKey {
+	return d.programID
+}
+
+// Discriminator implements decoder.DiscriminatedDecoder.
+func (d *Decoder) Discriminator() []byte {
+	return d.discriminator
+}
+
+// callDecode calls decode and returns a copy of its output, or nil if the
+// module cannot decode data.
+func (d *Decoder) callDecode(data []byte) ([]byte, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+	defer cancel()
+
+	var output []byte
+	err := d.withInput(ctx, data, func(mod api.Module, ptr, size uint64) error {
+		results, err := mod.ExportedFunction(exportDecode).Call(ctx, ptr, size)
+		if err != nil {
+			return err
+		}
+		if results[0] == 0 {
+			return nil
+		}
+
+		outPtr, outLen := uint32(results[0]>>32), uint32(results[0])
+		view, ok := mod.Memory().Read(outPtr, outLen)
+		if !ok {
+			return fmt.Errorf("output [%d, %d) out of memory bounds", outPtr, outPtr+outLen)
+		}
+		output = bytes.Clone(view)
+		d.dealloc(ctx, mod, uint64(outPtr), uint64(outLen))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decoder %s failed: %w", d.name, err)
+	}
+	return output, nil
+}
+
+// withInput copies data into the memory of the module instance and calls fn
+// with its address and size. The instance is discarded if fn fails. The caller
+// must hold d.mu.
+func (d *Decoder) withInput(ctx context.Context, data []byte, fn func(mod api.Module, ptr, size uint64) error) error {
+	mod, err := d.instantiate(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = func() error {
+		size := uint64(len(data))
+		results, err := mod.ExportedFunction(exportAlloc).Call(ctx, size)
+		if err != nil {
+			return err
+		}
+		ptr := uint32(results[0])
+		if !mod.Memory().Write(ptr, data) {
+			return fmt.Errorf("input [%d, %d) out of memory bounds", ptr, uint64(ptr)+size)
+		}
+		if err := fn(mod, uint64(ptr), size); err != nil {
+			return err
+		}
+		d.dealloc(ctx, mod, uint64(ptr), size)
+		return nil
+	}()
+	if err != nil {
+		// The instance may be closed or in an inconsistent state.
+		_ = mod.Close(context.Background())
+		d.instance = nil
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("timed out after %s: %w", d.timeout, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// dealloc frees memory of the module if it exports dealloc.
+func (d *Decoder) dealloc(ctx context.Context, mod api.Module, ptr, size uint64) {
+	if fn := mod.ExportedFunction(exportDealloc); fn != nil {
+		_, _ = fn.Call(ctx, ptr, size)
+	}
+}
+
+// instantiate returns the module instance, instantiating it if needed. The
+// caller must hold d.mu.
+func (d *Decoder) instantiate(ctx context.Context) (api.Module, error) {
+	if d.runtime == nil {
+		return nil, fmt.Errorf("decoder %s is not compiled", d.name)
+	}
+	if d.instance != nil && !d.instance.IsClosed() {
+		return d.instance, nil
+	}
+
+	mod, err := d.runtime.InstantiateModule(ctx, d.compiled, wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate decoder %s: %w", d.name, err)
+	}
+	d.instance = mod
+	return mod, nil
+}
@@ -0,0 +1,131 @@
+package wasm
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gagliardetto/solana-go"
+	"github.com/lugondev/go-carbon/pkg/plugin"
+)
+
+// testOutput is the JSON the test module returns for inputs starting with 1.
+const testOutput = `{"name":"Ping","data":{"ok":true}}`
+
+// testModule assembles a decoder module that, by the first byte of its input:
+// 1 decodes to testOutput, 2 loops forever and 3 grows its memory by 16 pages,
+// trapping if that fails. It can decode inputs starting with 1.
+func testModule() []byte {
+	section := func(id byte, content ...byte) []byte {
+		return append([]byte{id, byte(len(content))}, content...)
+	}
+	body := func(code ...byte) []byte {
+		return append([]byte{byte(len(code) + 1), 0}, code...)
+	}
+	name := func(s string) []byte { return append([]byte{byte(len(s))}, s...) }
+	firstByteIs := func(b byte) []byte { return []byte{0x20, 0, 0x2d, 0, 0, 0x41, b, 0x46} }
+
+	alloc := body(0x23, 0, 0x23, 0, 0x20, 0, 0x6a, 0x24, 0, 0x0b)
+	canDecode := body(append(firstByteIs(1), 0x0b)...)
+
+	var decode []byte
+	decode = append(decode, firstByteIs(2)...)
+	decode = append(decode, 0x04, 0x40, 0x03, 0x40, 0x0c, 0, 0x0b, 0x0b)
+	decode = append(decode, firstByteIs(3)...)
+	decode = append(decode, 0x04, 0x40, 0x41, 16, 0x40, 0, 0x41, 0x7f, 0x46, 0x04, 0x40, 0, 0x0b, 0x0b)
+	decode = append(decode, firstByteIs(1)...)
+	decode = append(decode, 0x04, 0x7e, 0x42, byte(len(testOutput)), 0x05, 0x42, 0, 0x0b, 0x0b)
+
+	var exports []byte
+	exports = append(exports, 4)
+	exports = append(append(exports, name("memory")...), 2, 0)
+	exports = append(append(exports, name("alloc")...), 0, 0)
+	exports = append(append(exports, name("can_decode")...), 0, 1)
+	exports = append(append(exports, name("decode")...), 0, 2)
+
+	var code []byte
+	code = append(code, 3)
+	code = append(code, alloc...)
+	code = append(code, canDecode...)
+	code = append(code, body(decode...)...)
+
+	data := append([]byte{1, 0, 0x41, 0, 0x0b, byte(len(testOutput))}, testOutput...)
+
+	var module []byte
+	module = append(module, 0, 'a', 's', 'm', 1, 0, 0, 0)
+	module = append(module, section(1, 3,
+		0x60, 1, 0x7f, 1, 0x7f,
+		0x60, 2, 0x7f, 0x7f, 1, 0x7f,
+		0x60, 2, 0x7f, 0x7f, 1, 0x7e)...)
+	module = append(module, section(3, 3, 0, 1, 2)...)
+	module = append(module, section(5, 1, 0, 1)...)
+	module = append(module, section(6, 1, 0x7f, 1, 0x41, 0x80, 0x08, 0x0b)...)
+	module = append(module, section(7, exports...)...)
+	module = append(module, section(10, code...)...)
+	module = append(module, section(11, data...)...)
+	return module
+}
+
+func TestDecoderDecodesThroughModule(t *testing.T) {
+	ctx := context.Background()
+	programID := solana.MustPublicKeyFromBase58("TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA")
+
+	p := NewPlugin("wasm-test", "1.0.0", "test decoders",
+		NewDecoder("Ping", testModule()).WithProgramID(programID),
+	)
+	registry := plugin.NewRegistry()
+	registry.MustRegister(p)
+	if err := registry.Initialize(ctx); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	defer registry.Shutdown(ctx)
+
+	event, err := registry.GetDecoderRegistry().Decode([]byte{1, 9, 9}, &programID)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if event.Name != "Ping" || event.ProgramID != programID {
+		t.Errorf("unexpected event: %+v", event)
+	}
+	if fields, ok := event.Data.(map[string]any); !ok || fields["ok"] != true {
+		t.Errorf("event data = %#v, want ok true", event.Data)
+	}
+
+	ping := p.GetDecoders()[0]
+	if !ping.CanDecode([]byte{1}) || ping.CanDecode([]byte{7}) {
+		t.Error("CanDecode should accept only inputs starting with 1")
+	}
+	if event, err := ping.Decode([]byte{7}); err == nil {
+		t.Errorf("expected Decode of declined data to fail, got %+v", event)
+	}
+}
+
+func TestDecoderEnforcesLimits(t *testing.T) {
+	ctx := context.Background()
+
+	d := NewDecoder("Ping", testModule()).
+		WithMemoryLimit(pageSize).
+		WithTimeout(50 * time.Millisecond)
+	if err := d.Compile(ctx); err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	defer d.Close(ctx)
+
+	start := time.Now()
+	if _, err := d.Decode([]byte{2}); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("looping module ran for %s", elapsed)
+	}
+
+	if _, err := d.Decode([]byte{3}); err == nil {
+		t.Error("expected growing memory past the limit to fail")
+	}
+
+	// A fresh instance replaces the one that failed.
+	if event, err := d.Decode([]byte{1}); err != nil || event == nil || event.Name != "Ping" {
+		t.Errorf("Decode after failures = %+v, %v", event, err)
+	}
+}
@@ -0,0 +1,62 @@
+package wasm
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lugondev/go-carbon/pkg/decoder"
+	"github.com/lugondev/go-carbon/pkg/plugin"
+)
+
+// Plugin is a plugin.DecoderPlugin providing WASM decoders. It compiles them
+// on Initialize and closes them on Shutdown.
+//
+// Example:
+//
+//	p := wasm.NewPlugin("amm-decoders", "1.0.0", "AMM event decoders",
+//	    wasm.NewDecoder("SwapEvent", swapModule).WithDiscriminator(swapDiscriminator),
+//	)
+//	registry.MustRegister(p)
+//	registry.Initialize(ctx)
+type Plugin struct {
+	*plugin.DecoderPluginBase
+	decoders []*Decoder
+}
+
+var _ plugin.DecoderPlugin = (*Plugin)(nil)
+
+// NewPlugin creates a Plugin providing decoders.
+func NewPlugin(name, version, description string, decoders ...*Decoder) *Plugin {
+	generic := make([]decoder.Decoder, len(decoders))
+	for i, d := range decoders {
+		generic[i] = d
+	}
+	return &Plugin{
+		DecoderPluginBase: plugin.NewDecoderPlugin(name, version, description, generic, nil),
+		decoders:          decoders,
+	}
+}
+
+// Initialize implements plugin.Plugin by compiling the decoders.
+func (p *Plugin) Initialize(ctx context.Context) error {
+	for i, d := range p.decoders {
+		if err := d.Compile(ctx); err != nil {
+			for _, compiled := range p.decoders[:i] {
+				_ = compiled.Close(ctx)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Shutdown implements plugin.Plugin by closing the decoders.
+func (p *Plugin) Shutdown(ctx context.Context) error {
+	var errs []error
+	for _, d := range p.decoders {
+		if err := d.Close(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
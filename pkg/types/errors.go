@@ -0,0 +1,287 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// InstructionErrorKindCustom is the InstructionError kind for program-defined error codes.
+const InstructionErrorKindCustom = "Custom"
+
+// TransactionError is a structured representation of the error a failed
+// transaction reports in its status metadata.
+type TransactionError struct {
+	// Kind is the transaction error variant, e.g. "InstructionError" or
+	// "InsufficientFundsForFee".
+	Kind string `json:"kind"`
+
+	// Instruction is set when Kind is "InstructionError".
+	Instruction *InstructionError `json:"instruction,omitempty"`
+
+	// Raw is the original error value as reported by the RPC.
+	Raw any `json:"raw,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *TransactionError) Error() string {
+	if e.Instruction != nil {
+		return e.Instruction.Error()
+	}
+	return "transaction error: " + e.Kind
+}
+
+// Unwrap returns the instruction error, if any.
+func (e *TransactionError) Unwrap() error {
+	if e.Instruction == nil {
+		return nil
+	}
+	return e.Instruction
+}
+
+// InstructionError describes the instruction that caused a transaction to fail.
+type InstructionError struct {
+	// Index is the index of the failing top-level instruction.
+	Index uint8 `json:"index"`
+
+	// Kind is the instruction error variant, e.g. "Custom" or "InvalidArgument".
+	Kind string `json:"kind"`
+
+	// Code is the program error code when Kind is "Custom".
+	Code *uint32 `json:"code,omitempty"`
+
+	// ProgramID is the program that returned the error, if it could be resolved.
+	ProgramID *Pubkey `json:"program_id,omitempty"`
+
+	// Name is the program error name from the program's IDL, if registered.
+	Name string `json:"name,omitempty"`
+
+	// Message is the program error message from the program's IDL, if registered.
+	Message string `json:"message,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *InstructionError) Error() string {
+	msg := fmt.Sprintf("instruction %d failed: %s", e.Index, e.Kind)
+	if e.Code != nil {
+		msg = fmt.Sprintf("%s(%d)", msg, *e.Code)
+	}
+	if e.Name != "" {
+		msg = fmt.Sprintf("%s %s", msg, e.Name)
+	}
+	if e.Message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Message)
+	}
+	return msg
+}
+
+// IsCustom reports whether this is a program-defined error.
+func (e *InstructionError) IsCustom() bool {
+	return e.Kind == InstructionErrorKindCustom && e.Code != nil
+}
+
+// ParseTransactionError converts an RPC transaction error value into a TransactionError.
+//
+// It accepts the decoded JSON forms returned by Solana RPC, such as the string
+// "AccountInUse", the object {"InstructionError": [0, {"Custom": 6001}]} or
+// {"InstructionError": [1, "InvalidArgument"]}. Returns nil if raw is nil.
+func ParseTransactionError(raw any) *TransactionError {
+	if raw == nil {
+		return nil
+	}
+
+	switch v := raw.(type) {
+	case *TransactionError:
+		return v
+	case string:
+		return &TransactionError{Kind: v, Raw: raw}
+	case json.RawMessage:
+		var decoded any
+		if err := json.Unmarshal(v, &decoded); err != nil {
+			return &TransactionError{Kind: string(v), Raw: raw}
+		}
+		parsed := ParseTransactionError(decoded)
+		if parsed != nil {
+			parsed.Raw = raw
+		}
+		return parsed
+	case map[string]any:
+		kind := firstKey(v)
+		result := &TransactionError{Kind: kind, Raw: raw}
+		if kind == "InstructionError" {
+			result.Instruction = parseInstructionError(v[kind])
+		}
+		return result
+	default:
+		return &TransactionError{Kind: fmt.Sprintf("%v", v), Raw: raw}
+	}
+}
+
+// parseInstructionError parses the [index, error] pair of an InstructionError.
+func parseInstructionError(raw any) *InstructionError {
+	pair, ok := raw.([]any)
+	if !ok || len(pair) != 2 {
+		return nil
+	}
+
+	index, ok := toUint64(pair[0])
+	if !ok {
+		return nil
+	}
+
+	result := &InstructionError{Index: uint8(index)}
+	switch detail := pair[1].(type) {
+	case string:
+		result.Kind = detail
+	case map[string]any:
+		result.Kind = firstKey(detail)
+		if result.Kind == InstructionErrorKindCustom {
+			if code, ok := toUint64(detail[result.Kind]); ok {
+				c := uint32(code)
+				result.Code = &c
+			}
+		}
+	default:
+		result.Kind = fmt.Sprintf("%v", detail)
+	}
+
+	return result
+}
+
+// firstKey returns the lexically first key of a map, which for Rust enum
+// encodings is the only key.
+func firstKey(m map[string]any) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		return ""
+	}
+	return keys[0]
+}
+
+// toUint64 converts a JSON number to uint64.
+func toUint64(v any) (uint64, bool) {
+	switch n := v.(type) {
+	case float64:
+		if n < 0 {
+			return 0, false
+		}
+		return uint64(n), true
+	case json.Number:
+		i, err := n.Int64()
+		if err != nil || i < 0 {
+			return 0, false
+		}
+		return uint64(i), true
+	case int:
+		return uint64(n), n >= 0
+	case int64:
+		return uint64(n), n >= 0
+	case uint8:
+		return uint64(n), true
+	case uint32:
+		return uint64(n), true
+	case uint64:
+		return n, true
+	default:
+		return 0, false
+	}
+}
+
+// GetInstructionError returns the InstructionError in err's chain, if any.
+func GetInstructionError(err error) (*InstructionError, bool) {
+	var ixErr *InstructionError
+	if errors.As(err, &ixErr) {
+		return ixErr, true
+	}
+	return nil, false
+}
+
+// ProgramError describes a program-defined error from a program's IDL.
+type ProgramError struct {
+	Code    uint32
+	Name    string
+	Message string
+}
+
+// ProgramErrorRegistry maps custom program error codes to their IDL names.
+type ProgramErrorRegistry struct {
+	mu     sync.RWMutex
+	errors map[Pubkey]map[uint32]ProgramError
+}
+
+// NewProgramErrorRegistry creates a new empty ProgramErrorRegistry.
+func NewProgramErrorRegistry() *ProgramErrorRegistry {
+	return &ProgramErrorRegistry{
+		errors: make(map[Pubkey]map[uint32]ProgramError),
+	}
+}
+
+// Register registers program errors for a program.
+func (r *ProgramErrorRegistry) Register(programID Pubkey, errs ...ProgramError) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byCode, exists := r.errors[programID]
+	if !exists {
+		byCode = make(map[uint32]ProgramError, len(errs))
+		r.errors[programID] = byCode
+	}
+	for _, e := range errs {
+		byCode[e.Code] = e
+	}
+}
+
+// RegisterNames registers program errors from the ErrorNames and ErrorMessages
+// maps emitted by the code generator. messages may be nil.
+func (r *ProgramErrorRegistry) RegisterNames(programID Pubkey, names map[uint32]string, messages map[uint32]string) {
+	errs := make([]ProgramError, 0, len(names))
+	for code, name := range names {
+		errs = append(errs, ProgramError{
+			Code:    code,
+			Name:    name,
+			Message: messages[code],
+		})
+	}
+	r.Register(programID, errs...)
+}
+
+// Lookup returns the program error registered for a program and code.
+func (r *ProgramErrorRegistry) Lookup(programID Pubkey, code uint32) (ProgramError, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, exists := r.errors[programID][code]
+	return e, exists
+}
+
+// Resolve attributes a custom instruction error to programID and fills in its
+// IDL name and message when the program's errors are registered.
+func (r *ProgramErrorRegistry) Resolve(ixErr *InstructionError, programID Pubkey) {
+	if ixErr == nil {
+		return
+	}
+
+	ixErr.ProgramID = &programID
+	if !ixErr.IsCustom() {
+		return
+	}
+
+	if e, exists := r.Lookup(programID, *ixErr.Code); exists {
+		ixErr.Name = e.Name
+		ixErr.Message = e.Message
+	}
+}
+
+// DefaultProgramErrors is the default global program error registry.
+var DefaultProgramErrors = NewProgramErrorRegistry()
+
+// RegisterProgramErrors registers generated error maps in the default registry.
+func RegisterProgramErrors(programID Pubkey, names map[uint32]string, messages map[uint32]string) {
+	DefaultProgramErrors.RegisterNames(programID, names, messages)
+}
@@ -0,0 +1,36 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseTransactionError(t *testing.T) {
+	txErr := ParseTransactionError(json.RawMessage(`{"InstructionError":[2,{"Custom":6001}]}`))
+	if txErr == nil || txErr.Instruction == nil {
+		t.Fatalf("expected instruction error, got %+v", txErr)
+	}
+
+	ixErr := txErr.Instruction
+	if ixErr.Index != 2 || !ixErr.IsCustom() || *ixErr.Code != 6001 {
+		t.Errorf("unexpected instruction error: %+v", ixErr)
+	}
+
+	var programID Pubkey
+	programID[0] = 1
+	registry := NewProgramErrorRegistry()
+	registry.RegisterNames(programID, map[uint32]string{6001: "SlippageExceeded"}, nil)
+	registry.Resolve(ixErr, programID)
+
+	if ixErr.Name != "SlippageExceeded" || ixErr.ProgramID == nil || *ixErr.ProgramID != programID {
+		t.Errorf("unexpected resolved error: %+v", ixErr)
+	}
+
+	if got, ok := GetInstructionError(txErr); !ok || got != ixErr {
+		t.Error("expected GetInstructionError to unwrap the instruction error")
+	}
+
+	if txErr := ParseTransactionError("AccountInUse"); txErr.Kind != "AccountInUse" || txErr.Instruction != nil {
+		t.Errorf("unexpected string error: %+v", txErr)
+	}
+}